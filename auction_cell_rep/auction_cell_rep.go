@@ -123,13 +123,18 @@ func (a *AuctionCellRep) State() (auctiontypes.CellState, error) {
 		lrps = append(lrps, lrp)
 	}
 
+	// The cell state has no way to describe a partial evacuation, so only an
+	// evacuation of the whole cell takes it out of the auction. A partially
+	// evacuating cell keeps being offered work, and Perform hands the work
+	// within the scope back for the auctioneer to place elsewhere. The scope
+	// itself is advertised in the cell presence.
 	state := auctiontypes.CellState{
 		RootFSProviders:    a.rootFSProviders,
 		AvailableResources: availableResources,
 		TotalResources:     totalResources,
 		LRPs:               lrps,
 		Zone:               a.zone,
-		Evacuating:         a.evacuationReporter.Evacuating(),
+		Evacuating:         a.evacuationReporter.Evacuating() && a.evacuationReporter.EvacuationScope().All(),
 	}
 
 	a.logger.Info("provided", lager.Data{
@@ -152,7 +157,22 @@ func (a *AuctionCellRep) Perform(work auctiontypes.Work) (auctiontypes.Work, err
 	})
 
 	if a.evacuationReporter.Evacuating() {
-		return work, nil
+		scope := a.evacuationReporter.EvacuationScope()
+		if scope.All() {
+			return work, nil
+		}
+
+		evacuatingProcesses, err := a.evacuatingProcessGuids(scope)
+		if err != nil {
+			logger.Error("failed-to-fetch-containers", err)
+			return work, nil
+		}
+
+		work, failedWork = splitEvacuatingWork(work, scope, evacuatingProcesses)
+		logger.Info("refused-work-within-evacuation-scope", lager.Data{
+			"lrp-starts": len(failedWork.LRPs),
+			"tasks":      len(failedWork.Tasks),
+		})
 	}
 
 	if len(work.LRPs) > 0 {
@@ -160,12 +180,12 @@ func (a *AuctionCellRep) Perform(work auctiontypes.Work) (auctiontypes.Work, err
 		lrpLogger.Info("allocating")
 		containers, lrpAuctionMap, err := a.lrpsToContainers(work.LRPs)
 		if err != nil {
-			failedWork.LRPs = work.LRPs
+			failedWork.LRPs = append(failedWork.LRPs, work.LRPs...)
 			lrpLogger.Info("failed-to-allocate")
 		} else {
 			errMessageMap, err := a.client.AllocateContainers(containers)
 			if err != nil {
-				failedWork.LRPs = work.LRPs
+				failedWork.LRPs = append(failedWork.LRPs, work.LRPs...)
 			} else {
 				for guid, lrpStart := range lrpAuctionMap {
 					if _, found := errMessageMap[guid]; found {
@@ -184,7 +204,7 @@ func (a *AuctionCellRep) Perform(work auctiontypes.Work) (auctiontypes.Work, err
 
 		errMessageMap, err := a.client.AllocateContainers(containers)
 		if err != nil {
-			failedWork.Tasks = work.Tasks
+			failedWork.Tasks = append(failedWork.Tasks, work.Tasks...)
 			taskLogger.Info("failed-to-allocate")
 		} else {
 			for _, task := range work.Tasks {
@@ -296,9 +316,15 @@ func (a *AuctionCellRep) tasksToContainers(tasks []models.Task) []executor.Conta
 
 			Action: task.Action,
 
-			Env:         executor.EnvironmentVariablesFromModel(task.EnvironmentVariables),
+			Env:         executor.EnvironmentVariablesFromModel(rep.WithoutDiagnosticFiles(task.EnvironmentVariables)),
 			EgressRules: task.EgressRules,
 		}
+
+		diagnosticFiles := rep.DiagnosticFilesFromEnv(task.EnvironmentVariables)
+		if diagnosticFiles != "" {
+			container.Tags[rep.DiagnosticFilesTag] = diagnosticFiles
+		}
+
 		containers = append(containers, container)
 	}
 
@@ -327,3 +353,50 @@ func (a *AuctionCellRep) fetchResourcesVia(fetcher func() (executor.ExecutorReso
 		Containers: resources.Containers,
 	}, nil
 }
+
+// evacuatingProcessGuids lists the processes with instances on the cell within
+// the scope. Auctioned LRPs have no instance guid yet, so the cell refuses
+// every instance of these processes lest a replacement land back on it.
+func (a *AuctionCellRep) evacuatingProcessGuids(scope evacuation_context.Scope) (map[string]struct{}, error) {
+	containers, err := a.client.ListContainers(executor.Tags{
+		rep.LifecycleTag: rep.LRPLifecycle,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	processGuids := make(map[string]struct{})
+	for _, container := range containers {
+		if scope.MatchesContainer(container) {
+			processGuids[container.Tags[rep.ProcessGuidTag]] = struct{}{}
+		}
+	}
+
+	return processGuids, nil
+}
+
+// splitEvacuatingWork separates the work falling within a partial evacuation,
+// which the cell refuses, from the work it may still perform.
+func splitEvacuatingWork(work auctiontypes.Work, scope evacuation_context.Scope, evacuatingProcesses map[string]struct{}) (auctiontypes.Work, auctiontypes.Work) {
+	accepted := auctiontypes.Work{}
+	refused := auctiontypes.Work{}
+
+	for _, lrp := range work.LRPs {
+		_, evacuating := evacuatingProcesses[lrp.DesiredLRP.ProcessGuid]
+		if evacuating || scope.Matches(lrp.DesiredLRP.Domain, lrp.DesiredLRP.ProcessGuid, "") {
+			refused.LRPs = append(refused.LRPs, lrp)
+		} else {
+			accepted.LRPs = append(accepted.LRPs, lrp)
+		}
+	}
+
+	for _, task := range work.Tasks {
+		if scope.Matches(task.Domain, "", "") {
+			refused.Tasks = append(refused.Tasks, task)
+		} else {
+			accepted.Tasks = append(accepted.Tasks, task)
+		}
+	}
+
+	return accepted, refused
+}
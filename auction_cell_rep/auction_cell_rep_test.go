@@ -8,6 +8,7 @@ import (
 	fake_client "github.com/cloudfoundry-incubator/executor/fakes"
 	"github.com/cloudfoundry-incubator/rep"
 	"github.com/cloudfoundry-incubator/rep/auction_cell_rep"
+	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context"
 	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context/fake_evacuation_context"
 	"github.com/cloudfoundry-incubator/runtime-schema/bbs/fake_bbs"
 	"github.com/cloudfoundry-incubator/runtime-schema/models"
@@ -102,6 +103,18 @@ var _ = Describe("AuctionCellRep", func() {
 			client.ListContainersReturns(containers, nil)
 		})
 
+		Context("when only part of the cell is evacuating", func() {
+			BeforeEach(func() {
+				evacuationReporter.EvacuationScopeReturns(evacuation_context.Scope{Domains: []string{"domain"}})
+			})
+
+			It("does not report the cell as evacuating, so that it is still auctioned work outside the scope", func() {
+				state, err := cellRep.State()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(state.Evacuating).Should(BeFalse())
+			})
+		})
+
 		It("queries the client and returns state", func() {
 			state, err := cellRep.State()
 			Ω(err).ShouldNot(HaveOccurred())
@@ -225,6 +238,76 @@ var _ = Describe("AuctionCellRep", func() {
 			It("returns all work it was given", func() {
 				Ω(cellRep.Perform(work)).Should(Equal(work))
 			})
+
+			Context("when only part of the cell is evacuating", func() {
+				var otherLRPAuction auctiontypes.LRPAuction
+
+				BeforeEach(func() {
+					evacuationReporter.EvacuationScopeReturns(evacuation_context.Scope{Domains: []string{"tests"}})
+
+					otherLRPAuction = lrpAuction
+					otherLRPAuction.DesiredLRP.Domain = "other-domain"
+					otherLRPAuction.DesiredLRP.ProcessGuid = "other-process-guid"
+					work.LRPs = append(work.LRPs, otherLRPAuction)
+
+					client.AllocateContainersReturns(map[string]string{}, nil)
+				})
+
+				It("returns the work within the evacuation scope", func() {
+					failedWork, err := cellRep.Perform(work)
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(failedWork.LRPs).Should(ConsistOf(lrpAuction))
+					Ω(failedWork.Tasks).Should(ConsistOf(task))
+				})
+
+				It("performs the work outside the evacuation scope", func() {
+					_, err := cellRep.Perform(work)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(client.AllocateContainersCallCount()).Should(Equal(1))
+					containers := client.AllocateContainersArgsForCall(0)
+					Ω(containers).Should(HaveLen(1))
+					Ω(containers[0].Tags[rep.ProcessGuidTag]).Should(Equal("other-process-guid"))
+				})
+			})
+
+			Context("when only some instances are evacuating", func() {
+				BeforeEach(func() {
+					evacuationReporter.EvacuationScopeReturns(evacuation_context.Scope{InstanceGuids: []string{"evacuating-instance-guid"}})
+
+					client.ListContainersReturns([]executor.Container{
+						{
+							Guid: rep.LRPContainerGuid("process-guid", "evacuating-instance-guid"),
+							Tags: executor.Tags{
+								rep.LifecycleTag:    rep.LRPLifecycle,
+								rep.DomainTag:       "tests",
+								rep.ProcessGuidTag:  "process-guid",
+								rep.InstanceGuidTag: "evacuating-instance-guid",
+							},
+						},
+					}, nil)
+					client.AllocateContainersReturns(map[string]string{}, nil)
+
+					work.Tasks = nil
+				})
+
+				It("returns new instances of the evacuating instances' processes", func() {
+					failedWork, err := cellRep.Perform(work)
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(failedWork.LRPs).Should(ConsistOf(lrpAuction))
+					Ω(client.AllocateContainersCallCount()).Should(BeZero())
+				})
+
+				Context("when listing the containers fails", func() {
+					BeforeEach(func() {
+						client.ListContainersReturns(nil, errors.New("boom"))
+					})
+
+					It("returns all work it was given", func() {
+						Ω(cellRep.Perform(work)).Should(Equal(work))
+					})
+				})
+			})
 		})
 
 		Describe("performing starts", func() {
@@ -521,6 +604,35 @@ var _ = Describe("AuctionCellRep", func() {
 				}))
 			})
 
+			Context("when the task asks for diagnostic files", func() {
+				BeforeEach(func() {
+					work.Tasks[0].EnvironmentVariables = append(work.Tasks[0].EnvironmentVariables, models.EnvironmentVariable{
+						Name:  rep.DiagnosticFilesEnvVar,
+						Value: "/tmp/crash.log",
+					})
+				})
+
+				It("tags the container with the diagnostic files", func() {
+					_, err := cellRep.Perform(work)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(client.AllocateContainersCallCount()).Should(Equal(1))
+					containers := client.AllocateContainersArgsForCall(0)
+					Ω(containers).Should(HaveLen(1))
+					Ω(containers[0].Tags[rep.DiagnosticFilesTag]).Should(Equal("/tmp/crash.log"))
+				})
+
+				It("does not pass the diagnostic files on to the container's processes", func() {
+					_, err := cellRep.Perform(work)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					containers := client.AllocateContainersArgsForCall(0)
+					for _, variable := range containers[0].Env {
+						Ω(variable.Name).ShouldNot(Equal(rep.DiagnosticFilesEnvVar))
+					}
+				})
+			})
+
 			Context("when allocation succeeds", func() {
 				BeforeEach(func() {
 					client.AllocateContainersReturns(map[string]string{}, nil)
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/cloudfoundry-incubator/auction/communication/http/auction_http_handlers"
@@ -18,6 +20,7 @@ import (
 	executorclient "github.com/cloudfoundry-incubator/executor/http/client"
 	"github.com/cloudfoundry-incubator/rep"
 	"github.com/cloudfoundry-incubator/rep/auction_cell_rep"
+	"github.com/cloudfoundry-incubator/rep/diagnostics"
 	"github.com/cloudfoundry-incubator/rep/evacuation"
 	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context"
 	"github.com/cloudfoundry-incubator/rep/generator"
@@ -26,17 +29,20 @@ import (
 	repserver "github.com/cloudfoundry-incubator/rep/http_server"
 	"github.com/cloudfoundry-incubator/rep/lrp_stopper"
 	"github.com/cloudfoundry-incubator/rep/maintain"
+	"github.com/cloudfoundry-incubator/rep/quarantine"
+	"github.com/cloudfoundry-incubator/rep/task_notifier"
 	Bbs "github.com/cloudfoundry-incubator/runtime-schema/bbs"
 	"github.com/cloudfoundry-incubator/runtime-schema/bbs/services_bbs"
+	"github.com/cloudfoundry-incubator/runtime-schema/bbs/shared"
 	bbsroutes "github.com/cloudfoundry-incubator/runtime-schema/routes"
 	"github.com/cloudfoundry/dropsonde"
 	"github.com/cloudfoundry/gunk/workpool"
+	"github.com/cloudfoundry/storeadapter"
 	"github.com/cloudfoundry/storeadapter/etcdstoreadapter"
 	"github.com/nu7hatch/gouuid"
 	"github.com/pivotal-golang/clock"
 	"github.com/pivotal-golang/lager"
 	"github.com/pivotal-golang/localip"
-	"github.com/pivotal-golang/operationq"
 	"github.com/tedsuo/ifrit"
 	"github.com/tedsuo/ifrit/grouper"
 	"github.com/tedsuo/ifrit/http_server"
@@ -56,6 +62,12 @@ var heartbeatInterval = flag.Duration(
 	"the interval between heartbeats for maintaining presence",
 )
 
+var presenceRefreshInterval = flag.Duration(
+	"presenceRefreshInterval",
+	30*time.Second,
+	"the interval on which the cell's presence (capacity, evacuation, version, stacks and rootfs providers) is refreshed, updating it in place if anything changed (0 disables)",
+)
+
 var executorURL = flag.String(
 	"executorURL",
 	"http://127.0.0.1:1700",
@@ -86,6 +98,66 @@ var pollingInterval = flag.Duration(
 	"the interval on which to scan the executor",
 )
 
+var operationWorkers = flag.Int(
+	"operationWorkers",
+	10,
+	"the number of container operations that may execute at once",
+)
+
+var operationTimeout = flag.Duration(
+	"operationTimeout",
+	2*time.Minute,
+	"how long a worker waits for a container operation before moving on (0 waits forever)",
+)
+
+var maxTimedOutOperations = flag.Int(
+	"maxTimedOutOperations",
+	0,
+	"the number of timed out container operations that may keep running once their workers have moved on (0 for as many as operationWorkers)",
+)
+
+var syncToken = flag.String(
+	"syncToken",
+	"",
+	"bearer token required to trigger an immediate sync over HTTP (disabled when empty)",
+)
+
+var diagnosticsToken = flag.String(
+	"diagnosticsToken",
+	"",
+	"bearer token required to read the diagnostics captured for failed tasks over HTTP (disabled when empty)",
+)
+
+var reconciliationToken = flag.String(
+	"reconciliationToken",
+	"",
+	"bearer token required to read the cell's reconciliation over HTTP (disabled when empty)",
+)
+
+var maxPollingInterval = flag.Duration(
+	"maxPollingInterval",
+	0,
+	"the longest interval the executor scan may back off to while the cell is in sync (defaults to pollingInterval)",
+)
+
+var healthMaxBulkSyncAge = flag.Duration(
+	"healthMaxBulkSyncAge",
+	0,
+	"how long ago the last successful executor scan may be before /health reports the cell unhealthy (defaults to twice the longest polling interval)",
+)
+
+var healthCheckTimeout = flag.Duration(
+	"healthCheckTimeout",
+	5*time.Second,
+	"how long each /health check may take before it is reported as failed",
+)
+
+var pollingJitter = flag.Float64(
+	"pollingJitter",
+	0,
+	"fraction of the polling interval by which to randomly spread scans, at least 0 and less than 1",
+)
+
 var communicationTimeout = flag.Duration(
 	"communicationTimeout",
 	10*time.Second,
@@ -98,12 +170,156 @@ var evacuationTimeout = flag.Duration(
 	"Timeout to wait for evacuation to complete",
 )
 
+var forceCleanupOnEvacuationTimeout = flag.Bool(
+	"forceCleanupOnEvacuationTimeout",
+	false,
+	"when evacuation times out, stop and delete the remaining containers, fail their tasks and remove their ActualLRPs",
+)
+
+var evacuationTTLFromStartTimeout = flag.Bool(
+	"evacuationTTLFromStartTimeout",
+	false,
+	"derive the TTL of an evacuating instance from its LRP's start timeout instead of the evacuation timeout",
+)
+
+var taskEvacuationMode = flag.String(
+	"taskEvacuationMode",
+	"wait",
+	"what to do with running tasks during evacuation: 'wait' for them to complete, 'fail' them immediately, or wait until 'deadline' and then fail them",
+)
+
+var taskEvacuationDeadline = flag.Duration(
+	"taskEvacuationDeadline",
+	5*time.Minute,
+	"how long a running task may continue during evacuation before it is failed, when taskEvacuationMode is 'deadline'",
+)
+
+var maxEvacuatingInstances = flag.Int(
+	"maxEvacuatingInstances",
+	0,
+	"the maximum number of running LRP instances evacuated at once (0 for no limit)",
+)
+
+var maxEvacuatingInstancesPerProcess = flag.Int(
+	"maxEvacuatingInstancesPerProcess",
+	0,
+	"the maximum number of running instances of a single process guid evacuated at once (0 for no limit)",
+)
+
+var evacuationWaitForReplacement = flag.Bool(
+	"evacuationWaitForReplacement",
+	false,
+	"count an evacuating instance against the limits until its replacement is running elsewhere, rather than for one evacuationPollingInterval after its evacuation is requested",
+)
+
+var evacuationStateFile = flag.String(
+	"evacuationStateFile",
+	"",
+	"path to a file recording an evacuation in progress, so that it resumes if the rep restarts (disabled if empty)",
+)
+
+var evacuationSignal = flag.String(
+	"evacuationSignal",
+	"",
+	"an OS signal that starts evacuation: SIGHUP, SIGUSR1 or SIGUSR2 (disabled if empty)",
+)
+
+var evacuateOnShutdown = flag.Bool(
+	"evacuateOnShutdown",
+	false,
+	"evacuate when sent SIGINT or SIGTERM, exiting once evacuation completes or times out",
+)
+
 var evacuationPollingInterval = flag.Duration(
 	"evacuationPollingInterval",
 	10*time.Second,
 	"the interval on which to scan the executor during evacuation",
 )
 
+var maxResultFileSize = flag.Int(
+	"maxResultFileSize",
+	internal.MAX_RESULT_SIZE,
+	"the maximum size, in bytes, of a task result file",
+)
+
+var resultCompressionThreshold = flag.Int(
+	"resultCompressionThreshold",
+	0,
+	"the task result size, in bytes, above which results are compressed before being written to the BBS (0 disables compression)",
+)
+
+var taskRunMaxAttempts = flag.Int(
+	"taskRunMaxAttempts",
+	1,
+	"the number of times running a task's container is attempted before the task is failed",
+)
+
+var taskRunRetryBackoff = flag.Duration(
+	"taskRunRetryBackoff",
+	time.Second,
+	"the time to wait before retrying to run a task's container, doubled after every attempt",
+)
+
+var containerCacheMaxAge = flag.Duration(
+	"containerCacheMaxAge",
+	5*time.Second,
+	"how long operations from a bulk sync may use the containers listed for it before refetching them (0 disables sharing)",
+)
+
+var quarantinePeriod = flag.Duration(
+	"quarantinePeriod",
+	10*time.Minute,
+	"how long a container may stay in an unexpected state before it is destroyed and its BBS record is cleaned up",
+)
+
+var quarantinePollingInterval = flag.Duration(
+	"quarantinePollingInterval",
+	30*time.Second,
+	"the interval on which to look for quarantined containers to destroy",
+)
+
+var diagnosticFileMaxSize = flag.Int(
+	"diagnosticFileMaxSize",
+	16*1024,
+	"the number of bytes captured from the end of each diagnostic file of a failed task",
+)
+
+var diagnosticsRetained = flag.Int(
+	"diagnosticsRetained",
+	100,
+	"the number of failed tasks whose diagnostics are kept in memory",
+)
+
+var drainPeriod = flag.Duration(
+	"drainPeriod",
+	0,
+	"the time to wait, after withdrawing an LRP instance's net info, before its container is stopped",
+)
+
+var taskNotificationSecret = flag.String(
+	"taskNotificationSecret",
+	"",
+	"the key used to sign task completion notifications (required when any -taskNotificationURL is given)",
+)
+
+var taskNotificationQueueSize = flag.Int(
+	"taskNotificationQueueSize",
+	100,
+	"the number of task completion notifications buffered for delivery before new ones are dropped",
+)
+
+var taskNotificationMaxAttempts = flag.Int(
+	"taskNotificationMaxAttempts",
+	3,
+	"the number of times delivering a task completion notification to a URL is attempted",
+)
+
+var taskNotificationRetryInterval = flag.Duration(
+	"taskNotificationRetryInterval",
+	time.Second,
+	"the time to wait before retrying to deliver a task completion notification",
+)
+
 type stackPathMap rep.StackPathMap
 
 func (s *stackPathMap) String() string {
@@ -128,6 +344,71 @@ func (s *stackPathMap) Set(value string) error {
 	return nil
 }
 
+type domainIntMap map[string]int
+
+func (m *domainIntMap) String() string {
+	return fmt.Sprintf("%v", *m)
+}
+
+func (m *domainIntMap) Set(value string) error {
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 {
+		return errors.New("Invalid domain value: not of the form 'domain:value'")
+	}
+
+	if parts[0] == "" {
+		return errors.New("Invalid domain value: blank domain")
+	}
+
+	n, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return fmt.Errorf("Invalid domain value: %s", err.Error())
+	}
+
+	(*m)[parts[0]] = n
+	return nil
+}
+
+type domainDurationMap map[string]time.Duration
+
+func (m *domainDurationMap) String() string {
+	return fmt.Sprintf("%v", *m)
+}
+
+func (m *domainDurationMap) Set(value string) error {
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 {
+		return errors.New("Invalid domain value: not of the form 'domain:duration'")
+	}
+
+	if parts[0] == "" {
+		return errors.New("Invalid domain value: blank domain")
+	}
+
+	d, err := time.ParseDuration(parts[1])
+	if err != nil {
+		return fmt.Errorf("Invalid domain value: %s", err.Error())
+	}
+
+	(*m)[parts[0]] = d
+	return nil
+}
+
+type urls []string
+
+func (u *urls) String() string {
+	return fmt.Sprintf("%v", *u)
+}
+
+func (u *urls) Set(value string) error {
+	if value == "" {
+		return errors.New("Cannot set blank value for URL")
+	}
+
+	*u = append(*u, value)
+	return nil
+}
+
 type providers []string
 
 func (p *providers) String() string {
@@ -148,14 +429,29 @@ const (
 	dropsondeOrigin      = "rep"
 )
 
+// version is set at build time with -ldflags "-X main.version <version>".
+var version = "dev"
+
 func main() {
 	cf_debug_server.AddFlags(flag.CommandLine)
 	cf_lager.AddFlags(flag.CommandLine)
 
 	stackMap := stackPathMap{}
 	supportedProviders := providers{}
+	domainMaxResultFileSizes := domainIntMap{}
+	domainResultCompressionThresholds := domainIntMap{}
+	domainDrainPeriods := domainDurationMap{}
+	taskNotificationURLs := urls{}
+	domainEvacuationTTLs := domainDurationMap{}
+	domainTaskEvacuationDeadlines := domainDurationMap{}
 	flag.Var(&stackMap, "preloadedRootFS", "List of preloaded RootFSes")
 	flag.Var(&supportedProviders, "rootFSProvider", "List of RootFS providers")
+	flag.Var(&domainMaxResultFileSizes, "domainMaxResultFileSize", "Per-domain override of maxResultFileSize, of the form 'domain:bytes'")
+	flag.Var(&domainResultCompressionThresholds, "domainResultCompressionThreshold", "Per-domain override of resultCompressionThreshold, of the form 'domain:bytes'")
+	flag.Var(&domainDrainPeriods, "domainDrainPeriod", "Per-domain override of drainPeriod, of the form 'domain:duration'")
+	flag.Var(&domainEvacuationTTLs, "domainEvacuationTTL", "Per-domain TTL of evacuating instances, of the form 'domain:duration'")
+	flag.Var(&domainTaskEvacuationDeadlines, "domainTaskEvacuationDeadline", "Per-domain override of taskEvacuationDeadline, of the form 'domain:duration'")
+	flag.Var(&taskNotificationURLs, "taskNotificationURL", "List of local URLs notified when a task completes")
 	flag.Parse()
 
 	cf_http.Initialize(*communicationTimeout)
@@ -167,7 +463,16 @@ func main() {
 		log.Fatalf("-cellID must be specified")
 	}
 
-	bbs := initializeRepBBS(logger)
+	if len(taskNotificationURLs) > 0 && *taskNotificationSecret == "" {
+		log.Fatalf("-taskNotificationSecret must be specified with -taskNotificationURL")
+	}
+
+	if *pollingJitter < 0 || *pollingJitter >= 1 {
+		log.Fatalf("-pollingJitter must be at least 0 and less than 1")
+	}
+
+	etcdAdapter := initializeStoreAdapter(logger)
+	bbs := initializeRepBBS(etcdAdapter, logger)
 
 	clock := clock.NewClock()
 
@@ -176,31 +481,96 @@ func main() {
 	evacuatable, evacuationReporter, evacuationNotifier := evacuation_context.New()
 
 	// only one outstanding operation per container is necessary
-	queue := operationq.NewSlidingQueue(1)
-
-	containerDelegate := internal.NewContainerDelegate(executorClient)
-	lrpProcessor := internal.NewLRPProcessor(bbs, containerDelegate, *cellID, evacuationReporter, uint64(evacuationTimeout.Seconds()))
-	taskProcessor := internal.NewTaskProcessor(bbs, containerDelegate, *cellID)
+	workerPool := harmonizer.NewWorkerPoolQueue(logger, clock, harmonizer.WorkerPoolConfig{
+		Workers:          *operationWorkers,
+		OperationTimeout: *operationTimeout,
+		MaxTimedOut:      *maxTimedOutOperations,
+	})
+	queue := harmonizer.NewInstrumentedQueue(workerPool, clock)
+
+	drainer := lrp_stopper.NewDrainer(bbs, clock, lrp_stopper.DrainConfig{Period: *drainPeriod, Domains: domainDrainPeriods})
+
+	taskNotifier := task_notifier.New(logger, cf_http.NewClient(), clock, task_notifier.Config{
+		URLs:          taskNotificationURLs,
+		Secret:        *taskNotificationSecret,
+		QueueSize:     *taskNotificationQueueSize,
+		MaxAttempts:   *taskNotificationMaxAttempts,
+		RetryInterval: *taskNotificationRetryInterval,
+	})
+
+	containerQuarantine := quarantine.New(clock)
+
+	diagnosticsStore := diagnostics.NewStore(*diagnosticsRetained)
+
+	containerChanges := internal.NewContainerChanges(clock)
+	containerDelegate := internal.NewChangeRecordingDelegate(internal.NewContainerDelegate(executorClient), containerChanges)
+	evacuationTTLConfig := internal.EvacuationTTLConfig{
+		Default:          *evacuationTimeout,
+		Domains:          domainEvacuationTTLs,
+		FromStartTimeout: *evacuationTTLFromStartTimeout,
+	}
+	evacuationWaveConfig := internal.EvacuationWaveConfig{
+		MaxInFlight:           *maxEvacuatingInstances,
+		MaxInFlightPerProcess: *maxEvacuatingInstancesPerProcess,
+		WaitForReplacement:    *evacuationWaitForReplacement,
+		WaveInterval:          *evacuationPollingInterval,
+	}
+	lrpProcessor := internal.NewLRPProcessor(bbs, containerDelegate, *cellID, evacuationReporter, evacuationTTLConfig, evacuationWaveConfig, drainer, containerQuarantine, clock)
+	runRetries := internal.NewRunRetries(clock)
+	taskProcessor := internal.NewTaskProcessor(
+		bbs,
+		containerDelegate,
+		*cellID,
+		initializeResultFileConfig(domainMaxResultFileSizes, domainResultCompressionThresholds),
+		internal.RetryPolicy{MaxAttempts: *taskRunMaxAttempts, Backoff: *taskRunRetryBackoff},
+		runRetries,
+		evacuationReporter,
+		initializeTaskEvacuationPolicy(domainTaskEvacuationDeadlines),
+		taskNotifier,
+		internal.NewDiagnosticsCollector(containerDelegate, diagnosticsStore, *diagnosticFileMaxSize, clock),
+		clock,
+	)
 
 	evacuator := evacuation.NewEvacuator(
 		logger,
 		clock,
 		executorClient,
+		bbs,
+		evacuatable,
+		evacuationReporter,
 		evacuationNotifier,
 		*cellID,
 		*evacuationTimeout,
 		*evacuationPollingInterval,
+		*forceCleanupOnEvacuationTimeout,
+		*evacuationStateFile,
 	)
 
-	httpServer, address := initializeServer(bbs, executorClient, evacuatable, evacuationReporter, logger, rep.StackPathMap(stackMap), supportedProviders)
-	opGenerator := generator.New(*cellID, bbs, executorClient, lrpProcessor, taskProcessor, containerDelegate)
+	err := evacuator.Resume(logger)
+	if err != nil {
+		logger.Fatal("failed-to-resume-evacuation", err)
+	}
 
+	opGenerator := generator.New(*cellID, bbs, executorClient, lrpProcessor, taskProcessor, containerDelegate, clock, *containerCacheMaxAge, containerChanges, runRetries)
+	bulker := harmonizer.NewBulker(logger, *pollingInterval, *maxPollingInterval, *pollingJitter, *evacuationPollingInterval, evacuationNotifier, clock, opGenerator, queue)
+	eventConsumer := harmonizer.NewEventConsumer(logger, opGenerator, queue, clock)
+	address := initializeAddress(logger)
+	maintainer := initializeCellHeartbeat(address, etcdAdapter, executorClient, evacuationReporter, logger, stackMap, supportedProviders)
+	healthChecks := initializeHealthChecks(etcdAdapter, executorClient, maintainer, eventConsumer, bulker)
+	httpServer := initializeServer(bbs, executorClient, drainer, evacuatable, evacuationReporter, evacuator, opGenerator, bulker, containerQuarantine, diagnosticsStore, healthChecks, clock, logger, rep.StackPathMap(stackMap), supportedProviders)
+
+	// Members are shut down in reverse order, so that nothing stops before the
+	// members that depend on it: operations notify the task notifier, and the
+	// bulker and event consumer push onto the operation queue.
 	members := grouper.Members{
-		{"heartbeater", initializeCellHeartbeat(address, bbs, executorClient, logger)},
+		{"heartbeater", maintainer},
+		{"task-notifier", taskNotifier},
+		{"operation-queue", workerPool},
 		{"http_server", httpServer},
-		{"bulker", harmonizer.NewBulker(logger, *pollingInterval, *evacuationPollingInterval, evacuationNotifier, clock, opGenerator, queue)},
-		{"event-consumer", harmonizer.NewEventConsumer(logger, opGenerator, queue)},
+		{"bulker", bulker},
+		{"event-consumer", eventConsumer},
 		{"evacuator", evacuator},
+		{"quarantine-reaper", quarantine.NewReaper(logger, containerQuarantine, bbs, executorClient, clock, *cellID, *quarantinePeriod, *quarantinePollingInterval)},
 	}
 
 	if dbgAddr := cf_debug_server.DebugAddress(flag.CommandLine); dbgAddr != "" {
@@ -211,11 +581,19 @@ func main() {
 
 	group := grouper.NewOrdered(os.Interrupt, members)
 
-	monitor := ifrit.Invoke(sigmon.New(group))
+	signal := initializeEvacuationSignal()
+	runner := evacuation.NewSignalRunner(logger, group, evacuatable, evacuationNotifier, signal, *evacuateOnShutdown)
+
+	var monitor ifrit.Process
+	if signal != nil {
+		monitor = ifrit.Invoke(sigmon.New(runner, signal))
+	} else {
+		monitor = ifrit.Invoke(sigmon.New(runner))
+	}
 
 	logger.Info("started", lager.Data{"cell-id": *cellID})
 
-	err := <-monitor.Wait()
+	err = <-monitor.Wait()
 	if err != nil {
 		logger.Error("exited-with-failure", err)
 		os.Exit(1)
@@ -224,6 +602,22 @@ func main() {
 	logger.Info("exited")
 }
 
+func initializeEvacuationSignal() os.Signal {
+	switch *evacuationSignal {
+	case "":
+		return nil
+	case "SIGHUP":
+		return syscall.SIGHUP
+	case "SIGUSR1":
+		return syscall.SIGUSR1
+	case "SIGUSR2":
+		return syscall.SIGUSR2
+	default:
+		log.Fatalf("-evacuationSignal: unsupported signal %s", *evacuationSignal)
+		return nil
+	}
+}
+
 func initializeDropsonde(logger lager.Logger) {
 	err := dropsonde.Initialize(dropsondeDestination, dropsondeOrigin)
 	if err != nil {
@@ -231,17 +625,66 @@ func initializeDropsonde(logger lager.Logger) {
 	}
 }
 
-func initializeCellHeartbeat(address string, bbs Bbs.RepBBS, executorClient executor.Client, logger lager.Logger) ifrit.Runner {
+func initializeCellHeartbeat(
+	address string,
+	store maintain.PresenceStore,
+	executorClient executor.Client,
+	evacuationReporter evacuation_context.EvacuationReporter,
+	logger lager.Logger,
+	stackMap stackPathMap,
+	supportedProviders []string,
+) *maintain.Maintainer {
+	stacks := []string{}
+	for stack := range stackMap {
+		stacks = append(stacks, stack)
+	}
+
 	config := maintain.Config{
-		CellID:            *cellID,
-		RepAddress:        address,
-		Zone:              *zone,
-		HeartbeatInterval: *heartbeatInterval,
+		CellID:                  *cellID,
+		RepAddress:              address,
+		Zone:                    *zone,
+		HeartbeatInterval:       *heartbeatInterval,
+		PresenceRefreshInterval: *presenceRefreshInterval,
+		RepVersion:              version,
+		Stacks:                  stacks,
+		RootFSProviders:         supportedProviders,
 	}
-	return maintain.New(config, executorClient, bbs, logger, clock.NewClock())
+	newPresence := maintain.NewPresenceFactory(logger, store, clock.NewClock())
+	return maintain.New(config, executorClient, newPresence, evacuationReporter, logger, clock.NewClock())
 }
 
-func initializeRepBBS(logger lager.Logger) Bbs.RepBBS {
+func initializeResultFileConfig(domainMaxSizes, domainCompressionThresholds domainIntMap) internal.ResultFileConfig {
+	config := internal.NewResultFileConfig(*maxResultFileSize, *resultCompressionThreshold)
+
+	for domain, maxSize := range domainMaxSizes {
+		policy := config.PolicyFor(domain)
+		policy.MaxSize = maxSize
+		config.Domains[domain] = policy
+	}
+
+	for domain, threshold := range domainCompressionThresholds {
+		policy := config.PolicyFor(domain)
+		policy.CompressionThreshold = threshold
+		config.Domains[domain] = policy
+	}
+
+	return config
+}
+
+func initializeTaskEvacuationPolicy(domainDeadlines domainDurationMap) internal.TaskEvacuationPolicy {
+	mode, err := internal.ParseTaskEvacuationMode(*taskEvacuationMode)
+	if err != nil {
+		log.Fatalf("-taskEvacuationMode: %s", err)
+	}
+
+	return internal.TaskEvacuationPolicy{
+		Mode:     mode,
+		Deadline: *taskEvacuationDeadline,
+		Domains:  domainDeadlines,
+	}
+}
+
+func initializeStoreAdapter(logger lager.Logger) storeadapter.StoreAdapter {
 	etcdAdapter := etcdstoreadapter.NewETCDStoreAdapter(
 		strings.Split(*etcdCluster, ","),
 		workpool.NewWorkPool(100),
@@ -252,23 +695,35 @@ func initializeRepBBS(logger lager.Logger) Bbs.RepBBS {
 		logger.Fatal("failed-to-connect-to-etcd", err)
 	}
 
+	return etcdAdapter
+}
+
+func initializeRepBBS(etcdAdapter storeadapter.StoreAdapter, logger lager.Logger) Bbs.RepBBS {
 	return Bbs.NewRepBBS(etcdAdapter, clock.NewClock(), logger)
 }
 
-func initializeLRPStopper(guid string, executorClient executor.Client, logger lager.Logger) lrp_stopper.LRPStopper {
-	return lrp_stopper.New(guid, executorClient, logger)
+func initializeLRPStopper(guid string, executorClient executor.Client, drainer lrp_stopper.Drainer, logger lager.Logger) lrp_stopper.LRPStopper {
+	return lrp_stopper.New(guid, executorClient, drainer, logger)
 }
 
 func initializeServer(
 	bbs Bbs.RepBBS,
 	executorClient executor.Client,
+	drainer lrp_stopper.Drainer,
 	evacuatable evacuation_context.Evacuatable,
 	evacuationReporter evacuation_context.EvacuationReporter,
+	evacuationStatus evacuation.StatusReporter,
+	opGenerator generator.Generator,
+	syncTrigger harmonizer.SyncTrigger,
+	containerQuarantine quarantine.Quarantine,
+	diagnosticsStore diagnostics.Store,
+	healthChecks map[string]repserver.HealthCheck,
+	clock clock.Clock,
 	logger lager.Logger,
 	stackMap rep.StackPathMap,
 	supportedProviders []string,
-) (ifrit.Runner, string) {
-	lrpStopper := initializeLRPStopper(*cellID, executorClient, logger)
+) ifrit.Runner {
+	lrpStopper := initializeLRPStopper(*cellID, executorClient, drainer, logger)
 
 	auctionCellRep := auction_cell_rep.New(*cellID, stackMap, supportedProviders, *zone, generateGuid, bbs, executorClient, evacuationReporter, logger)
 	handlers := auction_http_handlers.New(auctionCellRep, logger)
@@ -284,23 +739,92 @@ func initializeServer(
 	handlers["Ping"] = repserver.NewPingHandler()
 	routes = append(routes, rata.Route{Name: "Ping", Method: "GET", Path: "/ping"})
 
+	handlers["Health"] = repserver.NewHealthHandler(logger, clock, *healthCheckTimeout, healthChecks)
+	routes = append(routes, rata.Route{Name: "Health", Method: "GET", Path: "/health"})
+
 	handlers["Evacuate"] = repserver.NewEvacuationHandler(logger, evacuatable)
 	routes = append(routes, rata.Route{Name: "Evacuate", Method: "POST", Path: "/evacuate"})
 
+	handlers["CancelEvacuation"] = repserver.NewCancelEvacuationHandler(logger, evacuatable)
+	routes = append(routes, rata.Route{Name: "CancelEvacuation", Method: "DELETE", Path: "/evacuate"})
+
+	handlers["EvacuationStatus"] = repserver.NewEvacuationStatusHandler(logger, evacuationStatus)
+	routes = append(routes, rata.Route{Name: "EvacuationStatus", Method: "GET", Path: "/evacuation"})
+
+	handlers["Reconciliation"] = repserver.NewReconciliationHandler(logger, *reconciliationToken, opGenerator)
+	routes = append(routes, rata.Route{Name: "Reconciliation", Method: "GET", Path: "/reconciliation"})
+
+	handlers["Sync"] = repserver.NewSyncHandler(logger, *syncToken, syncTrigger)
+	routes = append(routes, rata.Route{Name: "Sync", Method: "POST", Path: "/sync"})
+
+	handlers["Quarantine"] = repserver.NewQuarantineHandler(logger, containerQuarantine)
+	routes = append(routes, rata.Route{Name: "Quarantine", Method: "GET", Path: "/quarantine"})
+
+	handlers["Diagnostics"] = repserver.NewDiagnosticsHandler(logger, *diagnosticsToken, diagnosticsStore)
+	routes = append(routes, rata.Route{Name: "Diagnostics", Method: "GET", Path: "/diagnostics/:task_guid"})
+
 	router, err := rata.NewRouter(routes, handlers)
 	if err != nil {
 		logger.Fatal("failed-to-construct-router", err)
 	}
 
+	return http_server.New(*listenAddr, router)
+}
+
+func initializeAddress(logger lager.Logger) string {
 	ip, err := localip.LocalIP()
 	if err != nil {
 		logger.Fatal("failed-to-fetch-ip", err)
 	}
 
 	port := strings.Split(*listenAddr, ":")[1]
-	address := fmt.Sprintf("http://%s:%s", ip, port)
+	return fmt.Sprintf("http://%s:%s", ip, port)
+}
 
-	return http_server.New(*listenAddr, router), address
+func initializeHealthChecks(
+	store storeadapter.StoreAdapter,
+	executorClient executor.Client,
+	maintainer *maintain.Maintainer,
+	eventConsumer *harmonizer.EventConsumer,
+	bulker *harmonizer.Bulker,
+) map[string]repserver.HealthCheck {
+	maxBulkSyncAge := *healthMaxBulkSyncAge
+	if maxBulkSyncAge == 0 {
+		longestInterval := *maxPollingInterval
+		if longestInterval < *pollingInterval {
+			longestInterval = *pollingInterval
+		}
+		maxBulkSyncAge = 2 * longestInterval
+	}
+
+	return map[string]repserver.HealthCheck{
+		"executor": func(logger lager.Logger) error {
+			return executorClient.Ping()
+		},
+		"bbs": func(logger lager.Logger) error {
+			// reading a single key is enough to tell whether the store is reachable
+			_, err := store.Get(shared.CellSchemaPath(*cellID))
+			if err == storeadapter.ErrorKeyNotFound {
+				return nil
+			}
+			return err
+		},
+		"heartbeat": func(logger lager.Logger) error {
+			if !maintainer.Heartbeating() {
+				return errors.New("cell presence is not being maintained")
+			}
+			return nil
+		},
+		"event-stream": func(logger lager.Logger) error {
+			if !eventConsumer.Subscribed() {
+				return errors.New("not subscribed to the operation stream")
+			}
+			return nil
+		},
+		"bulk-sync": func(logger lager.Logger) error {
+			return bulker.CheckSync(maxBulkSyncAge)
+		},
+	}
 }
 
 func generateGuid() (string, error) {
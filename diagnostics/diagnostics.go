@@ -0,0 +1,67 @@
+package diagnostics
+
+import (
+	"sync"
+	"time"
+)
+
+// Diagnostics holds the tails of the files captured from a failed task's
+// container before it was deleted.
+type Diagnostics struct {
+	TaskGuid      string            `json:"task_guid"`
+	Domain        string            `json:"domain"`
+	FailureReason string            `json:"failure_reason"`
+	CapturedAt    time.Time         `json:"captured_at"`
+	Files         map[string]string `json:"files"`
+	Errors        map[string]string `json:"errors,omitempty"`
+}
+
+// Store keeps the diagnostics of the most recently failed tasks.
+type Store interface {
+	Put(diagnostics Diagnostics)
+	Get(taskGuid string) (Diagnostics, bool)
+}
+
+type store struct {
+	maxEntries int
+
+	entries map[string]Diagnostics
+	order   []string
+	mu      sync.Mutex
+}
+
+// NewStore returns a Store holding at most maxEntries diagnostics, evicting the
+// oldest first.
+func NewStore(maxEntries int) Store {
+	return &store{
+		maxEntries: maxEntries,
+		entries:    map[string]Diagnostics{},
+	}
+}
+
+func (s *store) Put(diagnostics Diagnostics) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.maxEntries <= 0 {
+		return
+	}
+
+	if _, found := s.entries[diagnostics.TaskGuid]; !found {
+		s.order = append(s.order, diagnostics.TaskGuid)
+	}
+	s.entries[diagnostics.TaskGuid] = diagnostics
+
+	for len(s.order) > s.maxEntries {
+		delete(s.entries, s.order[0])
+		s.order = s.order[1:]
+	}
+}
+
+func (s *store) Get(taskGuid string) (Diagnostics, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	diagnostics, found := s.entries[taskGuid]
+	return diagnostics, found
+}
@@ -0,0 +1,55 @@
+package diagnostics_test
+
+import (
+	"github.com/cloudfoundry-incubator/rep/diagnostics"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Store", func() {
+	var store diagnostics.Store
+
+	BeforeEach(func() {
+		store = diagnostics.NewStore(2)
+	})
+
+	It("returns stored diagnostics by task guid", func() {
+		store.Put(diagnostics.Diagnostics{TaskGuid: "task-a", FailureReason: "boom"})
+
+		stored, found := store.Get("task-a")
+		Ω(found).Should(BeTrue())
+		Ω(stored.FailureReason).Should(Equal("boom"))
+
+		_, found = store.Get("task-b")
+		Ω(found).Should(BeFalse())
+	})
+
+	It("evicts the oldest diagnostics beyond its capacity", func() {
+		store.Put(diagnostics.Diagnostics{TaskGuid: "task-a"})
+		store.Put(diagnostics.Diagnostics{TaskGuid: "task-b"})
+		store.Put(diagnostics.Diagnostics{TaskGuid: "task-c"})
+
+		_, found := store.Get("task-a")
+		Ω(found).Should(BeFalse())
+
+		_, found = store.Get("task-b")
+		Ω(found).Should(BeTrue())
+
+		_, found = store.Get("task-c")
+		Ω(found).Should(BeTrue())
+	})
+
+	Context("when the capacity is zero", func() {
+		BeforeEach(func() {
+			store = diagnostics.NewStore(0)
+		})
+
+		It("stores nothing", func() {
+			store.Put(diagnostics.Diagnostics{TaskGuid: "task-a"})
+
+			_, found := store.Get("task-a")
+			Ω(found).Should(BeFalse())
+		})
+	})
+})
@@ -2,41 +2,67 @@ package evacuation
 
 import (
 	"os"
+	"sync"
 	"time"
 
 	"github.com/cloudfoundry-incubator/executor"
 	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context"
+	"github.com/cloudfoundry-incubator/runtime-schema/bbs"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
 	"github.com/pivotal-golang/clock"
 	"github.com/pivotal-golang/lager"
 )
 
 type Evacuator struct {
-	logger             lager.Logger
-	clock              clock.Clock
-	executorClient     executor.Client
-	evacuationNotifier evacuation_context.EvacuationNotifier
-	cellID             string
-	evacuationTimeout  time.Duration
-	pollingInterval    time.Duration
+	logger                lager.Logger
+	clock                 clock.Clock
+	executorClient        executor.Client
+	bbs                   bbs.RepBBS
+	evacuatable           evacuation_context.Evacuatable
+	evacuationReporter    evacuation_context.EvacuationReporter
+	evacuationNotifier    evacuation_context.EvacuationNotifier
+	cellID                string
+	evacuationTimeout     time.Duration
+	pollingInterval       time.Duration
+	forceCleanupOnTimeout bool
+	stateFile             string
+
+	statusLock        sync.Mutex
+	startedAt         time.Time
+	initialContainers int
+	countedContainers bool
+	pending           Pending
+	timedOut          bool
+	resumedStartedAt  time.Time
 }
 
 func NewEvacuator(
 	logger lager.Logger,
 	clock clock.Clock,
 	executorClient executor.Client,
+	bbs bbs.RepBBS,
+	evacuatable evacuation_context.Evacuatable,
+	evacuationReporter evacuation_context.EvacuationReporter,
 	evacuationNotifier evacuation_context.EvacuationNotifier,
 	cellID string,
 	evacuationTimeout time.Duration,
 	pollingInterval time.Duration,
+	forceCleanupOnTimeout bool,
+	stateFile string,
 ) *Evacuator {
 	return &Evacuator{
-		logger:             logger,
-		clock:              clock,
-		executorClient:     executorClient,
-		evacuationNotifier: evacuationNotifier,
-		cellID:             cellID,
-		evacuationTimeout:  evacuationTimeout,
-		pollingInterval:    pollingInterval,
+		logger:                logger,
+		clock:                 clock,
+		executorClient:        executorClient,
+		bbs:                   bbs,
+		evacuatable:           evacuatable,
+		evacuationReporter:    evacuationReporter,
+		evacuationNotifier:    evacuationNotifier,
+		cellID:                cellID,
+		evacuationTimeout:     evacuationTimeout,
+		pollingInterval:       pollingInterval,
+		forceCleanupOnTimeout: forceCleanupOnTimeout,
+		stateFile:             stateFile,
 	}
 }
 
@@ -46,39 +72,131 @@ func (e *Evacuator) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
 	defer logger.Info("finished")
 
 	evacuationNotify := e.evacuationNotifier.EvacuateNotify()
+	cancelNotify := e.evacuationNotifier.CancelNotify()
 	close(ready)
 
-	select {
-	case signal := <-signals:
-		logger.Info("signaled", lager.Data{"signal": signal.String()})
-		return nil
-	case <-evacuationNotify:
-		evacuationNotify = nil
-		logger.Info("notified-of-evacuation")
+	for {
+		select {
+		case signal := <-signals:
+			logger.Info("signaled", lager.Data{"signal": signal.String()})
+			return nil
+		case <-evacuationNotify:
+			logger.Info("notified-of-evacuation")
+		}
+
+		canceled := e.waitForEvacuation(logger, signals, cancelNotify)
+		if !canceled {
+			return nil
+		}
+
+		evacuationNotify = e.evacuationNotifier.EvacuateNotify()
+		cancelNotify = e.evacuationNotifier.CancelNotify()
+	}
+}
+
+// waitForEvacuation waits for the cell to be evacuated, the evacuation timeout
+// or a signal, and reports whether the rep should keep running: when the
+// evacuation was canceled, or when only part of the cell was evacuated.
+func (e *Evacuator) waitForEvacuation(logger lager.Logger, signals <-chan os.Signal, cancelNotify <-chan struct{}) bool {
+	startedAt := e.clock.Now()
+	if !e.resumedStartedAt.IsZero() {
+		startedAt = e.resumedStartedAt
+		e.resumedStartedAt = time.Time{}
 	}
 
-	timer := e.clock.NewTimer(e.evacuationTimeout)
+	e.statusLock.Lock()
+	e.startedAt = startedAt
+	e.statusLock.Unlock()
+
+	// the scope can be widened while evacuating, and is saved again each time
+	scopeNotify := e.evacuationNotifier.ScopeNotify()
+	e.saveState(logger, persistedState{StartedAt: startedAt, Scope: e.evacuationReporter.EvacuationScope()})
+
+	timer := e.clock.NewTimer(e.remainingTimeout(startedAt))
 	defer timer.Stop()
 
 	doneCh := make(chan struct{})
-	go e.evacuate(logger, doneCh)
-
-	select {
-	case <-doneCh:
-		logger.Info("evacuation-complete")
-		return nil
-	case <-timer.C():
-		logger.Error("failed-to-evacuate-before-timeout", nil)
-		return nil
-	case signal := <-signals:
-		logger.Info("signaled", lager.Data{"signal": signal.String()})
-		return nil
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	go e.evacuate(logger, doneCh, stopCh)
+
+	timeout := timer.C()
+
+	for {
+		select {
+		case <-doneCh:
+			logger.Info("evacuation-complete", lager.Data{"pending": e.lastPending()})
+			e.removeState(logger)
+			return e.finishPartialEvacuation(logger)
+		case <-timeout:
+			logger.Error("failed-to-evacuate-before-timeout", nil, lager.Data{"pending": e.lastPending()})
+			if e.forceCleanupOnTimeout {
+				e.forceCleanup(logger)
+			}
+
+			if e.evacuationReporter.EvacuationScope().All() {
+				e.removeState(logger)
+				return false
+			}
+
+			// A partial evacuation stays in force once timed out, so that what it
+			// failed to move is not handed back to ordinary processing. It ends
+			// once done or canceled.
+			logger.Error("partial-evacuation-timed-out", nil)
+			e.statusLock.Lock()
+			e.timedOut = true
+			e.statusLock.Unlock()
+			timeout = nil
+		case <-scopeNotify:
+			scopeNotify = e.evacuationNotifier.ScopeNotify()
+			scope := e.evacuationReporter.EvacuationScope()
+			logger.Info("evacuation-scope-widened", lager.Data{"scope": scope})
+			e.saveState(logger, persistedState{StartedAt: startedAt, Scope: scope})
+		case signal := <-signals:
+			logger.Info("signaled", lager.Data{"signal": signal.String()})
+			return false
+		case <-cancelNotify:
+			logger.Info("evacuation-canceled")
+			e.removeState(logger)
+			e.resetStatus()
+			return true
+		}
+	}
+}
+
+// finishPartialEvacuation returns the cell to service once a scoped
+// evacuation is over. A whole-cell evacuation leaves the cell to exit.
+func (e *Evacuator) finishPartialEvacuation(logger lager.Logger) bool {
+	if e.evacuationReporter.EvacuationScope().All() {
+		return false
 	}
 
-	return nil
+	logger.Info("partial-evacuation-finished")
+	e.resetStatus()
+	e.evacuatable.CancelEvacuation()
+
+	return true
+}
+
+func (e *Evacuator) resetStatus() {
+	e.statusLock.Lock()
+	e.startedAt = time.Time{}
+	e.initialContainers = 0
+	e.countedContainers = false
+	e.pending = Pending{}
+	e.timedOut = false
+	e.statusLock.Unlock()
+}
+
+func (e *Evacuator) lastPending() Pending {
+	e.statusLock.Lock()
+	defer e.statusLock.Unlock()
+
+	return e.pending
 }
 
-func (e *Evacuator) evacuate(logger lager.Logger, doneCh chan<- struct{}) {
+func (e *Evacuator) evacuate(logger lager.Logger, doneCh chan<- struct{}, stopCh <-chan struct{}) {
 	logger = logger.Session("evacuating")
 	logger.Info("started")
 
@@ -86,12 +204,19 @@ func (e *Evacuator) evacuate(logger lager.Logger, doneCh chan<- struct{}) {
 	defer timer.Stop()
 
 	for {
-		evacuated := e.allContainersEvacuated(logger)
+		pending, err := e.pendingEvacuation(logger)
 
-		if !evacuated {
-			logger.Info("evacuation-incomplete", lager.Data{"polling-interval": e.pollingInterval})
+		if err != nil || !pending.Done() {
+			logger.Info("evacuation-incomplete", lager.Data{"polling-interval": e.pollingInterval, "pending": pending})
 			timer.Reset(e.pollingInterval)
-			<-timer.C()
+
+			select {
+			case <-timer.C():
+			case <-stopCh:
+				logger.Info("stopped")
+				return
+			}
+
 			continue
 		}
 
@@ -102,12 +227,70 @@ func (e *Evacuator) evacuate(logger lager.Logger, doneCh chan<- struct{}) {
 	}
 }
 
-func (e *Evacuator) allContainersEvacuated(logger lager.Logger) bool {
+// pendingEvacuation counts what remains on this cell within the evacuation
+// scope, both in the executor and in the BBS.
+func (e *Evacuator) pendingEvacuation(logger lager.Logger) (Pending, error) {
+	scope := e.evacuationReporter.EvacuationScope()
+
 	containers, err := e.executorClient.ListContainers(nil)
 	if err != nil {
 		logger.Error("failed-to-list-containers", err)
-		return false
+		return e.lastPending(), err
+	}
+
+	containers = scopedContainers(containers, scope)
+
+	groups, err := e.bbs.ActualLRPGroupsByCellID(e.cellID)
+	if err != nil {
+		logger.Error("failed-to-retrieve-lrp-groups", err)
+		return e.lastPending(), err
+	}
+
+	tasks, err := e.bbs.TasksByCellID(logger, e.cellID)
+	if err != nil {
+		logger.Error("failed-to-retrieve-tasks", err)
+		return e.lastPending(), err
+	}
+
+	pending := Pending{Containers: len(containers)}
+
+	for _, group := range groups {
+		if e.ownedAndInScope(group.Instance, scope) {
+			pending.InstanceLRPs++
+		}
+		if e.ownedAndInScope(group.Evacuating, scope) {
+			pending.EvacuatingLRPs++
+		}
+	}
+
+	for _, task := range tasks {
+		if task.State == models.TaskStateRunning && scope.Matches(task.Domain, "", "") {
+			pending.Tasks++
+		}
+	}
+
+	e.statusLock.Lock()
+	if !e.countedContainers {
+		e.initialContainers = len(containers)
+		e.countedContainers = true
+	}
+	e.pending = pending
+	e.statusLock.Unlock()
+
+	return pending, nil
+}
+
+func scopedContainers(containers []executor.Container, scope evacuation_context.Scope) []executor.Container {
+	if scope.All() {
+		return containers
+	}
+
+	scoped := []executor.Container{}
+	for _, container := range containers {
+		if scope.MatchesContainer(container) {
+			scoped = append(scoped, container)
+		}
 	}
 
-	return len(containers) == 0
+	return scoped
 }
@@ -5,43 +5,95 @@ import "sync"
 //go:generate counterfeiter -o fake_evacuation_context/fake_evacuatable.go . Evacuatable
 type Evacuatable interface {
 	Evacuate()
+
+	// EvacuateScope evacuates only what the scope matches. Scoping an
+	// evacuation that is already in progress widens it.
+	EvacuateScope(scope Scope)
+
+	// CancelEvacuation returns the rep to normal operation. It does nothing
+	// when the rep is not evacuating.
+	CancelEvacuation()
 }
 
 //go:generate counterfeiter -o fake_evacuation_context/fake_evacuation_reporter.go . EvacuationReporter
 type EvacuationReporter interface {
+	// Evacuating reports whether the whole cell or part of it is evacuating.
 	Evacuating() bool
+
+	// EvacuationScope reports what is being evacuated.
+	EvacuationScope() Scope
 }
 
 //go:generate counterfeiter -o fake_evacuation_context/fake_evacuation_notifier.go . EvacuationNotifier
 type EvacuationNotifier interface {
+	// EvacuateNotify returns a channel that is closed when the rep next
+	// starts evacuating.
 	EvacuateNotify() <-chan struct{}
+
+	// CancelNotify returns a channel that is closed when that evacuation is
+	// canceled. Call it together with EvacuateNotify, before waiting.
+	CancelNotify() <-chan struct{}
+
+	// ScopeNotify returns a channel that is closed when the scope of the
+	// evacuation in progress is next widened.
+	ScopeNotify() <-chan struct{}
 }
 
 type evacuationContext struct {
-	evacuated chan struct{}
-	mu        sync.Mutex
+	evacuated    chan struct{}
+	canceled     chan struct{}
+	scopeChanged chan struct{}
+	scope        Scope
+	mu           sync.Mutex
 }
 
 func New() (Evacuatable, EvacuationReporter, EvacuationNotifier) {
 	evacuationContext := &evacuationContext{
-		evacuated: make(chan struct{}),
+		evacuated:    make(chan struct{}),
+		canceled:     make(chan struct{}),
+		scopeChanged: make(chan struct{}),
 	}
 
 	return evacuationContext, evacuationContext, evacuationContext
 }
 
 func (e *evacuationContext) Evacuate() {
+	e.EvacuateScope(Scope{})
+}
+
+func (e *evacuationContext) EvacuateScope(scope Scope) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	select {
 	case <-e.evacuated:
+		e.scope = e.scope.Union(scope)
+		close(e.scopeChanged)
+		e.scopeChanged = make(chan struct{})
 	default:
+		e.scope = scope
 		close(e.evacuated)
 	}
 }
 
+func (e *evacuationContext) CancelEvacuation() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	select {
+	case <-e.evacuated:
+		close(e.canceled)
+		e.evacuated = make(chan struct{})
+		e.canceled = make(chan struct{})
+		e.scope = Scope{}
+	default:
+	}
+}
+
 func (e *evacuationContext) Evacuating() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
 	select {
 	case <-e.evacuated:
 		return true
@@ -50,6 +102,30 @@ func (e *evacuationContext) Evacuating() bool {
 	}
 }
 
+func (e *evacuationContext) EvacuationScope() Scope {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.scope
+}
+
 func (e *evacuationContext) EvacuateNotify() <-chan struct{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
 	return e.evacuated
 }
+
+func (e *evacuationContext) CancelNotify() <-chan struct{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.canceled
+}
+
+func (e *evacuationContext) ScopeNotify() <-chan struct{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.scopeChanged
+}
@@ -64,4 +64,111 @@ var _ = Describe("EvacuationContext", func() {
 			})
 		})
 	})
+
+	Describe("EvacuateScope", func() {
+		var scope evacuation_context.Scope
+
+		BeforeEach(func() {
+			scope = evacuation_context.Scope{Domains: []string{"domain"}}
+		})
+
+		It("evacuates the scope", func() {
+			evacuatable.EvacuateScope(scope)
+			Ω(evacuationReporter.Evacuating()).Should(BeTrue())
+			Ω(evacuationReporter.EvacuationScope()).Should(Equal(scope))
+			Ω(evacuationNotifier.EvacuateNotify()).Should(BeClosed())
+		})
+
+		It("does not notify of a scope change when starting to evacuate", func() {
+			scopeNotify := evacuationNotifier.ScopeNotify()
+			evacuatable.EvacuateScope(scope)
+			Ω(scopeNotify).ShouldNot(BeClosed())
+		})
+
+		Context("when already evacuating part of the cell", func() {
+			BeforeEach(func() {
+				evacuatable.EvacuateScope(scope)
+			})
+
+			It("widens the scope", func() {
+				evacuatable.EvacuateScope(evacuation_context.Scope{ProcessGuids: []string{"process-guid"}})
+				Ω(evacuationReporter.EvacuationScope()).Should(Equal(evacuation_context.Scope{
+					Domains:      []string{"domain"},
+					ProcessGuids: []string{"process-guid"},
+				}))
+			})
+
+			It("evacuates the whole cell when Evacuate is called", func() {
+				evacuatable.Evacuate()
+				Ω(evacuationReporter.EvacuationScope().All()).Should(BeTrue())
+			})
+
+			It("notifies that the scope changed", func() {
+				scopeNotify := evacuationNotifier.ScopeNotify()
+				Ω(scopeNotify).ShouldNot(BeClosed())
+
+				evacuatable.Evacuate()
+				Ω(scopeNotify).Should(BeClosed())
+				Ω(evacuationNotifier.ScopeNotify()).ShouldNot(BeClosed())
+			})
+		})
+
+		Context("when the evacuation is canceled", func() {
+			It("forgets the scope", func() {
+				evacuatable.EvacuateScope(scope)
+				evacuatable.CancelEvacuation()
+				Ω(evacuationReporter.EvacuationScope().All()).Should(BeTrue())
+			})
+		})
+	})
+
+	Describe("CancelEvacuation", func() {
+		Context("when evacuating", func() {
+			var (
+				evacuateNotify <-chan struct{}
+				cancelNotify   <-chan struct{}
+			)
+
+			BeforeEach(func() {
+				evacuateNotify = evacuationNotifier.EvacuateNotify()
+				cancelNotify = evacuationNotifier.CancelNotify()
+				evacuatable.Evacuate()
+			})
+
+			It("makes the evacuation reporter return false for Evacuating", func() {
+				evacuatable.CancelEvacuation()
+				Ω(evacuationReporter.Evacuating()).Should(BeFalse())
+			})
+
+			It("closes the channel provided by the cancel notifier", func() {
+				Consistently(cancelNotify).ShouldNot(BeClosed())
+				evacuatable.CancelEvacuation()
+				Eventually(cancelNotify).Should(BeClosed())
+			})
+
+			It("allows evacuation to be started again", func() {
+				evacuatable.CancelEvacuation()
+				Ω(evacuateNotify).Should(BeClosed())
+
+				nextEvacuateNotify := evacuationNotifier.EvacuateNotify()
+				nextCancelNotify := evacuationNotifier.CancelNotify()
+				Ω(nextEvacuateNotify).ShouldNot(BeClosed())
+				Ω(nextCancelNotify).ShouldNot(BeClosed())
+
+				evacuatable.Evacuate()
+				Ω(evacuationReporter.Evacuating()).Should(BeTrue())
+				Ω(nextEvacuateNotify).Should(BeClosed())
+			})
+		})
+
+		Context("when not evacuating", func() {
+			It("does nothing", func() {
+				cancelNotify := evacuationNotifier.CancelNotify()
+				evacuatable.CancelEvacuation()
+
+				Ω(evacuationReporter.Evacuating()).Should(BeFalse())
+				Ω(cancelNotify).ShouldNot(BeClosed())
+			})
+		})
+	})
 })
@@ -8,9 +8,17 @@ import (
 )
 
 type FakeEvacuatable struct {
-	EvacuateStub        func()
-	evacuateMutex       sync.RWMutex
-	evacuateArgsForCall []struct{}
+	EvacuateStub             func()
+	evacuateMutex            sync.RWMutex
+	evacuateArgsForCall      []struct{}
+	EvacuateScopeStub        func(scope evacuation_context.Scope)
+	evacuateScopeMutex       sync.RWMutex
+	evacuateScopeArgsForCall []struct {
+		scope evacuation_context.Scope
+	}
+	CancelEvacuationStub        func()
+	cancelEvacuationMutex       sync.RWMutex
+	cancelEvacuationArgsForCall []struct{}
 }
 
 func (fake *FakeEvacuatable) Evacuate() {
@@ -28,4 +36,42 @@ func (fake *FakeEvacuatable) EvacuateCallCount() int {
 	return len(fake.evacuateArgsForCall)
 }
 
+func (fake *FakeEvacuatable) EvacuateScope(scope evacuation_context.Scope) {
+	fake.evacuateScopeMutex.Lock()
+	fake.evacuateScopeArgsForCall = append(fake.evacuateScopeArgsForCall, struct {
+		scope evacuation_context.Scope
+	}{scope})
+	fake.evacuateScopeMutex.Unlock()
+	if fake.EvacuateScopeStub != nil {
+		fake.EvacuateScopeStub(scope)
+	}
+}
+
+func (fake *FakeEvacuatable) EvacuateScopeCallCount() int {
+	fake.evacuateScopeMutex.RLock()
+	defer fake.evacuateScopeMutex.RUnlock()
+	return len(fake.evacuateScopeArgsForCall)
+}
+
+func (fake *FakeEvacuatable) EvacuateScopeArgsForCall(i int) evacuation_context.Scope {
+	fake.evacuateScopeMutex.RLock()
+	defer fake.evacuateScopeMutex.RUnlock()
+	return fake.evacuateScopeArgsForCall[i].scope
+}
+
+func (fake *FakeEvacuatable) CancelEvacuation() {
+	fake.cancelEvacuationMutex.Lock()
+	fake.cancelEvacuationArgsForCall = append(fake.cancelEvacuationArgsForCall, struct{}{})
+	fake.cancelEvacuationMutex.Unlock()
+	if fake.CancelEvacuationStub != nil {
+		fake.CancelEvacuationStub()
+	}
+}
+
+func (fake *FakeEvacuatable) CancelEvacuationCallCount() int {
+	fake.cancelEvacuationMutex.RLock()
+	defer fake.cancelEvacuationMutex.RUnlock()
+	return len(fake.cancelEvacuationArgsForCall)
+}
+
 var _ evacuation_context.Evacuatable = new(FakeEvacuatable)
@@ -14,6 +14,18 @@ type FakeEvacuationNotifier struct {
 	evacuateNotifyReturns     struct {
 		result1 <-chan struct{}
 	}
+	CancelNotifyStub        func() <-chan struct{}
+	cancelNotifyMutex       sync.RWMutex
+	cancelNotifyArgsForCall []struct{}
+	cancelNotifyReturns     struct {
+		result1 <-chan struct{}
+	}
+	ScopeNotifyStub        func() <-chan struct{}
+	scopeNotifyMutex       sync.RWMutex
+	scopeNotifyArgsForCall []struct{}
+	scopeNotifyReturns     struct {
+		result1 <-chan struct{}
+	}
 }
 
 func (fake *FakeEvacuationNotifier) EvacuateNotify() <-chan struct{} {
@@ -40,4 +52,52 @@ func (fake *FakeEvacuationNotifier) EvacuateNotifyReturns(result1 <-chan struct{
 	}{result1}
 }
 
+func (fake *FakeEvacuationNotifier) CancelNotify() <-chan struct{} {
+	fake.cancelNotifyMutex.Lock()
+	fake.cancelNotifyArgsForCall = append(fake.cancelNotifyArgsForCall, struct{}{})
+	fake.cancelNotifyMutex.Unlock()
+	if fake.CancelNotifyStub != nil {
+		return fake.CancelNotifyStub()
+	} else {
+		return fake.cancelNotifyReturns.result1
+	}
+}
+
+func (fake *FakeEvacuationNotifier) CancelNotifyCallCount() int {
+	fake.cancelNotifyMutex.RLock()
+	defer fake.cancelNotifyMutex.RUnlock()
+	return len(fake.cancelNotifyArgsForCall)
+}
+
+func (fake *FakeEvacuationNotifier) CancelNotifyReturns(result1 <-chan struct{}) {
+	fake.CancelNotifyStub = nil
+	fake.cancelNotifyReturns = struct {
+		result1 <-chan struct{}
+	}{result1}
+}
+
+func (fake *FakeEvacuationNotifier) ScopeNotify() <-chan struct{} {
+	fake.scopeNotifyMutex.Lock()
+	fake.scopeNotifyArgsForCall = append(fake.scopeNotifyArgsForCall, struct{}{})
+	fake.scopeNotifyMutex.Unlock()
+	if fake.ScopeNotifyStub != nil {
+		return fake.ScopeNotifyStub()
+	} else {
+		return fake.scopeNotifyReturns.result1
+	}
+}
+
+func (fake *FakeEvacuationNotifier) ScopeNotifyCallCount() int {
+	fake.scopeNotifyMutex.RLock()
+	defer fake.scopeNotifyMutex.RUnlock()
+	return len(fake.scopeNotifyArgsForCall)
+}
+
+func (fake *FakeEvacuationNotifier) ScopeNotifyReturns(result1 <-chan struct{}) {
+	fake.ScopeNotifyStub = nil
+	fake.scopeNotifyReturns = struct {
+		result1 <-chan struct{}
+	}{result1}
+}
+
 var _ evacuation_context.EvacuationNotifier = new(FakeEvacuationNotifier)
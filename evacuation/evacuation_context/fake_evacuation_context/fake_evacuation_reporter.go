@@ -14,6 +14,12 @@ type FakeEvacuationReporter struct {
 	evacuatingReturns     struct {
 		result1 bool
 	}
+	EvacuationScopeStub        func() evacuation_context.Scope
+	evacuationScopeMutex       sync.RWMutex
+	evacuationScopeArgsForCall []struct{}
+	evacuationScopeReturns     struct {
+		result1 evacuation_context.Scope
+	}
 }
 
 func (fake *FakeEvacuationReporter) Evacuating() bool {
@@ -40,4 +46,28 @@ func (fake *FakeEvacuationReporter) EvacuatingReturns(result1 bool) {
 	}{result1}
 }
 
+func (fake *FakeEvacuationReporter) EvacuationScope() evacuation_context.Scope {
+	fake.evacuationScopeMutex.Lock()
+	fake.evacuationScopeArgsForCall = append(fake.evacuationScopeArgsForCall, struct{}{})
+	fake.evacuationScopeMutex.Unlock()
+	if fake.EvacuationScopeStub != nil {
+		return fake.EvacuationScopeStub()
+	} else {
+		return fake.evacuationScopeReturns.result1
+	}
+}
+
+func (fake *FakeEvacuationReporter) EvacuationScopeCallCount() int {
+	fake.evacuationScopeMutex.RLock()
+	defer fake.evacuationScopeMutex.RUnlock()
+	return len(fake.evacuationScopeArgsForCall)
+}
+
+func (fake *FakeEvacuationReporter) EvacuationScopeReturns(result1 evacuation_context.Scope) {
+	fake.EvacuationScopeStub = nil
+	fake.evacuationScopeReturns = struct {
+		result1 evacuation_context.Scope
+	}{result1}
+}
+
 var _ evacuation_context.EvacuationReporter = new(FakeEvacuationReporter)
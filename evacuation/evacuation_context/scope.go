@@ -0,0 +1,66 @@
+package evacuation_context
+
+import (
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/rep"
+)
+
+// Scope limits evacuation to the LRPs and Tasks it matches. An empty Scope
+// evacuates the whole cell.
+type Scope struct {
+	Domains       []string `json:"domains,omitempty"`
+	ProcessGuids  []string `json:"process_guids,omitempty"`
+	InstanceGuids []string `json:"instance_guids,omitempty"`
+}
+
+// All reports whether the scope covers the whole cell.
+func (s Scope) All() bool {
+	return len(s.Domains) == 0 && len(s.ProcessGuids) == 0 && len(s.InstanceGuids) == 0
+}
+
+// Matches reports whether work with the given domain, process guid and
+// instance guid falls within the scope. Empty arguments match nothing.
+func (s Scope) Matches(domain, processGuid, instanceGuid string) bool {
+	if s.All() {
+		return true
+	}
+
+	return contains(s.Domains, domain) ||
+		contains(s.ProcessGuids, processGuid) ||
+		contains(s.InstanceGuids, instanceGuid)
+}
+
+func (s Scope) MatchesContainer(container executor.Container) bool {
+	return s.Matches(
+		container.Tags[rep.DomainTag],
+		container.Tags[rep.ProcessGuidTag],
+		container.Tags[rep.InstanceGuidTag],
+	)
+}
+
+// Union returns a scope matching everything either scope matches.
+func (s Scope) Union(other Scope) Scope {
+	if s.All() || other.All() {
+		return Scope{}
+	}
+
+	return Scope{
+		Domains:       append(append([]string{}, s.Domains...), other.Domains...),
+		ProcessGuids:  append(append([]string{}, s.ProcessGuids...), other.ProcessGuids...),
+		InstanceGuids: append(append([]string{}, s.InstanceGuids...), other.InstanceGuids...),
+	}
+}
+
+func contains(values []string, value string) bool {
+	if value == "" {
+		return false
+	}
+
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
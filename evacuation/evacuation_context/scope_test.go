@@ -0,0 +1,62 @@
+package evacuation_context_test
+
+import (
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/rep"
+	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Scope", func() {
+	var scope evacuation_context.Scope
+
+	Context("when empty", func() {
+		It("matches everything", func() {
+			Ω(scope.All()).Should(BeTrue())
+			Ω(scope.Matches("domain", "process-guid", "instance-guid")).Should(BeTrue())
+			Ω(scope.Matches("", "", "")).Should(BeTrue())
+		})
+	})
+
+	Context("when given domains, process guids and instance guids", func() {
+		BeforeEach(func() {
+			scope = evacuation_context.Scope{
+				Domains:       []string{"domain"},
+				ProcessGuids:  []string{"process-guid"},
+				InstanceGuids: []string{"instance-guid"},
+			}
+		})
+
+		It("matches work with any of them", func() {
+			Ω(scope.All()).Should(BeFalse())
+			Ω(scope.Matches("domain", "", "")).Should(BeTrue())
+			Ω(scope.Matches("", "process-guid", "")).Should(BeTrue())
+			Ω(scope.Matches("", "", "instance-guid")).Should(BeTrue())
+		})
+
+		It("does not match other work", func() {
+			Ω(scope.Matches("other-domain", "other-process-guid", "other-instance-guid")).Should(BeFalse())
+			Ω(scope.Matches("", "", "")).Should(BeFalse())
+		})
+
+		It("matches containers by their tags", func() {
+			Ω(scope.MatchesContainer(executor.Container{
+				Tags: executor.Tags{rep.ProcessGuidTag: "process-guid"},
+			})).Should(BeTrue())
+
+			Ω(scope.MatchesContainer(executor.Container{
+				Tags: executor.Tags{rep.DomainTag: "other-domain"},
+			})).Should(BeFalse())
+		})
+	})
+
+	Describe("Union", func() {
+		It("is the whole cell when either scope is", func() {
+			partial := evacuation_context.Scope{Domains: []string{"domain"}}
+			Ω(partial.Union(evacuation_context.Scope{}).All()).Should(BeTrue())
+			Ω(evacuation_context.Scope{}.Union(partial).All()).Should(BeTrue())
+		})
+	})
+})
@@ -10,6 +10,9 @@ import (
 	"github.com/cloudfoundry-incubator/rep"
 	"github.com/cloudfoundry-incubator/rep/evacuation"
 	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context"
+	"github.com/cloudfoundry-incubator/runtime-schema/bbs/fake_bbs"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/onsi/gomega/gbytes"
 	"github.com/pivotal-golang/clock/fakeclock"
 	"github.com/pivotal-golang/lager/lagertest"
 	"github.com/tedsuo/ifrit"
@@ -29,7 +32,9 @@ var _ = Describe("Evacuation", func() {
 		logger             *lagertest.TestLogger
 		fakeClock          *fakeclock.FakeClock
 		executorClient     *fakes.FakeClient
+		fakeBBS            *fake_bbs.FakeRepBBS
 		evacuatable        evacuation_context.Evacuatable
+		evacuationReporter evacuation_context.EvacuationReporter
 		evacuationNotifier evacuation_context.EvacuationNotifier
 
 		evacuator *evacuation.Evacuator
@@ -46,17 +51,23 @@ var _ = Describe("Evacuation", func() {
 		logger = lagertest.NewTestLogger("test")
 		fakeClock = fakeclock.NewFakeClock(time.Now())
 		executorClient = &fakes.FakeClient{}
+		fakeBBS = &fake_bbs.FakeRepBBS{}
 
-		evacuatable, _, evacuationNotifier = evacuation_context.New()
+		evacuatable, evacuationReporter, evacuationNotifier = evacuation_context.New()
 
 		evacuator = evacuation.NewEvacuator(
 			logger,
 			fakeClock,
 			executorClient,
+			fakeBBS,
+			evacuatable,
+			evacuationReporter,
 			evacuationNotifier,
 			cellID,
 			evacuationTimeout,
 			pollingInterval,
+			false,
+			"",
 		)
 
 		process = ifrit.Invoke(evacuator)
@@ -145,6 +156,67 @@ var _ = Describe("Evacuation", func() {
 				})
 			})
 
+			Context("and are all destroyed while the BBS still places work on this cell", func() {
+				var (
+					instanceLRP   models.ActualLRP
+					evacuatingLRP models.ActualLRP
+				)
+
+				BeforeEach(func() {
+					executorClient.ListContainersReturns([]executor.Container{}, nil)
+
+					instanceLRP = models.ActualLRP{
+						ActualLRPKey:         models.NewActualLRPKey("process-guid", 0, "domain"),
+						ActualLRPInstanceKey: models.NewActualLRPInstanceKey("instance-guid-0", cellID),
+					}
+					evacuatingLRP = models.ActualLRP{
+						ActualLRPKey:         models.NewActualLRPKey("process-guid", 1, "domain"),
+						ActualLRPInstanceKey: models.NewActualLRPInstanceKey("instance-guid-1", cellID),
+					}
+					fakeBBS.ActualLRPGroupsByCellIDReturns([]models.ActualLRPGroup{
+						{Instance: &instanceLRP},
+						{Evacuating: &evacuatingLRP},
+					}, nil)
+					fakeBBS.TasksByCellIDReturns([]models.Task{
+						{TaskGuid: "task-guid", Domain: "domain", CellID: cellID, State: models.TaskStateRunning},
+						{TaskGuid: "completed-task-guid", Domain: "domain", CellID: cellID, State: models.TaskStateCompleted},
+					}, nil)
+				})
+
+				It("waits for the BBS to hand off the work", func() {
+					Eventually(fakeBBS.TasksByCellIDCallCount).Should(Equal(1))
+					_, actualCellID := fakeBBS.TasksByCellIDArgsForCall(0)
+					Ω(actualCellID).Should(Equal(cellID))
+					Consistently(errChan).ShouldNot(Receive())
+
+					fakeBBS.ActualLRPGroupsByCellIDReturns([]models.ActualLRPGroup{}, nil)
+					fakeBBS.TasksByCellIDReturns([]models.Task{}, nil)
+					fakeClock.Increment(pollingInterval)
+
+					Eventually(errChan).Should(Receive(BeNil()))
+				})
+
+				It("reports what the BBS still places on this cell", func() {
+					Eventually(fakeBBS.TasksByCellIDCallCount).Should(Equal(1))
+
+					status, err := evacuator.Status(logger)
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(status.Pending).Should(Equal(evacuation.Pending{
+						InstanceLRPs:   1,
+						EvacuatingLRPs: 1,
+						Tasks:          1,
+					}))
+				})
+
+				It("logs what was still pending when it times out", func() {
+					Eventually(fakeClock.WatcherCount).Should(Equal(2))
+					fakeClock.Increment(evacuationTimeout + time.Second)
+
+					Eventually(errChan).Should(Receive(BeNil()))
+					Ω(logger).Should(gbytes.Say(`failed-to-evacuate-before-timeout.*"containers":0,"instance_lrps":1,"evacuating_lrps":1,"tasks":1`))
+				})
+			})
+
 			Context("and are not all destroyed before the timeout elapses", func() {
 				BeforeEach(func() {
 					executorClient.ListContainersReturns(containers, nil)
@@ -166,6 +238,204 @@ var _ = Describe("Evacuation", func() {
 						Eventually(errChan).Should(Receive(BeNil()))
 					})
 				})
+
+				Context("when the evacuation is canceled", func() {
+					JustBeforeEach(func() {
+						Eventually(executorClient.ListContainersCallCount).Should(Equal(1))
+						evacuatable.CancelEvacuation()
+					})
+
+					It("stops waiting for the containers to go away without exiting", func() {
+						Eventually(logger).Should(gbytes.Say("evacuation-canceled"))
+
+						fakeClock.Increment(evacuationTimeout + time.Second)
+						Consistently(errChan).ShouldNot(Receive())
+						Ω(executorClient.ListContainersCallCount()).Should(Equal(1))
+					})
+
+					It("no longer reports evacuation as active", func() {
+						Eventually(func() bool {
+							status, err := evacuator.Status(logger)
+							Ω(err).ShouldNot(HaveOccurred())
+							return status.Active
+						}).Should(BeFalse())
+					})
+
+					Context("and evacuation starts again", func() {
+						It("evacuates", func() {
+							Eventually(logger).Should(gbytes.Say("evacuation-canceled"))
+
+							executorClient.ListContainersReturns([]executor.Container{}, nil)
+							evacuatable.Evacuate()
+
+							Eventually(errChan).Should(Receive(BeNil()))
+						})
+					})
+				})
+			})
+		})
+	})
+
+	Describe("during a partial evacuation", func() {
+		var otherContainer executor.Container
+
+		BeforeEach(func() {
+			otherContainer = executor.Container{
+				Guid:  "guid-3",
+				State: executor.StateRunning,
+				Tags: map[string]string{
+					rep.LifecycleTag:   rep.LRPLifecycle,
+					rep.DomainTag:      "other-domain",
+					rep.ProcessGuidTag: "other-process-guid",
+				},
+			}
+		})
+
+		JustBeforeEach(func() {
+			evacuatable.EvacuateScope(evacuation_context.Scope{Domains: []string{"domain"}})
+		})
+
+		Context("when the containers within the scope go away", func() {
+			BeforeEach(func() {
+				executorClient.ListContainersReturns([]executor.Container{otherContainer}, nil)
+			})
+
+			It("returns the cell to service without exiting", func() {
+				Eventually(logger).Should(gbytes.Say("partial-evacuation-finished"))
+				Consistently(errChan).ShouldNot(Receive())
+				Ω(evacuationReporter.Evacuating()).Should(BeFalse())
+			})
+		})
+
+		Context("when the containers within the scope remain past the timeout", func() {
+			BeforeEach(func() {
+				executorClient.ListContainersReturns(append(containers, otherContainer), nil)
+			})
+
+			It("keeps the evacuation in force and reports the timeout without exiting", func() {
+				Eventually(executorClient.ListContainersCallCount).Should(Equal(1))
+
+				fakeClock.Increment(evacuationTimeout + time.Second)
+				Eventually(logger).Should(gbytes.Say("partial-evacuation-timed-out"))
+				Consistently(errChan).ShouldNot(Receive())
+				Ω(evacuationReporter.Evacuating()).Should(BeTrue())
+
+				status, err := evacuator.Status(logger)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(status.Active).Should(BeTrue())
+				Ω(status.TimedOut).Should(BeTrue())
+			})
+
+			Context("and then go away", func() {
+				It("returns the cell to service", func() {
+					Eventually(executorClient.ListContainersCallCount).Should(Equal(1))
+
+					fakeClock.Increment(evacuationTimeout + time.Second)
+					Eventually(logger).Should(gbytes.Say("partial-evacuation-timed-out"))
+
+					executorClient.ListContainersReturns([]executor.Container{otherContainer}, nil)
+					Eventually(func() bool {
+						fakeClock.Increment(pollingInterval)
+						return evacuationReporter.Evacuating()
+					}).Should(BeFalse())
+					Ω(logger).Should(gbytes.Say("partial-evacuation-finished"))
+				})
+			})
+
+			It("reports the scope and only the containers within it", func() {
+				Eventually(executorClient.ListContainersCallCount).Should(Equal(1))
+
+				status, err := evacuator.Status(logger)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(status.Active).Should(BeTrue())
+				Ω(status.Scope).Should(Equal(evacuation_context.Scope{Domains: []string{"domain"}}))
+				Ω(status.Containers.Total).Should(Equal(1))
+				Ω(status.Containers.ByDomain).Should(Equal(map[string]int{"domain": 1}))
+			})
+		})
+	})
+
+	Describe("Status", func() {
+		Context("before evacuating", func() {
+			It("reports that evacuation is not active", func() {
+				status, err := evacuator.Status(logger)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(status.Active).Should(BeFalse())
+				Ω(status.StartedAt).Should(BeNil())
+			})
+		})
+
+		Context("during evacuation", func() {
+			var (
+				startedAt     time.Time
+				evacuatingLRP models.ActualLRP
+			)
+
+			BeforeEach(func() {
+				responses := [][]executor.Container{containers, containers[1:]}
+				index := 0
+				executorClient.ListContainersStub = func(executor.Tags) ([]executor.Container, error) {
+					response := responses[index]
+					if index < len(responses)-1 {
+						index++
+					}
+					return response, nil
+				}
+
+				evacuatingLRP = models.ActualLRP{ActualLRPKey: models.NewActualLRPKey("process-guid", 2, "domain")}
+				fakeBBS.ActualLRPGroupsByCellIDReturns([]models.ActualLRPGroup{
+					{Evacuating: &evacuatingLRP},
+					{Instance: &models.ActualLRP{ActualLRPKey: models.NewActualLRPKey("other-process-guid", 0, "domain")}},
+				}, nil)
+
+				startedAt = fakeClock.Now()
+				evacuatable.Evacuate()
+				Eventually(executorClient.ListContainersCallCount).Should(Equal(1))
+			})
+
+			It("reports when evacuation started and how long remains", func() {
+				fakeClock.Increment(time.Minute)
+
+				status, err := evacuator.Status(logger)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(status.Active).Should(BeTrue())
+				Ω(*status.StartedAt).Should(BeTemporally("==", startedAt))
+				Ω(status.TimeRemainingInSeconds).Should(BeEquivalentTo((evacuationTimeout - time.Minute) / time.Second))
+			})
+
+			It("breaks down the remaining containers", func() {
+				status, err := evacuator.Status(logger)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(status.Containers.Total).Should(Equal(1))
+				Ω(status.Containers.ByLifecycle).Should(Equal(map[string]int{rep.LRPLifecycle: 1}))
+				Ω(status.Containers.ByState).Should(Equal(map[string]int{string(executor.StateRunning): 1}))
+				Ω(status.Containers.ByDomain).Should(Equal(map[string]int{"domain": 1}))
+			})
+
+			It("lists the LRPs still evacuating in the BBS", func() {
+				status, err := evacuator.Status(logger)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(fakeBBS.ActualLRPGroupsByCellIDArgsForCall(0)).Should(Equal(cellID))
+				Ω(status.EvacuatingLRPs).Should(ConsistOf(evacuatingLRP))
+			})
+
+			It("estimates completion from the rate containers have gone away", func() {
+				fakeClock.Increment(pollingInterval)
+				Eventually(executorClient.ListContainersCallCount).Should(Equal(2))
+
+				status, err := evacuator.Status(logger)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(*status.EstimatedCompletion).Should(BeTemporally("==", fakeClock.Now().Add(pollingInterval)))
+			})
+
+			Context("when listing containers fails", func() {
+				It("returns the error", func() {
+					executorClient.ListContainersStub = nil
+					executorClient.ListContainersReturns(nil, errors.New("boom"))
+
+					_, err := evacuator.Status(logger)
+					Ω(err).Should(MatchError("boom"))
+				})
 			})
 		})
 	})
@@ -0,0 +1,56 @@
+// This file was generated by counterfeiter
+package fake_evacuation
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/rep/evacuation"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeStatusReporter struct {
+	StatusStub        func(logger lager.Logger) (evacuation.Status, error)
+	statusMutex       sync.RWMutex
+	statusArgsForCall []struct {
+		logger lager.Logger
+	}
+	statusReturns struct {
+		result1 evacuation.Status
+		result2 error
+	}
+}
+
+func (fake *FakeStatusReporter) Status(logger lager.Logger) (evacuation.Status, error) {
+	fake.statusMutex.Lock()
+	fake.statusArgsForCall = append(fake.statusArgsForCall, struct {
+		logger lager.Logger
+	}{logger})
+	fake.statusMutex.Unlock()
+	if fake.StatusStub != nil {
+		return fake.StatusStub(logger)
+	} else {
+		return fake.statusReturns.result1, fake.statusReturns.result2
+	}
+}
+
+func (fake *FakeStatusReporter) StatusCallCount() int {
+	fake.statusMutex.RLock()
+	defer fake.statusMutex.RUnlock()
+	return len(fake.statusArgsForCall)
+}
+
+func (fake *FakeStatusReporter) StatusArgsForCall(i int) lager.Logger {
+	fake.statusMutex.RLock()
+	defer fake.statusMutex.RUnlock()
+	return fake.statusArgsForCall[i].logger
+}
+
+func (fake *FakeStatusReporter) StatusReturns(result1 evacuation.Status, result2 error) {
+	fake.StatusStub = nil
+	fake.statusReturns = struct {
+		result1 evacuation.Status
+		result2 error
+	}{result1, result2}
+}
+
+var _ evacuation.StatusReporter = new(FakeStatusReporter)
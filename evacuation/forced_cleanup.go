@@ -0,0 +1,142 @@
+package evacuation
+
+import (
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/rep"
+	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context"
+	"github.com/cloudfoundry-incubator/runtime-schema/metric"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/pivotal-golang/lager"
+)
+
+const TaskCompletionReasonEvacuationTimedOut = "cell evacuation timed out before task completed"
+
+const (
+	forcedCleanupContainers     = metric.Metric("RepForcedCleanupContainers")
+	forcedCleanupInstanceLRPs   = metric.Metric("RepForcedCleanupInstanceLRPs")
+	forcedCleanupEvacuatingLRPs = metric.Metric("RepForcedCleanupEvacuatingLRPs")
+	forcedCleanupTasks          = metric.Metric("RepForcedCleanupTasks")
+)
+
+// CleanupSummary lists everything removed by force once evacuation timed out.
+type CleanupSummary struct {
+	Containers     []string              `json:"containers"`
+	InstanceLRPs   []models.ActualLRPKey `json:"instance_lrps"`
+	EvacuatingLRPs []models.ActualLRPKey `json:"evacuating_lrps"`
+	FailedTasks    []string              `json:"failed_tasks"`
+}
+
+// forceCleanup removes whatever evacuation left behind: it fails the tasks
+// still running, whether or not they have a container, stops and deletes the
+// remaining containers, and removes the instance and evacuating ActualLRPs
+// this cell still holds.
+func (e *Evacuator) forceCleanup(logger lager.Logger) CleanupSummary {
+	logger = logger.Session("forced-cleanup")
+	logger.Info("started")
+	defer logger.Info("finished")
+
+	summary := CleanupSummary{
+		Containers:     []string{},
+		InstanceLRPs:   []models.ActualLRPKey{},
+		EvacuatingLRPs: []models.ActualLRPKey{},
+		FailedTasks:    []string{},
+	}
+
+	scope := e.evacuationReporter.EvacuationScope()
+
+	containers, err := e.executorClient.ListContainers(nil)
+	if err != nil {
+		logger.Error("failed-to-list-containers", err)
+	}
+
+	for _, container := range scopedContainers(containers, scope) {
+		if container.Tags[rep.LifecycleTag] == rep.TaskLifecycle && container.State != executor.StateCompleted {
+			err := e.bbs.FailTask(logger, container.Guid, TaskCompletionReasonEvacuationTimedOut)
+			if err != nil {
+				logger.Error("failed-to-fail-task", err, lager.Data{"task-guid": container.Guid})
+			} else {
+				summary.FailedTasks = append(summary.FailedTasks, container.Guid)
+			}
+		}
+
+		err := e.executorClient.StopContainer(container.Guid)
+		if err != nil && err != executor.ErrContainerNotFound {
+			logger.Error("failed-to-stop-container", err, lager.Data{"container-guid": container.Guid})
+		}
+
+		err = e.executorClient.DeleteContainer(container.Guid)
+		if err != nil && err != executor.ErrContainerNotFound {
+			logger.Error("failed-to-delete-container", err, lager.Data{"container-guid": container.Guid})
+			continue
+		}
+
+		summary.Containers = append(summary.Containers, container.Guid)
+	}
+
+	failed := make(map[string]struct{}, len(summary.FailedTasks))
+	for _, guid := range summary.FailedTasks {
+		failed[guid] = struct{}{}
+	}
+
+	tasks, err := e.bbs.TasksByCellID(logger, e.cellID)
+	if err != nil {
+		logger.Error("failed-to-retrieve-tasks", err)
+	}
+
+	for _, task := range tasks {
+		if _, found := failed[task.TaskGuid]; found {
+			continue
+		}
+
+		if task.State != models.TaskStateRunning || !scope.Matches(task.Domain, "", "") {
+			continue
+		}
+
+		err := e.bbs.FailTask(logger, task.TaskGuid, TaskCompletionReasonEvacuationTimedOut)
+		if err != nil {
+			logger.Error("failed-to-fail-task", err, lager.Data{"task-guid": task.TaskGuid})
+		} else {
+			summary.FailedTasks = append(summary.FailedTasks, task.TaskGuid)
+		}
+	}
+
+	groups, err := e.bbs.ActualLRPGroupsByCellID(e.cellID)
+	if err != nil {
+		logger.Error("failed-to-retrieve-lrp-groups", err)
+	}
+
+	for _, group := range groups {
+		if lrp := group.Instance; e.ownedAndInScope(lrp, scope) {
+			err := e.bbs.RemoveActualLRP(logger, lrp.ActualLRPKey, lrp.ActualLRPInstanceKey)
+			if err != nil {
+				logger.Error("failed-to-remove-actual-lrp", err, lager.Data{"lrp-key": lrp.ActualLRPKey})
+			} else {
+				summary.InstanceLRPs = append(summary.InstanceLRPs, lrp.ActualLRPKey)
+			}
+		}
+
+		if lrp := group.Evacuating; e.ownedAndInScope(lrp, scope) {
+			err := e.bbs.RemoveEvacuatingActualLRP(logger, lrp.ActualLRPKey, lrp.ActualLRPInstanceKey)
+			if err != nil {
+				logger.Error("failed-to-remove-evacuating-actual-lrp", err, lager.Data{"lrp-key": lrp.ActualLRPKey})
+			} else {
+				summary.EvacuatingLRPs = append(summary.EvacuatingLRPs, lrp.ActualLRPKey)
+			}
+		}
+	}
+
+	logger.Info("summary", lager.Data{"summary": summary})
+
+	forcedCleanupContainers.Send(len(summary.Containers))
+	forcedCleanupInstanceLRPs.Send(len(summary.InstanceLRPs))
+	forcedCleanupEvacuatingLRPs.Send(len(summary.EvacuatingLRPs))
+	forcedCleanupTasks.Send(len(summary.FailedTasks))
+
+	return summary
+}
+
+func (e *Evacuator) ownedAndInScope(lrp *models.ActualLRP, scope evacuation_context.Scope) bool {
+	return lrp != nil &&
+		lrp.CellID == e.cellID &&
+		scope.Matches(lrp.Domain, lrp.ProcessGuid, lrp.InstanceGuid)
+}
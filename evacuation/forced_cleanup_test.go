@@ -0,0 +1,188 @@
+package evacuation_test
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/executor/fakes"
+	"github.com/cloudfoundry-incubator/rep"
+	"github.com/cloudfoundry-incubator/rep/evacuation"
+	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context"
+	"github.com/cloudfoundry-incubator/runtime-schema/bbs/fake_bbs"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/cloudfoundry/dropsonde/metric_sender/fake"
+	"github.com/cloudfoundry/dropsonde/metrics"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager/lagertest"
+	"github.com/tedsuo/ifrit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Forced cleanup", func() {
+	const (
+		cellID            = "cell-id"
+		pollingInterval   = 30 * time.Second
+		evacuationTimeout = 3 * pollingInterval
+	)
+
+	var (
+		logger         *lagertest.TestLogger
+		fakeClock      *fakeclock.FakeClock
+		executorClient *fakes.FakeClient
+		fakeBBS        *fake_bbs.FakeRepBBS
+		sender         *fake.FakeMetricSender
+		forceCleanup   bool
+
+		evacuatable evacuation_context.Evacuatable
+		process     ifrit.Process
+
+		instanceLRP   models.ActualLRP
+		evacuatingLRP models.ActualLRP
+		otherCellLRP  models.ActualLRP
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		executorClient = new(fakes.FakeClient)
+		fakeBBS = new(fake_bbs.FakeRepBBS)
+		sender = fake.NewFakeMetricSender()
+		metrics.Initialize(sender)
+		forceCleanup = true
+
+		executorClient.ListContainersReturns([]executor.Container{
+			{Guid: "task-guid", State: executor.StateRunning, Tags: executor.Tags{rep.LifecycleTag: rep.TaskLifecycle}},
+			{Guid: "lrp-guid", State: executor.StateRunning, Tags: executor.Tags{rep.LifecycleTag: rep.LRPLifecycle}},
+		}, nil)
+
+		instanceLRP = models.ActualLRP{
+			ActualLRPKey:         models.NewActualLRPKey("process-guid", 0, "domain"),
+			ActualLRPInstanceKey: models.NewActualLRPInstanceKey("instance-guid-0", cellID),
+		}
+		evacuatingLRP = models.ActualLRP{
+			ActualLRPKey:         models.NewActualLRPKey("process-guid", 1, "domain"),
+			ActualLRPInstanceKey: models.NewActualLRPInstanceKey("instance-guid-1", cellID),
+		}
+		otherCellLRP = models.ActualLRP{
+			ActualLRPKey:         models.NewActualLRPKey("process-guid", 1, "domain"),
+			ActualLRPInstanceKey: models.NewActualLRPInstanceKey("instance-guid-2", "other-cell-id"),
+		}
+
+		fakeBBS.TasksByCellIDReturns([]models.Task{
+			{TaskGuid: "task-guid", Domain: "domain", State: models.TaskStateRunning},
+			{TaskGuid: "containerless-task-guid", Domain: "domain", State: models.TaskStateRunning},
+			{TaskGuid: "completed-task-guid", Domain: "domain", State: models.TaskStateCompleted},
+		}, nil)
+
+		fakeBBS.ActualLRPGroupsByCellIDReturns([]models.ActualLRPGroup{
+			{Instance: &instanceLRP},
+			{Instance: &otherCellLRP, Evacuating: &evacuatingLRP},
+		}, nil)
+	})
+
+	JustBeforeEach(func() {
+		var evacuationReporter evacuation_context.EvacuationReporter
+		var evacuationNotifier evacuation_context.EvacuationNotifier
+		evacuatable, evacuationReporter, evacuationNotifier = evacuation_context.New()
+
+		process = ifrit.Invoke(evacuation.NewEvacuator(
+			logger,
+			fakeClock,
+			executorClient,
+			fakeBBS,
+			evacuatable,
+			evacuationReporter,
+			evacuationNotifier,
+			cellID,
+			evacuationTimeout,
+			pollingInterval,
+			forceCleanup,
+			"",
+		))
+
+		evacuatable.Evacuate()
+		Eventually(executorClient.ListContainersCallCount).Should(Equal(1))
+
+		fakeClock.Increment(evacuationTimeout + time.Second)
+		Eventually(process.Wait()).Should(Receive(BeNil()))
+	})
+
+	AfterEach(func() {
+		process.Signal(os.Interrupt)
+	})
+
+	It("fails the tasks still running", func() {
+		Ω(fakeBBS.FailTaskCallCount()).Should(Equal(2))
+		_, taskGuid, reason := fakeBBS.FailTaskArgsForCall(0)
+		Ω(taskGuid).Should(Equal("task-guid"))
+		Ω(reason).Should(Equal(evacuation.TaskCompletionReasonEvacuationTimedOut))
+	})
+
+	It("fails the tasks the BBS still runs on this cell without a container", func() {
+		Ω(fakeBBS.FailTaskCallCount()).Should(Equal(2))
+		_, taskGuid, reason := fakeBBS.FailTaskArgsForCall(1)
+		Ω(taskGuid).Should(Equal("containerless-task-guid"))
+		Ω(reason).Should(Equal(evacuation.TaskCompletionReasonEvacuationTimedOut))
+	})
+
+	It("stops and deletes the remaining containers", func() {
+		Ω(executorClient.StopContainerCallCount()).Should(Equal(2))
+		Ω(executorClient.DeleteContainerCallCount()).Should(Equal(2))
+		Ω(executorClient.DeleteContainerArgsForCall(0)).Should(Equal("task-guid"))
+		Ω(executorClient.DeleteContainerArgsForCall(1)).Should(Equal("lrp-guid"))
+	})
+
+	It("removes the instance ActualLRPs on this cell", func() {
+		Ω(fakeBBS.RemoveActualLRPCallCount()).Should(Equal(1))
+		_, lrpKey, instanceKey := fakeBBS.RemoveActualLRPArgsForCall(0)
+		Ω(lrpKey).Should(Equal(instanceLRP.ActualLRPKey))
+		Ω(instanceKey).Should(Equal(instanceLRP.ActualLRPInstanceKey))
+	})
+
+	It("removes the evacuating ActualLRPs on this cell", func() {
+		Ω(fakeBBS.RemoveEvacuatingActualLRPCallCount()).Should(Equal(1))
+		_, lrpKey, instanceKey := fakeBBS.RemoveEvacuatingActualLRPArgsForCall(0)
+		Ω(lrpKey).Should(Equal(evacuatingLRP.ActualLRPKey))
+		Ω(instanceKey).Should(Equal(evacuatingLRP.ActualLRPInstanceKey))
+	})
+
+	It("emits a summary of everything removed", func() {
+		Ω(logger).Should(gbytes.Say("forced-cleanup.summary"))
+
+		Ω(sender.GetValue("RepForcedCleanupContainers").Value).Should(Equal(float64(2)))
+		Ω(sender.GetValue("RepForcedCleanupInstanceLRPs").Value).Should(Equal(float64(1)))
+		Ω(sender.GetValue("RepForcedCleanupEvacuatingLRPs").Value).Should(Equal(float64(1)))
+		Ω(sender.GetValue("RepForcedCleanupTasks").Value).Should(Equal(float64(2)))
+	})
+
+	Context("when removing something fails", func() {
+		BeforeEach(func() {
+			executorClient.DeleteContainerReturns(errors.New("boom"))
+			fakeBBS.RemoveActualLRPReturns(errors.New("boom"))
+		})
+
+		It("carries on and leaves it out of the summary", func() {
+			Ω(fakeBBS.RemoveEvacuatingActualLRPCallCount()).Should(Equal(1))
+			Ω(sender.GetValue("RepForcedCleanupContainers").Value).Should(Equal(float64(0)))
+			Ω(sender.GetValue("RepForcedCleanupInstanceLRPs").Value).Should(Equal(float64(0)))
+		})
+	})
+
+	Context("when forced cleanup is disabled", func() {
+		BeforeEach(func() {
+			forceCleanup = false
+		})
+
+		It("leaves everything in place", func() {
+			Ω(executorClient.DeleteContainerCallCount()).Should(BeZero())
+			Ω(fakeBBS.FailTaskCallCount()).Should(BeZero())
+			Ω(fakeBBS.RemoveActualLRPCallCount()).Should(BeZero())
+			Ω(fakeBBS.RemoveEvacuatingActualLRPCallCount()).Should(BeZero())
+		})
+	})
+})
@@ -0,0 +1,107 @@
+package evacuation
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context"
+	"github.com/pivotal-golang/lager"
+)
+
+// persistedState is written to the state file while an evacuation is in
+// progress, so that a restarted rep carries on evacuating.
+type persistedState struct {
+	StartedAt time.Time                `json:"started_at"`
+	Scope     evacuation_context.Scope `json:"scope"`
+}
+
+// Resume restarts an evacuation that was in progress when the rep last
+// exited, with whatever remains of its timeout. It must be called before the
+// Evacuator is run.
+func (e *Evacuator) Resume(logger lager.Logger) error {
+	if e.stateFile == "" {
+		return nil
+	}
+
+	logger = logger.Session("resuming-evacuation", lager.Data{"state-file": e.stateFile})
+
+	payload, err := ioutil.ReadFile(e.stateFile)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		logger.Error("failed-to-read-state-file", err)
+		return err
+	}
+
+	var state persistedState
+	err = json.Unmarshal(payload, &state)
+	if err != nil {
+		logger.Error("failed-to-parse-state-file", err)
+		return err
+	}
+
+	logger.Info("resumed", lager.Data{
+		"started-at": state.StartedAt,
+		"remaining":  e.remainingTimeout(state.StartedAt).String(),
+	})
+
+	e.resumedStartedAt = state.StartedAt
+	e.evacuatable.EvacuateScope(state.Scope)
+
+	return nil
+}
+
+func (e *Evacuator) saveState(logger lager.Logger, state persistedState) {
+	if e.stateFile == "" {
+		return
+	}
+
+	payload, err := json.Marshal(state)
+	if err != nil {
+		logger.Error("failed-to-marshal-state", err)
+		return
+	}
+
+	tmpFile, err := ioutil.TempFile(filepath.Dir(e.stateFile), filepath.Base(e.stateFile))
+	if err != nil {
+		logger.Error("failed-to-create-state-file", err)
+		return
+	}
+
+	_, err = tmpFile.Write(payload)
+	tmpFile.Close()
+	if err != nil {
+		logger.Error("failed-to-write-state-file", err)
+		os.Remove(tmpFile.Name())
+		return
+	}
+
+	err = os.Rename(tmpFile.Name(), e.stateFile)
+	if err != nil {
+		logger.Error("failed-to-write-state-file", err)
+		os.Remove(tmpFile.Name())
+	}
+}
+
+func (e *Evacuator) removeState(logger lager.Logger) {
+	if e.stateFile == "" {
+		return
+	}
+
+	err := os.Remove(e.stateFile)
+	if err != nil && !os.IsNotExist(err) {
+		logger.Error("failed-to-remove-state-file", err)
+	}
+}
+
+func (e *Evacuator) remainingTimeout(startedAt time.Time) time.Duration {
+	remaining := startedAt.Add(e.evacuationTimeout).Sub(e.clock.Now())
+	if remaining < 0 {
+		return 0
+	}
+
+	return remaining
+}
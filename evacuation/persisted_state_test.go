@@ -0,0 +1,218 @@
+package evacuation_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/executor/fakes"
+	"github.com/cloudfoundry-incubator/rep/evacuation"
+	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context"
+	"github.com/cloudfoundry-incubator/runtime-schema/bbs/fake_bbs"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager/lagertest"
+	"github.com/tedsuo/ifrit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Persisted evacuation state", func() {
+	const evacuationTimeout = 10 * time.Minute
+
+	var (
+		logger         *lagertest.TestLogger
+		fakeClock      *fakeclock.FakeClock
+		executorClient *fakes.FakeClient
+		tmpDir         string
+		stateFile      string
+
+		evacuatable        evacuation_context.Evacuatable
+		evacuationReporter evacuation_context.EvacuationReporter
+		evacuator          *evacuation.Evacuator
+		process            ifrit.Process
+	)
+
+	newEvacuator := func() {
+		var evacuationNotifier evacuation_context.EvacuationNotifier
+		evacuatable, evacuationReporter, evacuationNotifier = evacuation_context.New()
+
+		evacuator = evacuation.NewEvacuator(
+			logger,
+			fakeClock,
+			executorClient,
+			new(fake_bbs.FakeRepBBS),
+			evacuatable,
+			evacuationReporter,
+			evacuationNotifier,
+			"cell-id",
+			evacuationTimeout,
+			time.Second,
+			false,
+			stateFile,
+		)
+	}
+
+	stateFileExists := func() bool {
+		_, err := os.Stat(stateFile)
+		return err == nil
+	}
+
+	persistedScope := func() evacuation_context.Scope {
+		payload, err := ioutil.ReadFile(stateFile)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		var state struct {
+			Scope evacuation_context.Scope `json:"scope"`
+		}
+		Ω(json.Unmarshal(payload, &state)).Should(Succeed())
+		return state.Scope
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		executorClient = new(fakes.FakeClient)
+		executorClient.ListContainersReturns([]executor.Container{{Guid: "guid"}}, nil)
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "evacuation-state")
+		Ω(err).ShouldNot(HaveOccurred())
+		stateFile = filepath.Join(tmpDir, "evacuation.json")
+
+		newEvacuator()
+	})
+
+	AfterEach(func() {
+		if process != nil {
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive())
+			process = nil
+		}
+		os.RemoveAll(tmpDir)
+	})
+
+	Context("when evacuation starts", func() {
+		var startedAt time.Time
+
+		BeforeEach(func() {
+			startedAt = fakeClock.Now()
+			process = ifrit.Invoke(evacuator)
+			evacuatable.EvacuateScope(evacuation_context.Scope{Domains: []string{"domain"}})
+		})
+
+		It("records when it started and its scope", func() {
+			Eventually(stateFileExists).Should(BeTrue())
+
+			payload, err := ioutil.ReadFile(stateFile)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			var state struct {
+				StartedAt time.Time                `json:"started_at"`
+				Scope     evacuation_context.Scope `json:"scope"`
+			}
+			Ω(json.Unmarshal(payload, &state)).Should(Succeed())
+			Ω(state.StartedAt).Should(BeTemporally("==", startedAt))
+			Ω(state.Scope).Should(Equal(evacuation_context.Scope{Domains: []string{"domain"}}))
+		})
+
+		Context("when the scope is widened", func() {
+			It("records the wider scope", func() {
+				Eventually(stateFileExists).Should(BeTrue())
+
+				evacuatable.EvacuateScope(evacuation_context.Scope{ProcessGuids: []string{"process-guid"}})
+
+				Eventually(persistedScope).Should(Equal(evacuation_context.Scope{
+					Domains:      []string{"domain"},
+					ProcessGuids: []string{"process-guid"},
+				}))
+			})
+		})
+
+		Context("when the whole cell is evacuated on shutdown", func() {
+			It("records that the whole cell is evacuating", func() {
+				Eventually(stateFileExists).Should(BeTrue())
+
+				evacuatable.Evacuate()
+
+				Eventually(func() bool {
+					return persistedScope().All()
+				}).Should(BeTrue())
+			})
+		})
+
+		Context("when the evacuation is canceled", func() {
+			It("removes the record", func() {
+				Eventually(stateFileExists).Should(BeTrue())
+				evacuatable.CancelEvacuation()
+				Eventually(stateFileExists).Should(BeFalse())
+			})
+		})
+
+		Context("when the rep is stopped", func() {
+			It("keeps the record", func() {
+				Eventually(stateFileExists).Should(BeTrue())
+				process.Signal(os.Interrupt)
+				Eventually(process.Wait()).Should(Receive())
+				process = nil
+
+				Ω(stateFileExists()).Should(BeTrue())
+			})
+		})
+	})
+
+	Context("when the rep restarts mid-evacuation", func() {
+		BeforeEach(func() {
+			process = ifrit.Invoke(evacuator)
+			evacuatable.Evacuate()
+			Eventually(stateFileExists).Should(BeTrue())
+
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive())
+			process = nil
+
+			fakeClock.Increment(evacuationTimeout - time.Minute)
+			newEvacuator()
+		})
+
+		It("resumes evacuating", func() {
+			Ω(evacuator.Resume(logger)).Should(Succeed())
+			Ω(evacuationReporter.Evacuating()).Should(BeTrue())
+		})
+
+		It("times out once the rest of the original timeout has elapsed", func() {
+			Ω(evacuator.Resume(logger)).Should(Succeed())
+			process = ifrit.Invoke(evacuator)
+
+			Eventually(func() int64 {
+				status, err := evacuator.Status(logger)
+				Ω(err).ShouldNot(HaveOccurred())
+				return status.TimeRemainingInSeconds
+			}).Should(BeEquivalentTo(60))
+
+			exited := process.Wait()
+			Eventually(func() bool {
+				fakeClock.Increment(time.Second)
+				select {
+				case <-exited:
+					return true
+				default:
+					return false
+				}
+			}).Should(BeTrue())
+			process = nil
+
+			Ω(stateFileExists()).Should(BeFalse())
+		})
+	})
+
+	Context("when there is no record", func() {
+		It("does not evacuate", func() {
+			Ω(evacuator.Resume(logger)).Should(Succeed())
+			Ω(evacuationReporter.Evacuating()).Should(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,93 @@
+package evacuation
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context"
+	"github.com/pivotal-golang/lager"
+	"github.com/tedsuo/ifrit"
+)
+
+// SignalRunner runs the rep's members and lets OS signals start evacuation.
+//
+// The evacuation signal starts evacuation and is not passed on. With
+// evacuateOnShutdown, the first termination signal also starts evacuation
+// instead of being passed on, so that the members are shut down only once the
+// Evacuator finishes or times out. Any further signal is passed on as usual,
+// as is the termination signal should that evacuation be canceled.
+type SignalRunner struct {
+	logger             lager.Logger
+	runner             ifrit.Runner
+	evacuatable        evacuation_context.Evacuatable
+	evacuationNotifier evacuation_context.EvacuationNotifier
+	evacuationSignal   os.Signal
+	evacuateOnShutdown bool
+}
+
+func NewSignalRunner(
+	logger lager.Logger,
+	runner ifrit.Runner,
+	evacuatable evacuation_context.Evacuatable,
+	evacuationNotifier evacuation_context.EvacuationNotifier,
+	evacuationSignal os.Signal,
+	evacuateOnShutdown bool,
+) *SignalRunner {
+	return &SignalRunner{
+		logger:             logger,
+		runner:             runner,
+		evacuatable:        evacuatable,
+		evacuationNotifier: evacuationNotifier,
+		evacuationSignal:   evacuationSignal,
+		evacuateOnShutdown: evacuateOnShutdown,
+	}
+}
+
+func (r *SignalRunner) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	logger := r.logger.Session("evacuation-signals")
+
+	process := ifrit.Background(r.runner)
+
+	select {
+	case <-process.Ready():
+	case err := <-process.Wait():
+		return err
+	}
+
+	close(ready)
+
+	var shutdownSignal os.Signal
+	var cancelNotify <-chan struct{}
+
+	for {
+		select {
+		case err := <-process.Wait():
+			return err
+
+		case <-cancelNotify:
+			logger.Info("shutdown-evacuation-canceled", lager.Data{"signal": shutdownSignal.String()})
+			cancelNotify = nil
+			process.Signal(shutdownSignal)
+
+		case signal := <-signals:
+			switch {
+			case r.evacuationSignal != nil && signal == r.evacuationSignal:
+				logger.Info("evacuating-on-signal", lager.Data{"signal": signal.String()})
+				r.evacuatable.Evacuate()
+
+			case r.evacuateOnShutdown && shutdownSignal == nil && isTerminationSignal(signal):
+				logger.Info("evacuating-before-shutdown", lager.Data{"signal": signal.String()})
+				shutdownSignal = signal
+				cancelNotify = r.evacuationNotifier.CancelNotify()
+				r.evacuatable.Evacuate()
+
+			default:
+				process.Signal(signal)
+			}
+		}
+	}
+}
+
+func isTerminationSignal(signal os.Signal) bool {
+	return signal == os.Interrupt || signal == syscall.SIGTERM
+}
@@ -0,0 +1,121 @@
+package evacuation_test
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/cloudfoundry-incubator/rep/evacuation"
+	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context"
+	"github.com/pivotal-golang/lager/lagertest"
+	"github.com/tedsuo/ifrit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SignalRunner", func() {
+	var (
+		evacuatable        evacuation_context.Evacuatable
+		evacuationReporter evacuation_context.EvacuationReporter
+		evacuationNotifier evacuation_context.EvacuationNotifier
+		evacuateOnShutdown bool
+
+		received chan os.Signal
+		exit     chan struct{}
+		process  ifrit.Process
+	)
+
+	BeforeEach(func() {
+		evacuatable, evacuationReporter, evacuationNotifier = evacuation_context.New()
+		evacuateOnShutdown = false
+
+		received = make(chan os.Signal, 10)
+		exit = make(chan struct{})
+	})
+
+	JustBeforeEach(func() {
+		members := ifrit.RunFunc(func(signals <-chan os.Signal, ready chan<- struct{}) error {
+			close(ready)
+			for {
+				select {
+				case signal := <-signals:
+					received <- signal
+					if signal == os.Interrupt || signal == syscall.SIGTERM {
+						return nil
+					}
+				case <-exit:
+					return nil
+				}
+			}
+		})
+
+		process = ifrit.Invoke(evacuation.NewSignalRunner(
+			lagertest.NewTestLogger("test"),
+			members,
+			evacuatable,
+			evacuationNotifier,
+			syscall.SIGUSR1,
+			evacuateOnShutdown,
+		))
+	})
+
+	AfterEach(func() {
+		process.Signal(os.Kill)
+		close(exit)
+		Eventually(process.Wait()).Should(Receive())
+	})
+
+	Context("when sent the evacuation signal", func() {
+		JustBeforeEach(func() {
+			process.Signal(syscall.SIGUSR1)
+		})
+
+		It("starts evacuation", func() {
+			Eventually(evacuationReporter.Evacuating).Should(BeTrue())
+		})
+
+		It("does not pass the signal on", func() {
+			Consistently(received).ShouldNot(Receive())
+		})
+	})
+
+	Context("when sent a termination signal", func() {
+		JustBeforeEach(func() {
+			process.Signal(syscall.SIGTERM)
+		})
+
+		It("passes it on without evacuating", func() {
+			Eventually(received).Should(Receive(Equal(syscall.SIGTERM)))
+			Ω(evacuationReporter.Evacuating()).Should(BeFalse())
+		})
+
+		Context("when evacuating on shutdown", func() {
+			BeforeEach(func() {
+				evacuateOnShutdown = true
+			})
+
+			It("starts evacuation instead of passing it on", func() {
+				Eventually(evacuationReporter.Evacuating).Should(BeTrue())
+				Consistently(received).ShouldNot(Receive())
+			})
+
+			It("exits once the members do, when the Evacuator finishes", func() {
+				Eventually(evacuationReporter.Evacuating).Should(BeTrue())
+				exit <- struct{}{}
+				Eventually(process.Wait()).Should(Receive(BeNil()))
+			})
+
+			It("passes on a second termination signal", func() {
+				Eventually(evacuationReporter.Evacuating).Should(BeTrue())
+				process.Signal(syscall.SIGTERM)
+				Eventually(received).Should(Receive(Equal(syscall.SIGTERM)))
+			})
+
+			It("passes the signal on if the evacuation is canceled", func() {
+				Eventually(evacuationReporter.Evacuating).Should(BeTrue())
+				evacuatable.CancelEvacuation()
+				Eventually(received).Should(Receive(Equal(syscall.SIGTERM)))
+			})
+		})
+	})
+})
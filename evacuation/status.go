@@ -0,0 +1,144 @@
+package evacuation
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/rep"
+	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/pivotal-golang/lager"
+)
+
+//go:generate counterfeiter -o fake_evacuation/fake_status_reporter.go . StatusReporter
+
+// StatusReporter reports the progress of an evacuation.
+type StatusReporter interface {
+	Status(logger lager.Logger) (Status, error)
+}
+
+type Status struct {
+	Active    bool       `json:"active"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
+
+	// Scope is what is being evacuated. It is empty for the whole cell.
+	Scope evacuation_context.Scope `json:"scope"`
+
+	// TimeRemainingInSeconds is the time left before the evacuation timeout.
+	TimeRemainingInSeconds int64 `json:"time_remaining_in_seconds"`
+
+	// TimedOut is set once a partial evacuation has outlasted the timeout. It
+	// stays in force until done or canceled.
+	TimedOut bool `json:"timed_out"`
+
+	// Pending is what the last completion check found still on this cell, in
+	// the executor and in the BBS.
+	Pending Pending `json:"pending"`
+
+	Containers     ContainerCounts    `json:"containers"`
+	EvacuatingLRPs []models.ActualLRP `json:"evacuating_lrps"`
+
+	// EstimatedCompletion extrapolates the rate at which containers have gone
+	// away since evacuation started. It is absent until some have.
+	EstimatedCompletion *time.Time `json:"estimated_completion,omitempty"`
+}
+
+// Pending counts what an evacuation is still waiting on. Containers are those
+// the executor lists; the rest are what the BBS still places on the cell.
+type Pending struct {
+	Containers     int `json:"containers"`
+	InstanceLRPs   int `json:"instance_lrps"`
+	EvacuatingLRPs int `json:"evacuating_lrps"`
+	Tasks          int `json:"tasks"`
+}
+
+// Done reports whether nothing is pending on either side.
+func (p Pending) Done() bool {
+	return p == Pending{}
+}
+
+type ContainerCounts struct {
+	Total       int            `json:"total"`
+	ByLifecycle map[string]int `json:"by_lifecycle"`
+	ByState     map[string]int `json:"by_state"`
+	ByDomain    map[string]int `json:"by_domain"`
+}
+
+func countContainers(containers []executor.Container) ContainerCounts {
+	counts := ContainerCounts{
+		Total:       len(containers),
+		ByLifecycle: make(map[string]int),
+		ByState:     make(map[string]int),
+		ByDomain:    make(map[string]int),
+	}
+
+	for _, container := range containers {
+		counts.ByLifecycle[container.Tags[rep.LifecycleTag]]++
+		counts.ByState[string(container.State)]++
+		counts.ByDomain[container.Tags[rep.DomainTag]]++
+	}
+
+	return counts
+}
+
+func (e *Evacuator) Status(logger lager.Logger) (Status, error) {
+	logger = logger.Session("evacuation-status")
+
+	e.statusLock.Lock()
+	startedAt := e.startedAt
+	initialContainers := e.initialContainers
+	pending := e.pending
+	timedOut := e.timedOut
+	e.statusLock.Unlock()
+
+	if startedAt.IsZero() {
+		return Status{Active: false, EvacuatingLRPs: []models.ActualLRP{}}, nil
+	}
+
+	now := e.clock.Now()
+	scope := e.evacuationReporter.EvacuationScope()
+
+	status := Status{
+		Active:         true,
+		StartedAt:      &startedAt,
+		Scope:          scope,
+		Pending:        pending,
+		TimedOut:       timedOut,
+		EvacuatingLRPs: []models.ActualLRP{},
+	}
+
+	timeRemaining := startedAt.Add(e.evacuationTimeout).Sub(now)
+	if timeRemaining > 0 {
+		status.TimeRemainingInSeconds = int64(timeRemaining / time.Second)
+	}
+
+	containers, err := e.executorClient.ListContainers(nil)
+	if err != nil {
+		logger.Error("failed-to-list-containers", err)
+		return Status{}, err
+	}
+	containers = scopedContainers(containers, scope)
+	status.Containers = countContainers(containers)
+
+	groups, err := e.bbs.ActualLRPGroupsByCellID(e.cellID)
+	if err != nil {
+		logger.Error("failed-to-retrieve-lrp-groups", err)
+		return Status{}, err
+	}
+
+	for _, group := range groups {
+		if group.Evacuating != nil && scope.Matches(group.Evacuating.Domain, group.Evacuating.ProcessGuid, group.Evacuating.InstanceGuid) {
+			status.EvacuatingLRPs = append(status.EvacuatingLRPs, *group.Evacuating)
+		}
+	}
+
+	evacuated := initialContainers - len(containers)
+	elapsed := now.Sub(startedAt)
+	if evacuated > 0 && elapsed > 0 {
+		perContainer := elapsed / time.Duration(evacuated)
+		estimate := now.Add(perContainer * time.Duration(len(containers)))
+		status.EstimatedCompletion = &estimate
+	}
+
+	return status, nil
+}
@@ -19,13 +19,22 @@ type FakeGenerator struct {
 		result1 map[string]operationq.Operation
 		result2 error
 	}
-	OperationStreamStub        func(lager.Logger) (<-chan operationq.Operation, error)
+	OperationStreamStub        func(lager.Logger) (<-chan generator.StreamedOperation, error)
 	operationStreamMutex       sync.RWMutex
 	operationStreamArgsForCall []struct {
 		arg1 lager.Logger
 	}
 	operationStreamReturns struct {
-		result1 <-chan operationq.Operation
+		result1 <-chan generator.StreamedOperation
+		result2 error
+	}
+	DiffStub        func(lager.Logger) (map[string]generator.Reconciliation, error)
+	diffMutex       sync.RWMutex
+	diffArgsForCall []struct {
+		arg1 lager.Logger
+	}
+	diffReturns struct {
+		result1 map[string]generator.Reconciliation
 		result2 error
 	}
 }
@@ -63,7 +72,7 @@ func (fake *FakeGenerator) BatchOperationsReturns(result1 map[string]operationq.
 	}{result1, result2}
 }
 
-func (fake *FakeGenerator) OperationStream(arg1 lager.Logger) (<-chan operationq.Operation, error) {
+func (fake *FakeGenerator) OperationStream(arg1 lager.Logger) (<-chan generator.StreamedOperation, error) {
 	fake.operationStreamMutex.Lock()
 	fake.operationStreamArgsForCall = append(fake.operationStreamArgsForCall, struct {
 		arg1 lager.Logger
@@ -88,10 +97,43 @@ func (fake *FakeGenerator) OperationStreamArgsForCall(i int) lager.Logger {
 	return fake.operationStreamArgsForCall[i].arg1
 }
 
-func (fake *FakeGenerator) OperationStreamReturns(result1 <-chan operationq.Operation, result2 error) {
+func (fake *FakeGenerator) OperationStreamReturns(result1 <-chan generator.StreamedOperation, result2 error) {
 	fake.OperationStreamStub = nil
 	fake.operationStreamReturns = struct {
-		result1 <-chan operationq.Operation
+		result1 <-chan generator.StreamedOperation
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGenerator) Diff(arg1 lager.Logger) (map[string]generator.Reconciliation, error) {
+	fake.diffMutex.Lock()
+	fake.diffArgsForCall = append(fake.diffArgsForCall, struct {
+		arg1 lager.Logger
+	}{arg1})
+	fake.diffMutex.Unlock()
+	if fake.DiffStub != nil {
+		return fake.DiffStub(arg1)
+	} else {
+		return fake.diffReturns.result1, fake.diffReturns.result2
+	}
+}
+
+func (fake *FakeGenerator) DiffCallCount() int {
+	fake.diffMutex.RLock()
+	defer fake.diffMutex.RUnlock()
+	return len(fake.diffArgsForCall)
+}
+
+func (fake *FakeGenerator) DiffArgsForCall(i int) lager.Logger {
+	fake.diffMutex.RLock()
+	defer fake.diffMutex.RUnlock()
+	return fake.diffArgsForCall[i].arg1
+}
+
+func (fake *FakeGenerator) DiffReturns(result1 map[string]generator.Reconciliation, result2 error) {
+	fake.DiffStub = nil
+	fake.diffReturns = struct {
+		result1 map[string]generator.Reconciliation
 		result2 error
 	}{result1, result2}
 }
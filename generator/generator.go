@@ -3,12 +3,15 @@ package generator
 
 import (
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/cloudfoundry-incubator/executor"
 	"github.com/cloudfoundry-incubator/rep"
 	"github.com/cloudfoundry-incubator/rep/generator/internal"
 	"github.com/cloudfoundry-incubator/runtime-schema/bbs"
 	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/pivotal-golang/clock"
 	"github.com/pivotal-golang/lager"
 	"github.com/pivotal-golang/operationq"
 )
@@ -21,7 +24,11 @@ type Generator interface {
 	BatchOperations(lager.Logger) (map[string]operationq.Operation, error)
 
 	// OperationStream creates an operation every time a container lifecycle event is observed.
-	OperationStream(lager.Logger) (<-chan operationq.Operation, error)
+	OperationStream(lager.Logger) (<-chan StreamedOperation, error)
+
+	// Diff reports, for every guid on the cell, what the executor and the BBS know
+	// about it and the operation BatchOperations would create for it.
+	Diff(lager.Logger) (map[string]Reconciliation, error)
 }
 
 type generator struct {
@@ -31,8 +38,19 @@ type generator struct {
 	lrpProcessor      internal.LRPProcessor
 	taskProcessor     internal.TaskProcessor
 	containerDelegate internal.ContainerDelegate
+
+	clock                clock.Clock
+	containerCacheMaxAge time.Duration
+	containerChanges     *internal.ContainerChanges
+	runRetries           *internal.RunRetries
 }
 
+// New creates a Generator. Operations created by BatchOperations share the
+// containers listed for the batch for up to containerCacheMaxAge; zero
+// disables the cache. A container recorded in containerChanges since it was
+// listed is always fetched afresh; OperationStream records every container
+// it receives an event for, and also streams an operation for each task
+// container whose run retry is due.
 func New(
 	cellID string,
 	bbs bbs.RepBBS,
@@ -40,14 +58,22 @@ func New(
 	lrpProcessor internal.LRPProcessor,
 	taskProcessor internal.TaskProcessor,
 	containerDelegate internal.ContainerDelegate,
+	clock clock.Clock,
+	containerCacheMaxAge time.Duration,
+	containerChanges *internal.ContainerChanges,
+	runRetries *internal.RunRetries,
 ) Generator {
 	return &generator{
-		cellID:            cellID,
-		bbs:               bbs,
-		executorClient:    executorClient,
-		lrpProcessor:      lrpProcessor,
-		taskProcessor:     taskProcessor,
-		containerDelegate: containerDelegate,
+		cellID:               cellID,
+		bbs:                  bbs,
+		executorClient:       executorClient,
+		lrpProcessor:         lrpProcessor,
+		taskProcessor:        taskProcessor,
+		containerDelegate:    containerDelegate,
+		clock:                clock,
+		containerCacheMaxAge: containerCacheMaxAge,
+		containerChanges:     containerChanges,
+		runRetries:           runRetries,
 	}
 }
 
@@ -55,12 +81,41 @@ func (g *generator) BatchOperations(logger lager.Logger) (map[string]operationq.
 	logger = logger.Session("batch-operations")
 	logger.Info("started")
 
+	diff, err := g.diff(logger)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := make(map[string]operationq.Operation, len(diff))
+	for guid, reconciliation := range diff {
+		batch[guid] = reconciliation.Operation
+	}
+
+	logger.Info("succeeded", lager.Data{"batch-size": len(batch)})
+	return batch, nil
+}
+
+func (g *generator) Diff(logger lager.Logger) (map[string]Reconciliation, error) {
+	logger = logger.Session("diff")
+	logger.Info("started")
+
+	diff, err := g.diff(logger)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("succeeded", lager.Data{"diff-size": len(diff)})
+	return diff, nil
+}
+
+func (g *generator) diff(logger lager.Logger) (map[string]Reconciliation, error) {
 	containers := make(map[string]executor.Container)
 	instanceLRPs := make(map[string]models.ActualLRP)
 	evacuatingLRPs := make(map[string]models.ActualLRP)
 	tasks := make(map[string]models.Task)
 
 	errChan := make(chan error, 3)
+	listedAt := g.clock.Now()
 
 	go func() {
 		foundContainers, err := g.executorClient.ListContainers(nil)
@@ -119,46 +174,62 @@ func (g *generator) BatchOperations(logger lager.Logger) (map[string]operationq.
 		return nil, err
 	}
 
-	batch := make(map[string]operationq.Operation)
+	diff := make(map[string]Reconciliation)
 
-	// create operations for processes with containers
-	for guid, _ := range containers {
-		batch[guid] = g.operationFromContainer(logger, guid)
+	for guid, container := range containers {
+		container := container
+		diff[guid] = Reconciliation{Guid: guid, Container: &container}
 	}
 
-	// create operations for instance lrps with no containers
 	for guid, lrp := range instanceLRPs {
-		if _, foundContainer := batch[guid]; foundContainer {
-			continue
-		}
-		if _, foundEvacuatingLRP := evacuatingLRPs[guid]; foundEvacuatingLRP {
-			batch[guid] = NewResidualJointLRPOperation(logger, g.bbs, g.containerDelegate, lrp.ActualLRPKey, lrp.ActualLRPInstanceKey)
-		} else {
-			batch[guid] = NewResidualInstanceLRPOperation(logger, g.bbs, g.containerDelegate, lrp.ActualLRPKey, lrp.ActualLRPInstanceKey)
-		}
+		lrp := lrp
+		reconciliation := diff[guid]
+		reconciliation.Guid = guid
+		reconciliation.InstanceLRP = &lrp
+		diff[guid] = reconciliation
 	}
 
-	// create operations for evacuating lrps with no containers
 	for guid, lrp := range evacuatingLRPs {
-		_, found := batch[guid]
-		if !found {
-			batch[guid] = NewResidualEvacuatingLRPOperation(logger, g.bbs, g.containerDelegate, lrp.ActualLRPKey, lrp.ActualLRPInstanceKey)
-		}
+		lrp := lrp
+		reconciliation := diff[guid]
+		reconciliation.Guid = guid
+		reconciliation.EvacuatingLRP = &lrp
+		diff[guid] = reconciliation
 	}
 
-	// create operations for tasks with no containers
-	for guid, _ := range tasks {
-		_, found := batch[guid]
-		if !found {
-			batch[guid] = NewResidualTaskOperation(logger, g.bbs, g.containerDelegate, guid)
-		}
+	for guid, task := range tasks {
+		task := task
+		reconciliation := diff[guid]
+		reconciliation.Guid = guid
+		reconciliation.Task = &task
+		diff[guid] = reconciliation
 	}
 
-	logger.Info("succeeded", lager.Data{"batch-size": len(batch)})
-	return batch, nil
+	g.runRetries.Retain(listedAt, containers)
+
+	containerDelegate := g.containerDelegate
+	if g.containerCacheMaxAge > 0 {
+		g.containerChanges.Forget(listedAt.Add(-g.containerCacheMaxAge))
+		containerDelegate = internal.NewContainerCache(g.containerDelegate, g.clock, g.containerCacheMaxAge, g.containerChanges, containers, listedAt)
+	}
+
+	for guid, reconciliation := range diff {
+		reconciliation.Operation = g.operationFromReconciliation(logger, containerDelegate, reconciliation)
+		reconciliation.OperationType = OperationType(reconciliation.Operation)
+		diff[guid] = reconciliation
+	}
+
+	return diff, nil
 }
 
-func (g *generator) OperationStream(logger lager.Logger) (<-chan operationq.Operation, error) {
+// StreamedOperation is an operation created for a container lifecycle event,
+// with the time the rep read the event off the executor's stream.
+type StreamedOperation struct {
+	Operation  operationq.Operation
+	ReceivedAt time.Time
+}
+
+func (g *generator) OperationStream(logger lager.Logger) (<-chan StreamedOperation, error) {
 	logger = logger.Session("operation-stream")
 
 	logger.Info("subscribing")
@@ -170,19 +241,55 @@ func (g *generator) OperationStream(logger lager.Logger) (<-chan operationq.Oper
 
 	logger.Info("succeeded-subscribing")
 
-	opChan := make(chan operationq.Operation)
+	opChan := make(chan StreamedOperation)
+	closed := make(chan struct{})
+
+	wg := new(sync.WaitGroup)
+	wg.Add(2)
+
+	go func() {
+		wg.Wait()
+		close(opChan)
+	}()
 
 	go func() {
+		defer wg.Done()
+
+		for {
+			select {
+			case guid := <-g.runRetries.Due():
+				logger.Debug("run-retry-due", lager.Data{"container-guid": guid})
+				streamed := StreamedOperation{
+					Operation:  NewContainerOperation(logger, g.lrpProcessor, g.taskProcessor, g.containerDelegate, guid, rep.TaskLifecycle),
+					ReceivedAt: g.clock.Now(),
+				}
+
+				select {
+				case opChan <- streamed:
+				case <-closed:
+					return
+				}
+
+			case <-closed:
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
 		defer events.Close()
 
 		for {
 			e, err := events.Next()
 			if err != nil {
 				logger.Debug("event-stream-closed")
-				close(opChan)
+				close(closed)
 				return
 			}
 
+			receivedAt := g.clock.Now()
+
 			lifecycle, ok := e.(executor.LifecycleEvent)
 			if !ok {
 				logger.Debug("received-non-lifecycle-event")
@@ -190,13 +297,44 @@ func (g *generator) OperationStream(logger lager.Logger) (<-chan operationq.Oper
 			}
 
 			container := lifecycle.Container()
-			opChan <- g.operationFromContainer(logger, container.Guid)
+			g.containerChanges.Record(container.Guid)
+
+			opChan <- StreamedOperation{
+				Operation:  g.operationFromContainer(logger, g.containerDelegate, container),
+				ReceivedAt: receivedAt,
+			}
 		}
 	}()
 
 	return opChan, nil
 }
 
-func (g *generator) operationFromContainer(logger lager.Logger, guid string) operationq.Operation {
-	return NewContainerOperation(logger, g.lrpProcessor, g.taskProcessor, g.containerDelegate, guid)
+// operationFromReconciliation creates the operation for a reconciliation.
+// Only container operations are given the sync's container delegate: residual
+// operations check for a container created since the listing, so they always
+// ask the executor.
+func (g *generator) operationFromReconciliation(logger lager.Logger, containerDelegate internal.ContainerDelegate, r Reconciliation) operationq.Operation {
+	switch {
+	// create operations for processes with containers
+	case r.Container != nil:
+		return g.operationFromContainer(logger, containerDelegate, *r.Container)
+
+	// create operations for instance lrps with no containers
+	case r.InstanceLRP != nil && r.EvacuatingLRP != nil:
+		return NewResidualJointLRPOperation(logger, g.bbs, g.containerDelegate, r.InstanceLRP.ActualLRPKey, r.InstanceLRP.ActualLRPInstanceKey)
+	case r.InstanceLRP != nil:
+		return NewResidualInstanceLRPOperation(logger, g.bbs, g.containerDelegate, r.InstanceLRP.ActualLRPKey, r.InstanceLRP.ActualLRPInstanceKey)
+
+	// create operations for evacuating lrps with no containers
+	case r.EvacuatingLRP != nil:
+		return NewResidualEvacuatingLRPOperation(logger, g.bbs, g.containerDelegate, r.EvacuatingLRP.ActualLRPKey, r.EvacuatingLRP.ActualLRPInstanceKey)
+
+	// create operations for tasks with no containers
+	default:
+		return NewResidualTaskOperation(logger, g.bbs, g.containerDelegate, r.Guid)
+	}
+}
+
+func (g *generator) operationFromContainer(logger lager.Logger, containerDelegate internal.ContainerDelegate, container executor.Container) operationq.Operation {
+	return NewContainerOperation(logger, g.lrpProcessor, g.taskProcessor, containerDelegate, container.Guid, container.Tags[rep.LifecycleTag])
 }
@@ -2,13 +2,16 @@ package generator_test
 
 import (
 	"errors"
+	"time"
 
 	"github.com/cloudfoundry-incubator/executor"
 	efakes "github.com/cloudfoundry-incubator/executor/fakes"
 	"github.com/cloudfoundry-incubator/rep"
 	"github.com/cloudfoundry-incubator/rep/generator"
+	"github.com/cloudfoundry-incubator/rep/generator/internal"
 	"github.com/cloudfoundry-incubator/rep/generator/internal/fake_internal"
 	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/pivotal-golang/clock/fakeclock"
 	"github.com/pivotal-golang/operationq"
 
 	. "github.com/onsi/ginkgo"
@@ -29,6 +32,10 @@ var _ = Describe("Generator", func() {
 		fakeLRPProcessor      *fake_internal.FakeLRPProcessor
 		fakeTaskProcessor     *fake_internal.FakeTaskProcessor
 		fakeContainerDelegate *fake_internal.FakeContainerDelegate
+		fakeClock             *fakeclock.FakeClock
+		containerCacheMaxAge  time.Duration
+		containerChanges      *internal.ContainerChanges
+		runRetries            *internal.RunRetries
 
 		opGenerator generator.Generator
 	)
@@ -39,8 +46,14 @@ var _ = Describe("Generator", func() {
 		fakeLRPProcessor = &fake_internal.FakeLRPProcessor{}
 		fakeTaskProcessor = &fake_internal.FakeTaskProcessor{}
 		fakeContainerDelegate = &fake_internal.FakeContainerDelegate{}
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		containerCacheMaxAge = 0
+		containerChanges = internal.NewContainerChanges(fakeClock)
+		runRetries = internal.NewRunRetries(fakeClock)
+	})
 
-		opGenerator = generator.New(cellID, fakeBBS, fakeExecutorClient, fakeLRPProcessor, fakeTaskProcessor, fakeContainerDelegate)
+	JustBeforeEach(func() {
+		opGenerator = generator.New(cellID, fakeBBS, fakeExecutorClient, fakeLRPProcessor, fakeTaskProcessor, fakeContainerDelegate, fakeClock, containerCacheMaxAge, containerChanges, runRetries)
 	})
 
 	Describe("BatchOperations", func() {
@@ -185,6 +198,63 @@ var _ = Describe("Generator", func() {
 				Ω(batch[guid]).Should(BeAssignableToTypeOf(new(generator.ResidualTaskOperation)))
 			})
 
+			Context("when run retries are scheduled", func() {
+				BeforeEach(func() {
+					runRetries.Schedule(guidContainerForTask, nil, time.Second)
+					runRetries.Schedule("gone-guid", nil, time.Second)
+					fakeClock.Increment(time.Millisecond)
+				})
+
+				It("forgets the retries of containers that no longer exist", func() {
+					fakeClock.Increment(time.Second)
+					Eventually(runRetries.Due()).Should(Receive(Equal(guidContainerForTask)))
+					Consistently(runRetries.Due()).ShouldNot(Receive())
+				})
+			})
+
+			Context("when the container cache is enabled", func() {
+				BeforeEach(func() {
+					containerCacheMaxAge = time.Minute
+
+					fakeExecutorClient.ListContainersReturns([]executor.Container{
+						{
+							Guid: rep.LRPContainerGuid(processGuid, instanceGuidContainerOnly),
+							Tags: executor.Tags{rep.LifecycleTag: rep.LRPLifecycle},
+						},
+					}, nil)
+				})
+
+				It("hands the listed container to the container operation", func() {
+					guid := rep.LRPContainerGuid(processGuid, instanceGuidContainerOnly)
+
+					batch[guid].Execute()
+
+					Ω(fakeContainerDelegate.GetContainerCallCount()).Should(Equal(0))
+					Ω(fakeLRPProcessor.ProcessCallCount()).Should(Equal(1))
+					_, container := fakeLRPProcessor.ProcessArgsForCall(0)
+					Ω(container.Guid).Should(Equal(guid))
+				})
+
+				It("fetches a container changed since the listing from the executor", func() {
+					guid := rep.LRPContainerGuid(processGuid, instanceGuidContainerOnly)
+					containerChanges.Record(guid)
+					fakeContainerDelegate.GetContainerReturns(executor.Container{Guid: guid}, true)
+
+					batch[guid].Execute()
+
+					Ω(fakeContainerDelegate.GetContainerCallCount()).Should(Equal(1))
+				})
+
+				It("checks residual operations against the executor", func() {
+					fakeContainerDelegate.GetContainerReturns(executor.Container{Guid: guidTaskOnly}, true)
+
+					batch[guidTaskOnly].Execute()
+
+					Ω(fakeContainerDelegate.GetContainerCallCount()).Should(Equal(1))
+					Ω(fakeBBS.FailTaskCallCount()).Should(Equal(0))
+				})
+			})
+
 		})
 
 		Context("when retrieving data fails", func() {
@@ -235,11 +305,86 @@ var _ = Describe("Generator", func() {
 		})
 	})
 
+	Describe("Diff", func() {
+		const (
+			processGuid = "process-guid"
+			taskGuid    = "guid-task-only"
+		)
+
+		var (
+			containerGuid   string
+			residualLRPGuid string
+
+			diff    map[string]generator.Reconciliation
+			diffErr error
+		)
+
+		BeforeEach(func() {
+			containerGuid = rep.LRPContainerGuid(processGuid, "guid-container-for-instance-lrp")
+			residualLRPGuid = rep.LRPContainerGuid(processGuid, "guid-instance-lrp-only")
+
+			actualLRPKey := models.ActualLRPKey{ProcessGuid: processGuid}
+			containerLRP := models.ActualLRP{ActualLRPKey: actualLRPKey, ActualLRPInstanceKey: models.NewActualLRPInstanceKey("guid-container-for-instance-lrp", cellID)}
+			residualLRP := models.ActualLRP{ActualLRPKey: actualLRPKey, ActualLRPInstanceKey: models.NewActualLRPInstanceKey("guid-instance-lrp-only", cellID)}
+
+			fakeExecutorClient.ListContainersReturns([]executor.Container{{Guid: containerGuid}}, nil)
+			fakeBBS.ActualLRPGroupsByCellIDReturns([]models.ActualLRPGroup{
+				{Instance: &containerLRP},
+				{Instance: &residualLRP, Evacuating: &residualLRP},
+			}, nil)
+			fakeBBS.TasksByCellIDReturns([]models.Task{{TaskGuid: taskGuid}}, nil)
+		})
+
+		JustBeforeEach(func() {
+			diff, diffErr = opGenerator.Diff(logger)
+		})
+
+		It("does not return an error", func() {
+			Ω(diffErr).ShouldNot(HaveOccurred())
+		})
+
+		It("reports what the executor and the bbs have for a guid with a container", func() {
+			Ω(diff).Should(HaveKey(containerGuid))
+			reconciliation := diff[containerGuid]
+			Ω(reconciliation.Container).ShouldNot(BeNil())
+			Ω(reconciliation.InstanceLRP).ShouldNot(BeNil())
+			Ω(reconciliation.EvacuatingLRP).Should(BeNil())
+			Ω(reconciliation.OperationType).Should(Equal(generator.ContainerOperationType))
+			Ω(reconciliation.Operation).Should(BeAssignableToTypeOf(new(generator.ContainerOperation)))
+		})
+
+		It("reports the residual operation for lrps with no container", func() {
+			Ω(diff).Should(HaveKey(residualLRPGuid))
+			reconciliation := diff[residualLRPGuid]
+			Ω(reconciliation.Container).Should(BeNil())
+			Ω(reconciliation.InstanceLRP).ShouldNot(BeNil())
+			Ω(reconciliation.EvacuatingLRP).ShouldNot(BeNil())
+			Ω(reconciliation.OperationType).Should(Equal(generator.ResidualJointOperationType))
+		})
+
+		It("reports the residual operation for tasks with no container", func() {
+			Ω(diff).Should(HaveKey(taskGuid))
+			reconciliation := diff[taskGuid]
+			Ω(reconciliation.Task).ShouldNot(BeNil())
+			Ω(reconciliation.OperationType).Should(Equal(generator.ResidualTaskOperationType))
+		})
+
+		Context("when retrieving data fails", func() {
+			BeforeEach(func() {
+				fakeBBS.TasksByCellIDReturns(nil, errors.New("oh no, no task!"))
+			})
+
+			It("returns an error", func() {
+				Ω(diffErr).Should(MatchError(ContainSubstring("oh no, no task!")))
+			})
+		})
+	})
+
 	Describe("OperationStream", func() {
 		const sessionPrefix = "test.operation-stream."
 
 		var (
-			stream    <-chan operationq.Operation
+			stream    <-chan generator.StreamedOperation
 			streamErr error
 		)
 
@@ -272,6 +417,25 @@ var _ = Describe("Generator", func() {
 				Ω(logger).Should(Say(sessionPrefix + "succeeded-subscribing"))
 			})
 
+			Context("when a task container's run retry is due", func() {
+				BeforeEach(func() {
+					runRetries.Schedule("some-task-guid", nil, time.Second)
+				})
+
+				AfterEach(func() {
+					close(receivedEvents)
+				})
+
+				It("yields an operation for that container", func() {
+					fakeClock.Increment(time.Second)
+
+					var streamed generator.StreamedOperation
+					Eventually(stream).Should(Receive(&streamed))
+					Ω(streamed.Operation.Key()).Should(Equal("some-task-guid"))
+					Ω(generator.OperationLifecycle(streamed.Operation)).Should(Equal(rep.TaskLifecycle))
+				})
+			})
+
 			Context("when the event stream closes", func() {
 				BeforeEach(func() {
 					close(receivedEvents)
@@ -318,9 +482,21 @@ var _ = Describe("Generator", func() {
 						})
 
 						It("yields an operation for that container", func() {
-							var operation operationq.Operation
-							Eventually(stream).Should(Receive(&operation))
-							Ω(operation.Key()).Should(Equal(container.Guid))
+							var streamed generator.StreamedOperation
+							Eventually(stream).Should(Receive(&streamed))
+							Ω(streamed.Operation.Key()).Should(Equal(container.Guid))
+							Ω(generator.OperationLifecycle(streamed.Operation)).Should(Equal(rep.LRPLifecycle))
+						})
+
+						It("records when the event was received", func() {
+							var streamed generator.StreamedOperation
+							Eventually(stream).Should(Receive(&streamed))
+							Ω(streamed.ReceivedAt).Should(BeTemporally("==", fakeClock.Now()))
+						})
+
+						It("records the container as changed", func() {
+							Eventually(stream).Should(Receive())
+							Ω(containerChanges.ChangedSince(container.Guid, fakeClock.Now())).Should(BeTrue())
 						})
 					})
 
@@ -340,9 +516,10 @@ var _ = Describe("Generator", func() {
 						})
 
 						It("yields an operation for that container", func() {
-							var operation operationq.Operation
-							Eventually(stream).Should(Receive(&operation))
-							Ω(operation.Key()).Should(Equal(container.Guid))
+							var streamed generator.StreamedOperation
+							Eventually(stream).Should(Receive(&streamed))
+							Ω(streamed.Operation.Key()).Should(Equal(container.Guid))
+							Ω(generator.OperationLifecycle(streamed.Operation)).Should(Equal(rep.TaskLifecycle))
 						})
 					})
 				})
@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+// containerCache is a ContainerDelegate that answers GetContainer from the
+// containers listed during a sync. Once the snapshot is older than maxAge the
+// next lookup refreshes every cached guid in a single batch, so operations
+// running well after the sync still see the current state of their container.
+// A container recorded as changed since the snapshot was taken is always
+// fetched from the delegate.
+type containerCache struct {
+	ContainerDelegate
+
+	clock   clock.Clock
+	maxAge  time.Duration
+	changes *ContainerChanges
+	guids   []string
+	cached  map[string]struct{}
+
+	mu         sync.Mutex
+	containers map[string]executor.Container
+	fetchedAt  time.Time
+	refreshing chan struct{}
+}
+
+// NewContainerCache returns a delegate whose GetContainer answers for the
+// containers listed at listedAt during the sync. Lookups of any other guid,
+// such as those with only BBS records, fall through to the delegate so that a
+// container created since the listing is never reported missing.
+func NewContainerCache(
+	delegate ContainerDelegate,
+	clock clock.Clock,
+	maxAge time.Duration,
+	changes *ContainerChanges,
+	containers map[string]executor.Container,
+	listedAt time.Time,
+) ContainerDelegate {
+	guids := make([]string, 0, len(containers))
+	cached := make(map[string]struct{}, len(containers))
+	for guid := range containers {
+		guids = append(guids, guid)
+		cached[guid] = struct{}{}
+	}
+
+	return &containerCache{
+		ContainerDelegate: delegate,
+		clock:             clock,
+		maxAge:            maxAge,
+		changes:           changes,
+		guids:             guids,
+		cached:            cached,
+		containers:        containers,
+		fetchedAt:         listedAt,
+	}
+}
+
+func (c *containerCache) GetContainer(logger lager.Logger, guid string) (executor.Container, bool) {
+	if _, ok := c.cached[guid]; !ok {
+		return c.ContainerDelegate.GetContainer(logger, guid)
+	}
+
+	containers, fetchedAt, err := c.snapshot(logger)
+	if err != nil || c.changes.ChangedSince(guid, fetchedAt) {
+		return c.ContainerDelegate.GetContainer(logger, guid)
+	}
+
+	container, ok := containers[guid]
+	return container, ok
+}
+
+// snapshot returns the cached containers and when they were fetched,
+// refreshing them first when they are too old. Only one refresh is made at a
+// time, without holding the lock; other lookups wait for it.
+func (c *containerCache) snapshot(logger lager.Logger) (map[string]executor.Container, time.Time, error) {
+	c.mu.Lock()
+	for c.refreshing != nil {
+		refreshing := c.refreshing
+		c.mu.Unlock()
+		<-refreshing
+		c.mu.Lock()
+	}
+
+	if c.clock.Now().Sub(c.fetchedAt) <= c.maxAge {
+		containers, fetchedAt := c.containers, c.fetchedAt
+		c.mu.Unlock()
+		return containers, fetchedAt, nil
+	}
+
+	refreshing := make(chan struct{})
+	c.refreshing = refreshing
+	c.mu.Unlock()
+
+	fetchedAt := c.clock.Now()
+	logger.Info("refreshing-container-cache", lager.Data{"count": len(c.guids)})
+	containers, err := c.ContainerDelegate.GetContainers(logger, c.guids)
+	if err != nil {
+		logger.Error("failed-refreshing-container-cache", err)
+	}
+
+	c.mu.Lock()
+	if err == nil {
+		c.containers = containers
+		c.fetchedAt = fetchedAt
+	}
+	c.refreshing = nil
+	close(refreshing)
+	c.mu.Unlock()
+
+	return containers, fetchedAt, err
+}
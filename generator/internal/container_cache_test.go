@@ -0,0 +1,145 @@
+package internal_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/rep/generator/internal"
+	"github.com/cloudfoundry-incubator/rep/generator/internal/fake_internal"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ContainerCache", func() {
+	var (
+		delegate  *fake_internal.FakeContainerDelegate
+		fakeClock *fakeclock.FakeClock
+		logger    *lagertest.TestLogger
+		changes   *internal.ContainerChanges
+		cache     internal.ContainerDelegate
+	)
+
+	BeforeEach(func() {
+		delegate = new(fake_internal.FakeContainerDelegate)
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		logger = lagertest.NewTestLogger("test")
+		changes = internal.NewContainerChanges(fakeClock)
+		listedAt := fakeClock.Now()
+
+		cache = internal.NewContainerCache(
+			delegate,
+			fakeClock,
+			time.Minute,
+			changes,
+			map[string]executor.Container{
+				"listed-guid": {Guid: "listed-guid", State: executor.StateRunning},
+				"gone-guid":   {Guid: "gone-guid", State: executor.StateRunning},
+			},
+			listedAt,
+		)
+	})
+
+	Context("while the snapshot is fresh", func() {
+		It("returns listed containers without asking the delegate", func() {
+			container, ok := cache.GetContainer(logger, "listed-guid")
+			Ω(ok).Should(BeTrue())
+			Ω(container.State).Should(Equal(executor.StateRunning))
+			Ω(delegate.GetContainerCallCount()).Should(Equal(0))
+		})
+	})
+
+	Context("when the container changed since it was listed", func() {
+		BeforeEach(func() {
+			changes.Record("listed-guid")
+			delegate.GetContainerReturns(executor.Container{Guid: "listed-guid", State: executor.StateReserved}, true)
+		})
+
+		It("asks the delegate for its current state", func() {
+			container, ok := cache.GetContainer(logger, "listed-guid")
+			Ω(ok).Should(BeTrue())
+			Ω(container.State).Should(Equal(executor.StateReserved))
+			Ω(delegate.GetContainerCallCount()).Should(Equal(1))
+		})
+
+		It("still answers for the other listed containers", func() {
+			_, ok := cache.GetContainer(logger, "gone-guid")
+			Ω(ok).Should(BeTrue())
+			Ω(delegate.GetContainerCallCount()).Should(Equal(0))
+		})
+	})
+
+	Context("when the guid was not listed during the sync", func() {
+		BeforeEach(func() {
+			delegate.GetContainerReturns(executor.Container{Guid: "other-guid"}, true)
+		})
+
+		It("asks the delegate", func() {
+			container, ok := cache.GetContainer(logger, "other-guid")
+			Ω(ok).Should(BeTrue())
+			Ω(container.Guid).Should(Equal("other-guid"))
+			Ω(delegate.GetContainerCallCount()).Should(Equal(1))
+		})
+	})
+
+	Context("when the snapshot is stale", func() {
+		BeforeEach(func() {
+			fakeClock.Increment(time.Minute + time.Second)
+			delegate.GetContainersReturns(map[string]executor.Container{
+				"listed-guid": {Guid: "listed-guid", State: executor.StateCompleted},
+			}, nil)
+		})
+
+		It("refreshes every cached guid in one batch", func() {
+			container, ok := cache.GetContainer(logger, "listed-guid")
+			Ω(ok).Should(BeTrue())
+			Ω(container.State).Should(Equal(executor.StateCompleted))
+
+			_, ok = cache.GetContainer(logger, "gone-guid")
+			Ω(ok).Should(BeFalse())
+
+			Ω(delegate.GetContainersCallCount()).Should(Equal(1))
+			_, guids := delegate.GetContainersArgsForCall(0)
+			Ω(guids).Should(ConsistOf("listed-guid", "gone-guid"))
+		})
+
+		It("does not hold up other lookups while refreshing", func() {
+			release := make(chan struct{})
+			delegate.GetContainersStub = func(lager.Logger, []string) (map[string]executor.Container, error) {
+				<-release
+				return map[string]executor.Container{}, nil
+			}
+
+			refreshed := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				cache.GetContainer(logger, "listed-guid")
+				close(refreshed)
+			}()
+
+			Eventually(delegate.GetContainersCallCount).Should(Equal(1))
+			cache.GetContainer(logger, "other-guid")
+			Ω(delegate.GetContainerCallCount()).Should(Equal(1))
+
+			close(release)
+			Eventually(refreshed).Should(BeClosed())
+		})
+
+		Context("and refreshing fails", func() {
+			BeforeEach(func() {
+				delegate.GetContainersReturns(nil, errors.New("boom"))
+				delegate.GetContainerReturns(executor.Container{Guid: "listed-guid"}, true)
+			})
+
+			It("falls back to fetching the single container", func() {
+				_, ok := cache.GetContainer(logger, "listed-guid")
+				Ω(ok).Should(BeTrue())
+				Ω(delegate.GetContainerCallCount()).Should(Equal(1))
+			})
+		})
+	})
+})
@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+// ContainerChanges records when each container was last changed by the rep,
+// or reported changed by the executor's event stream, so that a container
+// cache never answers for a container with state listed before then.
+type ContainerChanges struct {
+	clock clock.Clock
+
+	mu        sync.Mutex
+	changedAt map[string]time.Time
+}
+
+func NewContainerChanges(clock clock.Clock) *ContainerChanges {
+	return &ContainerChanges{
+		clock:     clock,
+		changedAt: make(map[string]time.Time),
+	}
+}
+
+// Record notes that the container is changing now.
+func (c *ContainerChanges) Record(guid string) {
+	c.mu.Lock()
+	c.changedAt[guid] = c.clock.Now()
+	c.mu.Unlock()
+}
+
+// ChangedSince reports whether the container was recorded changing at or
+// after the given time.
+func (c *ContainerChanges) ChangedSince(guid string, since time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	changedAt, found := c.changedAt[guid]
+	return found && !changedAt.Before(since)
+}
+
+// Forget drops the changes recorded before the given time. No cache listed
+// before then is still answering, so they can no longer matter.
+func (c *ContainerChanges) Forget(before time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for guid, changedAt := range c.changedAt {
+		if changedAt.Before(before) {
+			delete(c.changedAt, guid)
+		}
+	}
+}
+
+// changeRecordingDelegate records every container the rep is about to change.
+type changeRecordingDelegate struct {
+	ContainerDelegate
+	changes *ContainerChanges
+}
+
+// NewChangeRecordingDelegate returns a delegate that records in changes every
+// container it runs, stops or deletes, before doing so.
+func NewChangeRecordingDelegate(delegate ContainerDelegate, changes *ContainerChanges) ContainerDelegate {
+	return &changeRecordingDelegate{
+		ContainerDelegate: delegate,
+		changes:           changes,
+	}
+}
+
+func (d *changeRecordingDelegate) RunContainer(logger lager.Logger, guid string) bool {
+	d.changes.Record(guid)
+	return d.ContainerDelegate.RunContainer(logger, guid)
+}
+
+func (d *changeRecordingDelegate) TryRunContainer(logger lager.Logger, guid string) error {
+	d.changes.Record(guid)
+	return d.ContainerDelegate.TryRunContainer(logger, guid)
+}
+
+func (d *changeRecordingDelegate) StopContainer(logger lager.Logger, guid string) bool {
+	d.changes.Record(guid)
+	return d.ContainerDelegate.StopContainer(logger, guid)
+}
+
+func (d *changeRecordingDelegate) DeleteContainer(logger lager.Logger, guid string) bool {
+	d.changes.Record(guid)
+	return d.ContainerDelegate.DeleteContainer(logger, guid)
+}
@@ -0,0 +1,83 @@
+package internal_test
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/rep/generator/internal"
+	"github.com/cloudfoundry-incubator/rep/generator/internal/fake_internal"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ContainerChanges", func() {
+	var (
+		fakeClock *fakeclock.FakeClock
+		changes   *internal.ContainerChanges
+	)
+
+	BeforeEach(func() {
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		changes = internal.NewContainerChanges(fakeClock)
+	})
+
+	It("reports containers changed at or after the given time", func() {
+		before := fakeClock.Now()
+		changes.Record("some-guid")
+
+		Ω(changes.ChangedSince("some-guid", before)).Should(BeTrue())
+		Ω(changes.ChangedSince("some-guid", before.Add(time.Second))).Should(BeFalse())
+		Ω(changes.ChangedSince("other-guid", before)).Should(BeFalse())
+	})
+
+	It("forgets changes recorded before the given time", func() {
+		before := fakeClock.Now()
+		changes.Record("some-guid")
+		fakeClock.Increment(time.Minute)
+
+		changes.Forget(fakeClock.Now())
+		Ω(changes.ChangedSince("some-guid", before)).Should(BeFalse())
+	})
+
+	Describe("the change recording delegate", func() {
+		var (
+			delegate *fake_internal.FakeContainerDelegate
+			logger   *lagertest.TestLogger
+		)
+
+		BeforeEach(func() {
+			logger = lagertest.NewTestLogger("test")
+			delegate = new(fake_internal.FakeContainerDelegate)
+			delegate.RunContainerStub = func(_ lager.Logger, guid string) bool {
+				Ω(changes.ChangedSince(guid, fakeClock.Now())).Should(BeTrue())
+				return true
+			}
+		})
+
+		It("records a container before changing it", func() {
+			recording := internal.NewChangeRecordingDelegate(delegate, changes)
+
+			Ω(recording.RunContainer(logger, "run-guid")).Should(BeTrue())
+			Ω(delegate.RunContainerCallCount()).Should(Equal(1))
+
+			recording.StopContainer(logger, "stop-guid")
+			recording.DeleteContainer(logger, "delete-guid")
+			recording.TryRunContainer(logger, "try-run-guid")
+
+			now := fakeClock.Now()
+			for _, guid := range []string{"stop-guid", "delete-guid", "try-run-guid"} {
+				Ω(changes.ChangedSince(guid, now)).Should(BeTrue())
+			}
+		})
+
+		It("does not record lookups", func() {
+			recording := internal.NewChangeRecordingDelegate(delegate, changes)
+
+			recording.GetContainer(logger, "some-guid")
+			Ω(changes.ChangedSince("some-guid", fakeClock.Now())).Should(BeFalse())
+		})
+	})
+})
@@ -3,7 +3,8 @@ package internal
 import (
 	"archive/tar"
 	"errors"
-	"fmt"
+	"io"
+	"io/ioutil"
 
 	"github.com/cloudfoundry-incubator/executor"
 	"github.com/pivotal-golang/lager"
@@ -11,18 +12,25 @@ import (
 
 const MAX_RESULT_SIZE = 1024 * 10
 
-var ErrResultFileTooLarge = errors.New(
-	fmt.Sprintf("result file is too large (over %d bytes)", MAX_RESULT_SIZE),
-)
+// MAX_TAILED_FILE_SIZE bounds how much of a file is streamed from the
+// executor only to be skipped on the way to its tail.
+const MAX_TAILED_FILE_SIZE = 1024 * 1024 * 4
+
+var ErrResultFileTooLarge = errors.New("result file is too large")
+var ErrResultFileNotFound = errors.New("result file not found")
+var ErrFileTooLargeToTail = errors.New("file is too large to tail")
 
 //go:generate counterfeiter -o fake_internal/fake_container_delegate.go container_delegate.go ContainerDelegate
 
 type ContainerDelegate interface {
 	GetContainer(logger lager.Logger, guid string) (executor.Container, bool)
+	GetContainers(logger lager.Logger, guids []string) (map[string]executor.Container, error)
 	RunContainer(logger lager.Logger, guid string) bool
+	TryRunContainer(logger lager.Logger, guid string) error
 	StopContainer(logger lager.Logger, guid string) bool
 	DeleteContainer(logger lager.Logger, guid string) bool
-	FetchContainerResultFile(logger lager.Logger, guid string, filename string) (string, error)
+	FetchContainerResultFile(logger lager.Logger, guid string, filename string, maxSize int) (string, error)
+	FetchContainerFileTail(logger lager.Logger, guid string, filename string, maxSize int) (string, error)
 }
 
 type containerDelegate struct {
@@ -46,16 +54,52 @@ func (d *containerDelegate) GetContainer(logger lager.Logger, guid string) (exec
 	return container, true
 }
 
+// GetContainers looks up the given containers in a single executor round trip.
+// Containers that do not exist are absent from the result.
+func (d *containerDelegate) GetContainers(logger lager.Logger, guids []string) (map[string]executor.Container, error) {
+	logger.Info("fetch-containers", lager.Data{"count": len(guids)})
+	allContainers, err := d.client.ListContainers(nil)
+	if err != nil {
+		logger.Error("failed-fetch-containers", err)
+		return nil, err
+	}
+
+	wanted := make(map[string]struct{}, len(guids))
+	for _, guid := range guids {
+		wanted[guid] = struct{}{}
+	}
+
+	containers := make(map[string]executor.Container, len(guids))
+	for _, container := range allContainers {
+		if _, ok := wanted[container.Guid]; ok {
+			containers[container.Guid] = container
+		}
+	}
+
+	logger.Info("succeeded-fetch-containers")
+	return containers, nil
+}
+
 func (d *containerDelegate) RunContainer(logger lager.Logger, guid string) bool {
+	err := d.TryRunContainer(logger, guid)
+	if err != nil {
+		d.DeleteContainer(logger, guid)
+		return false
+	}
+	return true
+}
+
+// TryRunContainer runs the container, leaving it in place if the executor
+// refuses so that the caller may try again.
+func (d *containerDelegate) TryRunContainer(logger lager.Logger, guid string) error {
 	logger.Info("running-container")
 	err := d.client.RunContainer(guid)
 	if err != nil {
 		logInfoOrError(logger, "failed-running-container", err)
-		d.DeleteContainer(logger, guid)
-		return false
+		return err
 	}
 	logger.Info("succeeded-running-container")
-	return true
+	return nil
 }
 
 func (d *containerDelegate) StopContainer(logger lager.Logger, guid string) bool {
@@ -80,7 +124,7 @@ func (d *containerDelegate) DeleteContainer(logger lager.Logger, guid string) bo
 	return true
 }
 
-func (d *containerDelegate) FetchContainerResultFile(logger lager.Logger, guid string, filename string) (string, error) {
+func (d *containerDelegate) FetchContainerResultFile(logger lager.Logger, guid string, filename string, maxSize int) (string, error) {
 	logger.Info("fetching-container-result")
 	stream, err := d.client.GetFiles(guid, filename)
 	if err != nil {
@@ -92,20 +136,74 @@ func (d *containerDelegate) FetchContainerResultFile(logger lager.Logger, guid s
 
 	tarReader := tar.NewReader(stream)
 
+	// the executor streams an empty archive for a file that does not exist
 	_, err = tarReader.Next()
+	if err == io.EOF {
+		logger.Info("failed-fetching-container-result-not-found")
+		return "", ErrResultFileNotFound
+	}
+	if err != nil {
+		logger.Error("failed-reading-container-result-archive", err)
+		return "", err
+	}
+
+	buf, err := ioutil.ReadAll(io.LimitReader(tarReader, int64(maxSize)+1))
 	if err != nil {
+		logger.Error("failed-reading-container-result", err)
 		return "", err
 	}
 
-	buf := make([]byte, MAX_RESULT_SIZE+1)
-	n, err := tarReader.Read(buf)
-	if n > MAX_RESULT_SIZE {
-		logInfoOrError(logger, "failed-fetching-container-result-too-large", err)
+	if len(buf) > maxSize {
+		logger.Error("failed-fetching-container-result-too-large", ErrResultFileTooLarge, lager.Data{"max-size": maxSize})
 		return "", ErrResultFileTooLarge
 	}
 
 	logger.Info("succeeded-fetching-container-result")
-	return string(buf[:n]), nil
+	return string(buf), nil
+}
+
+// FetchContainerFileTail returns at most the last maxSize bytes of the file.
+// Files larger than MAX_TAILED_FILE_SIZE are not read.
+func (d *containerDelegate) FetchContainerFileTail(logger lager.Logger, guid string, filename string, maxSize int) (string, error) {
+	logger = logger.WithData(lager.Data{"filename": filename})
+
+	logger.Info("fetching-container-file-tail")
+	stream, err := d.client.GetFiles(guid, filename)
+	if err != nil {
+		logInfoOrError(logger, "failed-fetching-container-file-stream-from-executor", err)
+		return "", err
+	}
+
+	defer stream.Close()
+
+	tarReader := tar.NewReader(stream)
+
+	header, err := tarReader.Next()
+	if err != nil {
+		return "", err
+	}
+
+	if header.Size > MAX_TAILED_FILE_SIZE {
+		logger.Error("failed-fetching-container-file-tail-too-large", ErrFileTooLargeToTail, lager.Data{"size": header.Size})
+		return "", ErrFileTooLargeToTail
+	}
+
+	if skip := header.Size - int64(maxSize); skip > 0 {
+		_, err = io.CopyN(ioutil.Discard, tarReader, skip)
+		if err != nil {
+			logger.Error("failed-skipping-container-file-head", err)
+			return "", err
+		}
+	}
+
+	buf, err := ioutil.ReadAll(io.LimitReader(tarReader, int64(maxSize)))
+	if err != nil {
+		logger.Error("failed-reading-container-file-tail", err)
+		return "", err
+	}
+
+	logger.Info("succeeded-fetching-container-file-tail", lager.Data{"size": len(buf)})
+	return string(buf), nil
 }
 
 func logInfoOrError(logger lager.Logger, msg string, err error) {
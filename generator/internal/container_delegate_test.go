@@ -1,9 +1,11 @@
 package internal_test
 
 import (
+	"archive/tar"
 	"errors"
 	"strings"
 
+	"github.com/cloudfoundry-incubator/executor"
 	"github.com/cloudfoundry-incubator/executor/fakes"
 	"github.com/cloudfoundry-incubator/rep/generator/internal"
 	"github.com/pivotal-golang/archiver/extractor/test_helper"
@@ -27,6 +29,54 @@ var _ = Describe("ContainerDelegate", func() {
 		logger = lagertest.NewTestLogger(sessionPrefix)
 	})
 
+	Describe("GetContainers", func() {
+		var (
+			containers map[string]executor.Container
+			getErr     error
+		)
+
+		JustBeforeEach(func() {
+			containers, getErr = containerDelegate.GetContainers(logger, []string{"guid-a", "guid-b"})
+		})
+
+		Context("when listing containers succeeds", func() {
+			BeforeEach(func() {
+				executorClient.ListContainersReturns([]executor.Container{
+					{Guid: "guid-a"},
+					{Guid: "guid-c"},
+				}, nil)
+			})
+
+			It("lists the containers once", func() {
+				Ω(executorClient.ListContainersCallCount()).Should(Equal(1))
+				Ω(executorClient.GetContainerCallCount()).Should(Equal(0))
+			})
+
+			It("returns the requested containers that exist", func() {
+				Ω(getErr).ShouldNot(HaveOccurred())
+				Ω(containers).Should(Equal(map[string]executor.Container{
+					"guid-a": {Guid: "guid-a"},
+				}))
+			})
+		})
+
+		Context("when listing containers fails", func() {
+			disaster := errors.New("ka-boom")
+
+			BeforeEach(func() {
+				executorClient.ListContainersReturns(nil, disaster)
+			})
+
+			It("returns the error", func() {
+				Ω(getErr).Should(Equal(disaster))
+			})
+
+			It("logs the failure", func() {
+				Ω(logger).Should(gbytes.Say(sessionPrefix + ".failed-fetch-containers"))
+			})
+		})
+	})
+
 	Describe("RunContainer", func() {
 		var result bool
 
@@ -87,6 +137,41 @@ var _ = Describe("ContainerDelegate", func() {
 		})
 	})
 
+	Describe("TryRunContainer", func() {
+		var runErr error
+
+		JustBeforeEach(func() {
+			runErr = containerDelegate.TryRunContainer(logger, expectedGuid)
+		})
+
+		It("runs the container", func() {
+			Ω(executorClient.RunContainerCallCount()).Should(Equal(1))
+			Ω(executorClient.RunContainerArgsForCall(0)).Should(Equal(expectedGuid))
+		})
+
+		Context("when running succeeds", func() {
+			It("does not return an error", func() {
+				Ω(runErr).ShouldNot(HaveOccurred())
+			})
+		})
+
+		Context("when running fails", func() {
+			disaster := errors.New("ka-boom")
+
+			BeforeEach(func() {
+				executorClient.RunContainerReturns(disaster)
+			})
+
+			It("returns the error", func() {
+				Ω(runErr).Should(Equal(disaster))
+			})
+
+			It("does not delete the container", func() {
+				Ω(executorClient.DeleteContainerCallCount()).Should(BeZero())
+			})
+		})
+	})
+
 	Describe("StopContainer", func() {
 		var result bool
 
@@ -178,7 +263,7 @@ var _ = Describe("ContainerDelegate", func() {
 		})
 
 		JustBeforeEach(func() {
-			result, fetchErr = containerDelegate.FetchContainerResultFile(logger, expectedGuid, filename)
+			result, fetchErr = containerDelegate.FetchContainerResultFile(logger, expectedGuid, filename, internal.MAX_RESULT_SIZE)
 		})
 
 		Context("when fetching the file stream from the container succeeds", func() {
@@ -219,6 +304,30 @@ var _ = Describe("ContainerDelegate", func() {
 				})
 			})
 
+			Context("and the payload is exactly the maximum size", func() {
+				var body string
+
+				BeforeEach(func() {
+					body = strings.Repeat("x", internal.MAX_RESULT_SIZE)
+					test_helper.WriteTar(
+						fileStream,
+						[]test_helper.ArchiveFile{{
+							Name: "some-file",
+							Body: body,
+							Mode: 0600,
+						}},
+					)
+				})
+
+				It("succeeds", func() {
+					Ω(fetchErr).ShouldNot(HaveOccurred())
+				})
+
+				It("returns the whole file", func() {
+					Ω(result).Should(Equal(body))
+				})
+			})
+
 			Context("but the payload is too large", func() {
 				BeforeEach(func() {
 					test_helper.WriteTar(
@@ -232,7 +341,7 @@ var _ = Describe("ContainerDelegate", func() {
 				})
 
 				It("returns an error", func() {
-					Ω(fetchErr).Should(HaveOccurred())
+					Ω(fetchErr).Should(Equal(internal.ErrResultFileTooLarge))
 				})
 
 				It("closes the result stream", func() {
@@ -244,9 +353,9 @@ var _ = Describe("ContainerDelegate", func() {
 				})
 			})
 
-			Context("when the stream is empty for whatever reason", func() {
-				It("returns an error", func() {
-					Ω(fetchErr).Should(HaveOccurred())
+			Context("when the stream is empty because the file does not exist", func() {
+				It("returns an error saying it was not found", func() {
+					Ω(fetchErr).Should(Equal(internal.ErrResultFileNotFound))
 				})
 
 				It("closes the result stream", func() {
@@ -271,4 +380,87 @@ var _ = Describe("ContainerDelegate", func() {
 			})
 		})
 	})
+
+	Describe("FetchContainerFileTail", func() {
+		var (
+			fileStream *gbytes.Buffer
+			tail       string
+			fetchErr   error
+		)
+
+		BeforeEach(func() {
+			fileStream = gbytes.NewBuffer()
+			executorClient.GetFilesReturns(fileStream, nil)
+		})
+
+		JustBeforeEach(func() {
+			tail, fetchErr = containerDelegate.FetchContainerFileTail(logger, expectedGuid, "/tmp/crash.log", 5)
+		})
+
+		Context("when the file is larger than the maximum size", func() {
+			BeforeEach(func() {
+				test_helper.WriteTar(fileStream, []test_helper.ArchiveFile{{
+					Name: "crash.log",
+					Body: "0123456789",
+					Mode: 0600,
+				}})
+			})
+
+			It("returns the end of the file", func() {
+				Ω(fetchErr).ShouldNot(HaveOccurred())
+				Ω(tail).Should(Equal("56789"))
+			})
+
+			It("closes the stream", func() {
+				Ω(fileStream.Closed()).Should(BeTrue())
+			})
+		})
+
+		Context("when the file is too large to skip to its tail", func() {
+			BeforeEach(func() {
+				tarWriter := tar.NewWriter(fileStream)
+				err := tarWriter.WriteHeader(&tar.Header{
+					Name: "crash.log",
+					Size: internal.MAX_TAILED_FILE_SIZE + 1,
+					Mode: 0600,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+
+			It("does not read it", func() {
+				Ω(fetchErr).Should(Equal(internal.ErrFileTooLargeToTail))
+			})
+
+			It("closes the stream", func() {
+				Ω(fileStream.Closed()).Should(BeTrue())
+			})
+		})
+
+		Context("when the file is smaller than the maximum size", func() {
+			BeforeEach(func() {
+				test_helper.WriteTar(fileStream, []test_helper.ArchiveFile{{
+					Name: "crash.log",
+					Body: "0123",
+					Mode: 0600,
+				}})
+			})
+
+			It("returns the whole file", func() {
+				Ω(fetchErr).ShouldNot(HaveOccurred())
+				Ω(tail).Should(Equal("0123"))
+			})
+		})
+
+		Context("when fetching the file stream fails", func() {
+			disaster := errors.New("nope")
+
+			BeforeEach(func() {
+				executorClient.GetFilesReturns(nil, disaster)
+			})
+
+			It("returns the error", func() {
+				Ω(fetchErr).Should(Equal(disaster))
+			})
+		})
+	})
 })
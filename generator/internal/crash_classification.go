@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/runtime-schema/metric"
+	"github.com/pivotal-golang/lager"
+)
+
+const (
+	CrashCategoryOOM                = "oom"
+	CrashCategoryHealthCheckFailure = "health-check-failure"
+	CrashCategoryStartTimeout       = "start-timeout"
+	CrashCategoryExitCode           = "exit-code"
+	CrashCategoryUnknown            = "unknown"
+)
+
+// executorOOMMarker is what the executor's run step appends to the exit
+// status when the container reported an out of memory event.
+const executorOOMMarker = "(out of memory)"
+
+var exitStatusRegexp = regexp.MustCompile(`(?i)exit(?:ed)?(?: with)? status (-?\d+)`)
+
+// The failure reasons the executor's health check steps report when an
+// instance never became healthy within its start timeout, and when a healthy
+// instance's health check failed.
+var (
+	startTimeoutReasonRegexp = regexp.MustCompile(`^(?:Timed out after \S+: health check never passed\.|Instance never healthy after )`)
+	healthCheckReasonRegexp  = regexp.MustCompile(`^Instance became unhealthy: `)
+)
+
+// CrashClassification describes why an LRP container completed without being
+// asked to stop.
+type CrashClassification struct {
+	Category   string
+	ExitStatus int
+}
+
+// ClassifyCrash derives the crash category from the container's configuration
+// and the failure reason reported in its run result. Only the reasons the
+// executor itself reports are recognized; a process's own output never is.
+func ClassifyCrash(container executor.Container) CrashClassification {
+	reason := container.RunResult.FailureReason
+
+	classification := CrashClassification{Category: CrashCategoryUnknown}
+
+	match := exitStatusRegexp.FindStringSubmatch(reason)
+	if match != nil {
+		classification.ExitStatus, _ = strconv.Atoi(match[1])
+	}
+
+	switch {
+	case strings.Contains(reason, executorOOMMarker):
+		classification.Category = CrashCategoryOOM
+	case container.StartTimeout > 0 && startTimeoutReasonRegexp.MatchString(reason):
+		classification.Category = CrashCategoryStartTimeout
+	case container.Monitor != nil && healthCheckReasonRegexp.MatchString(reason):
+		classification.Category = CrashCategoryHealthCheckFailure
+	case match != nil:
+		classification.Category = CrashCategoryExitCode
+	}
+
+	return classification
+}
+
+// Reason prefixes the failure reason with the crash category, leaving
+// unclassified reasons untouched.
+func (c CrashClassification) Reason(failureReason string) string {
+	switch c.Category {
+	case CrashCategoryUnknown:
+		return failureReason
+	case CrashCategoryExitCode:
+		return fmt.Sprintf("%s %d: %s", c.Category, c.ExitStatus, failureReason)
+	default:
+		return fmt.Sprintf("%s: %s", c.Category, failureReason)
+	}
+}
+
+func crashCounter(category string) metric.Counter {
+	return metric.Counter(fmt.Sprintf("RepLRPCrashes.%s", category))
+}
+
+func domainCrashCounter(category, domain string) metric.Counter {
+	return metric.Counter(fmt.Sprintf("RepLRPCrashes.%s.%s", category, domain))
+}
+
+func classifyCrash(logger lager.Logger, lrpContainer *lrpContainer) string {
+	classification := ClassifyCrash(lrpContainer.Container)
+	logger.Info("classified-crash", lager.Data{
+		"category":    classification.Category,
+		"exit-status": classification.ExitStatus,
+		"domain":      lrpContainer.ActualLRPKey.Domain,
+	})
+
+	crashCounter(classification.Category).Increment()
+	domainCrashCounter(classification.Category, lrpContainer.ActualLRPKey.Domain).Increment()
+
+	return classification.Reason(lrpContainer.RunResult.FailureReason)
+}
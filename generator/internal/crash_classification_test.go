@@ -0,0 +1,139 @@
+package internal_test
+
+import (
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/rep/generator/internal"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ClassifyCrash", func() {
+	var container executor.Container
+
+	BeforeEach(func() {
+		container = executor.Container{
+			Guid:  "some-guid",
+			State: executor.StateCompleted,
+		}
+	})
+
+	Context("when the container ran out of memory", func() {
+		BeforeEach(func() {
+			container.RunResult.FailureReason = "Exited with status 137 (out of memory)"
+		})
+
+		It("classifies the crash as an OOM", func() {
+			classification := internal.ClassifyCrash(container)
+			Ω(classification.Category).Should(Equal(internal.CrashCategoryOOM))
+			Ω(classification.ExitStatus).Should(Equal(137))
+		})
+
+		It("records the category in the reason", func() {
+			classification := internal.ClassifyCrash(container)
+			Ω(classification.Reason(container.RunResult.FailureReason)).Should(Equal("oom: Exited with status 137 (out of memory)"))
+		})
+	})
+
+	Context("when the process only mentions running out of memory", func() {
+		BeforeEach(func() {
+			container.RunResult.FailureReason = "Exited with status 1: app said it was out of memory"
+		})
+
+		It("classifies the crash by exit code", func() {
+			Ω(internal.ClassifyCrash(container).Category).Should(Equal(internal.CrashCategoryExitCode))
+		})
+	})
+
+	Context("when the container timed out before becoming healthy", func() {
+		BeforeEach(func() {
+			container.StartTimeout = 60
+			container.Monitor = &models.RunAction{Path: "healthcheck"}
+			container.RunResult.FailureReason = "Timed out after 1m0s: health check never passed."
+		})
+
+		It("classifies the crash as a start timeout", func() {
+			Ω(internal.ClassifyCrash(container).Category).Should(Equal(internal.CrashCategoryStartTimeout))
+		})
+
+		Context("when the executor reports it as never healthy", func() {
+			BeforeEach(func() {
+				container.RunResult.FailureReason = "Instance never healthy after 1m0s: exit status 1"
+			})
+
+			It("classifies the crash as a start timeout", func() {
+				Ω(internal.ClassifyCrash(container).Category).Should(Equal(internal.CrashCategoryStartTimeout))
+			})
+		})
+	})
+
+	Context("when the process itself reports a timeout", func() {
+		BeforeEach(func() {
+			container.StartTimeout = 60
+			container.Monitor = &models.RunAction{Path: "healthcheck"}
+			container.RunResult.FailureReason = "Exited with status 1: request timed out"
+		})
+
+		It("classifies the crash by exit code", func() {
+			Ω(internal.ClassifyCrash(container).Category).Should(Equal(internal.CrashCategoryExitCode))
+		})
+	})
+
+	Context("when the process itself mentions its health", func() {
+		BeforeEach(func() {
+			container.Monitor = &models.RunAction{Path: "healthcheck"}
+			container.RunResult.FailureReason = "Exited with status 3: health endpoint misconfigured"
+		})
+
+		It("classifies the crash by exit code", func() {
+			Ω(internal.ClassifyCrash(container).Category).Should(Equal(internal.CrashCategoryExitCode))
+		})
+	})
+
+	Context("when the container's health monitor failed", func() {
+		BeforeEach(func() {
+			container.Monitor = &models.RunAction{Path: "healthcheck"}
+			container.RunResult.FailureReason = "Instance became unhealthy: health check failed"
+		})
+
+		It("classifies the crash as a health check failure", func() {
+			Ω(internal.ClassifyCrash(container).Category).Should(Equal(internal.CrashCategoryHealthCheckFailure))
+		})
+
+		Context("when the container has no monitor", func() {
+			BeforeEach(func() {
+				container.Monitor = nil
+			})
+
+			It("does not blame the health check", func() {
+				Ω(internal.ClassifyCrash(container).Category).Should(Equal(internal.CrashCategoryUnknown))
+			})
+		})
+	})
+
+	Context("when the process exited with a non-zero status", func() {
+		BeforeEach(func() {
+			container.RunResult.FailureReason = "Exited with status 2"
+		})
+
+		It("classifies the crash by exit code", func() {
+			classification := internal.ClassifyCrash(container)
+			Ω(classification.Category).Should(Equal(internal.CrashCategoryExitCode))
+			Ω(classification.ExitStatus).Should(Equal(2))
+			Ω(classification.Reason(container.RunResult.FailureReason)).Should(Equal("exit-code 2: Exited with status 2"))
+		})
+	})
+
+	Context("when the failure reason is not recognized", func() {
+		BeforeEach(func() {
+			container.RunResult.FailureReason = "crashed"
+		})
+
+		It("leaves the reason untouched", func() {
+			classification := internal.ClassifyCrash(container)
+			Ω(classification.Category).Should(Equal(internal.CrashCategoryUnknown))
+			Ω(classification.Reason(container.RunResult.FailureReason)).Should(Equal("crashed"))
+		})
+	})
+})
@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/rep"
+	"github.com/cloudfoundry-incubator/rep/diagnostics"
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+//go:generate counterfeiter -o fake_internal/fake_diagnostics_collector.go diagnostics_collector.go DiagnosticsCollector
+
+// DiagnosticsCollector captures the diagnostic files a failed task asked for
+// before its container is deleted.
+type DiagnosticsCollector interface {
+	Collect(logger lager.Logger, container executor.Container)
+}
+
+type diagnosticsCollector struct {
+	containerDelegate ContainerDelegate
+	store             diagnostics.Store
+	maxFileSize       int
+	clock             clock.Clock
+}
+
+func NewDiagnosticsCollector(containerDelegate ContainerDelegate, store diagnostics.Store, maxFileSize int, clock clock.Clock) DiagnosticsCollector {
+	return &diagnosticsCollector{
+		containerDelegate: containerDelegate,
+		store:             store,
+		maxFileSize:       maxFileSize,
+		clock:             clock,
+	}
+}
+
+func (c *diagnosticsCollector) Collect(logger lager.Logger, container executor.Container) {
+	paths := rep.ParseDiagnosticFiles(container.Tags[rep.DiagnosticFilesTag])
+	if !container.RunResult.Failed || len(paths) == 0 {
+		return
+	}
+
+	logger = logger.Session("collect-diagnostics")
+	logger.Info("starting", lager.Data{"files": paths})
+	defer logger.Info("finished")
+
+	captured := diagnostics.Diagnostics{
+		TaskGuid:      container.Guid,
+		Domain:        container.Tags[rep.DomainTag],
+		FailureReason: container.RunResult.FailureReason,
+		CapturedAt:    c.clock.Now(),
+		Files:         map[string]string{},
+	}
+
+	for _, path := range paths {
+		tail, err := c.containerDelegate.FetchContainerFileTail(logger, container.Guid, path, c.maxFileSize)
+		if err != nil {
+			if captured.Errors == nil {
+				captured.Errors = map[string]string{}
+			}
+			captured.Errors[path] = err.Error()
+			continue
+		}
+
+		captured.Files[path] = tail
+	}
+
+	c.store.Put(captured)
+}
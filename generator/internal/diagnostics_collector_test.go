@@ -0,0 +1,100 @@
+package internal_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/rep"
+	"github.com/cloudfoundry-incubator/rep/diagnostics"
+	"github.com/cloudfoundry-incubator/rep/generator/internal"
+	"github.com/cloudfoundry-incubator/rep/generator/internal/fake_internal"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DiagnosticsCollector", func() {
+	var (
+		containerDelegate *fake_internal.FakeContainerDelegate
+		store             diagnostics.Store
+		fakeClock         *fakeclock.FakeClock
+		logger            *lagertest.TestLogger
+		container         executor.Container
+	)
+
+	BeforeEach(func() {
+		containerDelegate = new(fake_internal.FakeContainerDelegate)
+		store = diagnostics.NewStore(10)
+		fakeClock = fakeclock.NewFakeClock(time.Unix(1000, 0))
+		logger = lagertest.NewTestLogger("test")
+
+		container = executor.Container{
+			Guid:  "task-guid",
+			State: executor.StateCompleted,
+			Tags: executor.Tags{
+				rep.LifecycleTag:       rep.TaskLifecycle,
+				rep.DomainTag:          "domain",
+				rep.DiagnosticFilesTag: "/tmp/crash.log,/tmp/missing",
+			},
+			RunResult: executor.ContainerRunResult{Failed: true, FailureReason: "boom"},
+		}
+
+		containerDelegate.FetchContainerFileTailStub = func(_ lager.Logger, _ string, filename string, _ int) (string, error) {
+			if filename == "/tmp/missing" {
+				return "", errors.New("no such file")
+			}
+			return "last words", nil
+		}
+	})
+
+	JustBeforeEach(func() {
+		internal.NewDiagnosticsCollector(containerDelegate, store, 42, fakeClock).Collect(logger, container)
+	})
+
+	It("fetches the tail of every diagnostic file", func() {
+		Ω(containerDelegate.FetchContainerFileTailCallCount()).Should(Equal(2))
+		_, guid, filename, maxSize := containerDelegate.FetchContainerFileTailArgsForCall(0)
+		Ω(guid).Should(Equal("task-guid"))
+		Ω(filename).Should(Equal("/tmp/crash.log"))
+		Ω(maxSize).Should(Equal(42))
+	})
+
+	It("stores what it captured", func() {
+		captured, found := store.Get("task-guid")
+		Ω(found).Should(BeTrue())
+		Ω(captured).Should(Equal(diagnostics.Diagnostics{
+			TaskGuid:      "task-guid",
+			Domain:        "domain",
+			FailureReason: "boom",
+			CapturedAt:    time.Unix(1000, 0),
+			Files:         map[string]string{"/tmp/crash.log": "last words"},
+			Errors:        map[string]string{"/tmp/missing": "no such file"},
+		}))
+	})
+
+	Context("when the task succeeded", func() {
+		BeforeEach(func() {
+			container.RunResult = executor.ContainerRunResult{}
+		})
+
+		It("captures nothing", func() {
+			Ω(containerDelegate.FetchContainerFileTailCallCount()).Should(Equal(0))
+			_, found := store.Get("task-guid")
+			Ω(found).Should(BeFalse())
+		})
+	})
+
+	Context("when the task did not ask for diagnostics", func() {
+		BeforeEach(func() {
+			delete(container.Tags, rep.DiagnosticFilesTag)
+		})
+
+		It("captures nothing", func() {
+			Ω(containerDelegate.FetchContainerFileTailCallCount()).Should(Equal(0))
+		})
+	})
+})
@@ -0,0 +1,34 @@
+package internal
+
+import "sync"
+
+// evacuatedContainers remembers the running LRP containers this rep has
+// evacuated, so that their evacuating ActualLRPs can be cleaned up should
+// evacuation be canceled while the containers are still around.
+type evacuatedContainers struct {
+	guids map[string]struct{}
+	lock  sync.Mutex
+}
+
+func newEvacuatedContainers() *evacuatedContainers {
+	return &evacuatedContainers{
+		guids: make(map[string]struct{}),
+	}
+}
+
+func (e *evacuatedContainers) Add(guid string) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.guids[guid] = struct{}{}
+}
+
+// Remove forgets the container and reports whether it had been evacuated.
+func (e *evacuatedContainers) Remove(guid string) bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	_, found := e.guids[guid]
+	delete(e.guids, guid)
+	return found
+}
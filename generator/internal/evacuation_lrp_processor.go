@@ -3,24 +3,34 @@ package internal
 import (
 	"github.com/cloudfoundry-incubator/executor"
 	"github.com/cloudfoundry-incubator/rep"
+	"github.com/cloudfoundry-incubator/rep/lrp_stopper"
+	"github.com/cloudfoundry-incubator/rep/quarantine"
 	"github.com/cloudfoundry-incubator/runtime-schema/bbs"
 	"github.com/cloudfoundry-incubator/runtime-schema/bbs/shared"
 	"github.com/pivotal-golang/lager"
 )
 
 type evacuationLRPProcessor struct {
-	bbs                    bbs.RepBBS
-	containerDelegate      ContainerDelegate
-	cellID                 string
-	evacuationTTLInSeconds uint64
+	bbs                 bbs.RepBBS
+	containerDelegate   ContainerDelegate
+	cellID              string
+	evacuationTTLConfig EvacuationTTLConfig
+	drainer             lrp_stopper.Drainer
+	quarantine          quarantine.Quarantine
+	evacuated           *evacuatedContainers
+	waves               *evacuationWaves
 }
 
-func newEvacuationLRPProcessor(bbs bbs.RepBBS, containerDelegate ContainerDelegate, cellID string, evacuationTTLInSeconds uint64) LRPProcessor {
+func newEvacuationLRPProcessor(bbs bbs.RepBBS, containerDelegate ContainerDelegate, cellID string, evacuationTTLConfig EvacuationTTLConfig, drainer lrp_stopper.Drainer, quarantine quarantine.Quarantine, evacuated *evacuatedContainers, waves *evacuationWaves) LRPProcessor {
 	return &evacuationLRPProcessor{
-		bbs:                    bbs,
-		containerDelegate:      containerDelegate,
-		cellID:                 cellID,
-		evacuationTTLInSeconds: evacuationTTLInSeconds,
+		bbs:                 bbs,
+		containerDelegate:   containerDelegate,
+		cellID:              cellID,
+		evacuationTTLConfig: evacuationTTLConfig,
+		drainer:             drainer,
+		quarantine:          quarantine,
+		evacuated:           evacuated,
+		waves:               waves,
 	}
 }
 
@@ -58,7 +68,10 @@ func (p *evacuationLRPProcessor) Process(logger lager.Logger, container executor
 		p.processCompletedContainer(logger, lrpContainer)
 	default:
 		p.processInvalidContainer(logger, lrpContainer)
+		return
 	}
+
+	p.quarantine.Release(logger, container.Guid)
 }
 
 func (p *evacuationLRPProcessor) processReservedContainer(logger lager.Logger, lrpContainer *lrpContainer) {
@@ -79,6 +92,11 @@ func (p *evacuationLRPProcessor) processCreatedContainer(logger lager.Logger, lr
 func (p *evacuationLRPProcessor) processRunningContainer(logger lager.Logger, lrpContainer *lrpContainer) {
 	logger = logger.Session("process-running-container")
 
+	if p.drainer.Draining(lrpContainer.Guid) {
+		logger.Info("skipped-draining-container")
+		return
+	}
+
 	logger.Debug("extracting-net-info-from-container")
 	netInfo, err := rep.ActualLRPNetInfoFromContainer(lrpContainer.Container)
 	if err != nil {
@@ -87,11 +105,21 @@ func (p *evacuationLRPProcessor) processRunningContainer(logger lager.Logger, lr
 	}
 	logger.Debug("succeeded-extracting-net-info-from-container")
 
-	retainment, err := p.bbs.EvacuateRunningActualLRP(logger, lrpContainer.ActualLRPKey, lrpContainer.ActualLRPInstanceKey, netInfo, p.evacuationTTLInSeconds)
+	if !p.waves.Admit(lrpContainer.Guid, lrpContainer.ProcessGuid) {
+		logger.Info("deferred-evacuation-to-a-later-wave")
+		return
+	}
+
+	retainment, err := p.bbs.EvacuateRunningActualLRP(logger, lrpContainer.ActualLRPKey, lrpContainer.ActualLRPInstanceKey, netInfo, p.evacuationTTLConfig.TTLInSecondsFor(lrpContainer.Container))
 	if retainment == shared.DeleteContainer {
 		p.containerDelegate.DeleteContainer(logger, lrpContainer.Container.Guid)
+		p.waves.Forget(lrpContainer.Guid)
 	} else if err != nil {
 		logger.Error("failed-to-evacuate-running-actual-lrp", err, lager.Data{"lrp-key": lrpContainer.ActualLRPKey})
+		p.waves.Forget(lrpContainer.Guid)
+	} else {
+		p.evacuated.Add(lrpContainer.Guid)
+		p.waves.Evacuated(lrpContainer.Guid)
 	}
 }
 
@@ -104,18 +132,20 @@ func (p *evacuationLRPProcessor) processCompletedContainer(logger lager.Logger,
 			logger.Error("failed-to-evacuate-stopped-actual-lrp", err, lager.Data{"lrp-key": lrpContainer.ActualLRPKey})
 		}
 	} else {
-		_, err := p.bbs.EvacuateCrashedActualLRP(logger, lrpContainer.ActualLRPKey, lrpContainer.ActualLRPInstanceKey, lrpContainer.RunResult.FailureReason)
+		_, err := p.bbs.EvacuateCrashedActualLRP(logger, lrpContainer.ActualLRPKey, lrpContainer.ActualLRPInstanceKey, classifyCrash(logger, lrpContainer))
 		if err != nil {
 			logger.Error("failed-to-evacuate-crashed-actual-lrp", err, lager.Data{"lrp-key": lrpContainer.ActualLRPKey})
 		}
 	}
 
 	p.containerDelegate.DeleteContainer(logger, lrpContainer.Guid)
+	p.waves.Forget(lrpContainer.Guid)
 }
 
 func (p *evacuationLRPProcessor) processInvalidContainer(logger lager.Logger, lrpContainer *lrpContainer) {
 	logger = logger.Session("process-invalid-container")
 	logger.Error("not-processing-container-in-invalid-state", nil)
+	p.quarantine.Observe(logger, lrpContainer.Container)
 }
 
 func (p *evacuationLRPProcessor) evacuateClaimedLRPContainer(logger lager.Logger, lrpContainer *lrpContainer) {
@@ -3,16 +3,21 @@ package internal_test
 import (
 	"errors"
 	"strconv"
+	"time"
 
 	"github.com/cloudfoundry-incubator/executor"
 	"github.com/cloudfoundry-incubator/rep"
+	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context"
 	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context/fake_evacuation_context"
 	"github.com/cloudfoundry-incubator/rep/generator/internal"
 	"github.com/cloudfoundry-incubator/rep/generator/internal/fake_internal"
+	"github.com/cloudfoundry-incubator/rep/lrp_stopper/fake_lrp_stopper"
+	"github.com/cloudfoundry-incubator/rep/quarantine/fake_quarantine"
 	"github.com/cloudfoundry-incubator/runtime-schema/bbs/bbserrors"
 	"github.com/cloudfoundry-incubator/runtime-schema/bbs/fake_bbs"
 	"github.com/cloudfoundry-incubator/runtime-schema/bbs/shared"
 	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/pivotal-golang/clock/fakeclock"
 	"github.com/pivotal-golang/lager/lagertest"
 
 	. "github.com/onsi/ginkgo"
@@ -31,8 +36,12 @@ var _ = Describe("EvacuationLrpProcessor", func() {
 			fakeRepBBS             *fake_bbs.FakeRepBBS
 			fakeContainerDelegate  *fake_internal.FakeContainerDelegate
 			fakeEvacuationReporter *fake_evacuation_context.FakeEvacuationReporter
+			fakeDrainer            *fake_lrp_stopper.FakeDrainer
+			fakeQuarantine         *fake_quarantine.FakeQuarantine
+			fakeClock              *fakeclock.FakeClock
 
 			lrpProcessor internal.LRPProcessor
+			waveConfig   internal.EvacuationWaveConfig
 
 			processGuid  string
 			desiredLRP   models.DesiredLRP
@@ -53,7 +62,11 @@ var _ = Describe("EvacuationLrpProcessor", func() {
 			fakeEvacuationReporter = &fake_evacuation_context.FakeEvacuationReporter{}
 			fakeEvacuationReporter.EvacuatingReturns(true)
 
-			lrpProcessor = internal.NewLRPProcessor(fakeRepBBS, fakeContainerDelegate, localCellID, fakeEvacuationReporter, evacuationTTL)
+			fakeDrainer = new(fake_lrp_stopper.FakeDrainer)
+			fakeQuarantine = new(fake_quarantine.FakeQuarantine)
+			fakeClock = fakeclock.NewFakeClock(time.Now())
+
+			waveConfig = internal.EvacuationWaveConfig{}
 
 			processGuid = "process-guid"
 			desiredLRP = models.DesiredLRP{
@@ -85,9 +98,48 @@ var _ = Describe("EvacuationLrpProcessor", func() {
 		})
 
 		JustBeforeEach(func() {
+			lrpProcessor = internal.NewLRPProcessor(fakeRepBBS, fakeContainerDelegate, localCellID, fakeEvacuationReporter, internal.EvacuationTTLConfig{Default: evacuationTTL * time.Second}, waveConfig, fakeDrainer, fakeQuarantine, fakeClock)
 			lrpProcessor.Process(logger, container)
 		})
 
+		Context("when the container is Invalid", func() {
+			BeforeEach(func() {
+				container.State = executor.StateInvalid
+			})
+
+			It("quarantines the container", func() {
+				Ω(fakeQuarantine.ObserveCallCount()).Should(Equal(1))
+				_, quarantined := fakeQuarantine.ObserveArgsForCall(0)
+				Ω(quarantined.Guid).Should(Equal(container.Guid))
+			})
+
+			It("does not release the container", func() {
+				Ω(fakeQuarantine.ReleaseCallCount()).Should(BeZero())
+			})
+		})
+
+		for _, state := range []executor.State{
+			executor.StateReserved,
+			executor.StateInitializing,
+			executor.StateCreated,
+			executor.StateRunning,
+			executor.StateCompleted,
+		} {
+			state := state
+
+			Context("when the container is "+string(state), func() {
+				BeforeEach(func() {
+					container.State = state
+				})
+
+				It("releases the container from quarantine", func() {
+					Ω(fakeQuarantine.ReleaseCallCount()).Should(Equal(1))
+					_, guid := fakeQuarantine.ReleaseArgsForCall(0)
+					Ω(guid).Should(Equal(container.Guid))
+				})
+			})
+		}
+
 		Context("when the container is Reserved", func() {
 			BeforeEach(func() {
 				container.State = executor.StateReserved
@@ -255,6 +307,38 @@ var _ = Describe("EvacuationLrpProcessor", func() {
 				Ω(actualTTL).Should(Equal(uint64(evacuationTTL)))
 			})
 
+			Context("when the instance is being drained", func() {
+				BeforeEach(func() {
+					fakeDrainer.DrainingReturns(true)
+				})
+
+				It("does not evacuate the lrp", func() {
+					Ω(fakeRepBBS.EvacuateRunningActualLRPCallCount()).Should(Equal(0))
+				})
+
+				It("does not delete the container", func() {
+					Ω(fakeContainerDelegate.DeleteContainerCallCount()).Should(Equal(0))
+				})
+			})
+
+			Context("when the container is outside the evacuation scope", func() {
+				BeforeEach(func() {
+					fakeEvacuationReporter.EvacuationScopeReturns(evacuation_context.Scope{Domains: []string{"other-domain"}})
+				})
+
+				It("does not evacuate the lrp", func() {
+					Ω(fakeRepBBS.EvacuateRunningActualLRPCallCount()).Should(Equal(0))
+				})
+
+				It("processes the container as usual", func() {
+					Ω(fakeRepBBS.StartActualLRPCallCount()).Should(Equal(1))
+					_, actualLRPKey, actualLRPInstanceKey, actualLRPNetInfo := fakeRepBBS.StartActualLRPArgsForCall(0)
+					Ω(actualLRPKey).Should(Equal(lrpKey))
+					Ω(actualLRPInstanceKey).Should(Equal(lrpInstanceKey))
+					Ω(actualLRPNetInfo).Should(Equal(lrpNetInfo))
+				})
+			})
+
 			Context("when the evacuation returns successfully", func() {
 				BeforeEach(func() {
 					fakeRepBBS.EvacuateRunningActualLRPReturns(shared.KeepContainer, nil)
@@ -286,6 +370,111 @@ var _ = Describe("EvacuationLrpProcessor", func() {
 					Ω(fakeContainerDelegate.DeleteContainerCallCount()).Should(Equal(0))
 				})
 			})
+
+			Context("when evacuating in waves", func() {
+				var otherContainer executor.Container
+
+				newRunningContainer := func(processGuid, instanceGuid string) executor.Container {
+					other := container
+					other.Guid = rep.LRPContainerGuid(processGuid, instanceGuid)
+					other.Tags = executor.Tags{}
+					for k, v := range container.Tags {
+						other.Tags[k] = v
+					}
+					other.Tags[rep.ProcessGuidTag] = processGuid
+					other.Tags[rep.InstanceGuidTag] = instanceGuid
+					return other
+				}
+
+				BeforeEach(func() {
+					waveConfig = internal.EvacuationWaveConfig{MaxInFlight: 1, WaveInterval: time.Minute}
+					fakeRepBBS.EvacuateRunningActualLRPReturns(shared.KeepContainer, nil)
+					otherContainer = newRunningContainer(processGuid, "other-instance-guid")
+				})
+
+				It("defers evacuating more instances than are allowed in flight", func() {
+					lrpProcessor.Process(logger, otherContainer)
+					Ω(fakeRepBBS.EvacuateRunningActualLRPCallCount()).Should(Equal(1))
+				})
+
+				It("keeps evacuating the instances already in flight", func() {
+					lrpProcessor.Process(logger, container)
+					Ω(fakeRepBBS.EvacuateRunningActualLRPCallCount()).Should(Equal(2))
+				})
+
+				It("evacuates the next instance once the replacement is running", func() {
+					fakeRepBBS.EvacuateRunningActualLRPReturns(shared.DeleteContainer, nil)
+					lrpProcessor.Process(logger, container)
+
+					lrpProcessor.Process(logger, otherContainer)
+					Ω(fakeRepBBS.EvacuateRunningActualLRPCallCount()).Should(Equal(3))
+					_, actualLRPKey, _, _, _ := fakeRepBBS.EvacuateRunningActualLRPArgsForCall(2)
+					Ω(actualLRPKey.ProcessGuid).Should(Equal(processGuid))
+				})
+
+				It("keeps the instance in flight for a wave interval after its evacuation has been requested", func() {
+					fakeClock.Increment(time.Minute - time.Second)
+					lrpProcessor.Process(logger, otherContainer)
+					Ω(fakeRepBBS.EvacuateRunningActualLRPCallCount()).Should(Equal(1))
+
+					fakeClock.Increment(time.Second)
+					lrpProcessor.Process(logger, otherContainer)
+					Ω(fakeRepBBS.EvacuateRunningActualLRPCallCount()).Should(Equal(2))
+				})
+
+				It("does not count an instance that has left flight against the limits again", func() {
+					fakeClock.Increment(time.Minute)
+					lrpProcessor.Process(logger, container)
+					lrpProcessor.Process(logger, otherContainer)
+					Ω(fakeRepBBS.EvacuateRunningActualLRPCallCount()).Should(Equal(3))
+				})
+
+				Context("when waiting for replacements", func() {
+					BeforeEach(func() {
+						waveConfig.WaitForReplacement = true
+					})
+
+					It("keeps the instance in flight after its evacuation has been requested", func() {
+						fakeClock.Increment(time.Hour)
+						lrpProcessor.Process(logger, otherContainer)
+						Ω(fakeRepBBS.EvacuateRunningActualLRPCallCount()).Should(Equal(1))
+					})
+
+					It("evacuates the next instance once the replacement is running", func() {
+						fakeRepBBS.EvacuateRunningActualLRPReturns(shared.DeleteContainer, nil)
+						lrpProcessor.Process(logger, container)
+
+						lrpProcessor.Process(logger, otherContainer)
+						Ω(fakeRepBBS.EvacuateRunningActualLRPCallCount()).Should(Equal(3))
+					})
+				})
+
+				It("evacuates the next instance once the container in flight has completed", func() {
+					completed := container
+					completed.State = executor.StateCompleted
+					completed.RunResult.Stopped = true
+					lrpProcessor.Process(logger, completed)
+
+					lrpProcessor.Process(logger, otherContainer)
+					Ω(fakeRepBBS.EvacuateRunningActualLRPCallCount()).Should(Equal(2))
+				})
+
+				Context("when limiting instances per process", func() {
+					BeforeEach(func() {
+						waveConfig = internal.EvacuationWaveConfig{MaxInFlightPerProcess: 1}
+					})
+
+					It("defers evacuating more instances of the same process", func() {
+						lrpProcessor.Process(logger, otherContainer)
+						Ω(fakeRepBBS.EvacuateRunningActualLRPCallCount()).Should(Equal(1))
+					})
+
+					It("evacuates instances of other processes", func() {
+						lrpProcessor.Process(logger, newRunningContainer("other-process-guid", "other-instance-guid"))
+						Ω(fakeRepBBS.EvacuateRunningActualLRPCallCount()).Should(Equal(2))
+					})
+				})
+			})
 		})
 
 		Context("when the container is COMPLETED (shutdown)", func() {
@@ -0,0 +1,28 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/rep"
+)
+
+// EvacuationTTLConfig determines how long an evacuating instance holds its
+// slot in the BBS while it waits to be replaced.
+type EvacuationTTLConfig struct {
+	Default          time.Duration
+	Domains          map[string]time.Duration
+	FromStartTimeout bool
+}
+
+func (c EvacuationTTLConfig) TTLInSecondsFor(container executor.Container) uint64 {
+	if ttl, ok := c.Domains[container.Tags[rep.DomainTag]]; ok {
+		return uint64(ttl.Seconds())
+	}
+
+	if c.FromStartTimeout && container.StartTimeout > 0 {
+		return uint64(container.StartTimeout)
+	}
+
+	return uint64(c.Default.Seconds())
+}
@@ -0,0 +1,66 @@
+package internal_test
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/rep"
+	"github.com/cloudfoundry-incubator/rep/generator/internal"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EvacuationTTLConfig", func() {
+	var (
+		config    internal.EvacuationTTLConfig
+		container executor.Container
+	)
+
+	BeforeEach(func() {
+		config = internal.EvacuationTTLConfig{
+			Default: 10 * time.Minute,
+			Domains: map[string]time.Duration{"slow-domain": 30 * time.Minute},
+		}
+
+		container = executor.Container{
+			Tags:         executor.Tags{rep.DomainTag: "some-domain"},
+			StartTimeout: 60,
+		}
+	})
+
+	It("defaults to the global TTL", func() {
+		Ω(config.TTLInSecondsFor(container)).Should(Equal(uint64(600)))
+	})
+
+	Context("when the TTL is derived from the start timeout", func() {
+		BeforeEach(func() {
+			config.FromStartTimeout = true
+		})
+
+		It("uses the container's start timeout", func() {
+			Ω(config.TTLInSecondsFor(container)).Should(Equal(uint64(60)))
+		})
+
+		Context("and the container has no start timeout", func() {
+			BeforeEach(func() {
+				container.StartTimeout = 0
+			})
+
+			It("uses the global TTL", func() {
+				Ω(config.TTLInSecondsFor(container)).Should(Equal(uint64(600)))
+			})
+		})
+	})
+
+	Context("when the domain overrides the TTL", func() {
+		BeforeEach(func() {
+			config.FromStartTimeout = true
+			container.Tags[rep.DomainTag] = "slow-domain"
+		})
+
+		It("uses the domain's TTL", func() {
+			Ω(config.TTLInSecondsFor(container)).Should(Equal(uint64(1800)))
+		})
+	})
+})
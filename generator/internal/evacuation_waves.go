@@ -0,0 +1,130 @@
+package internal
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pivotal-golang/clock"
+)
+
+// EvacuationWaveConfig limits how many running LRP instances are evacuated at
+// once, so that their replacements are auctioned in waves rather than all at
+// once. Zero limits are unlimited.
+type EvacuationWaveConfig struct {
+	MaxInFlight           int
+	MaxInFlightPerProcess int
+
+	// WaitForReplacement keeps an instance in flight until its replacement is
+	// running elsewhere or its container is gone. Otherwise it stays in flight
+	// for WaveInterval after its evacuation is requested, so that each wave is
+	// given that long to be auctioned before the next is evacuated.
+	WaitForReplacement bool
+	WaveInterval       time.Duration
+}
+
+type evacuatingInstance struct {
+	processGuid string
+	inFlight    bool
+	landsAt     time.Time
+}
+
+// evacuationWaves admits running LRP containers to evacuation within the
+// limits of an EvacuationWaveConfig. Once admitted, a container stays admitted
+// until forgotten, whether or not it is still in flight.
+type evacuationWaves struct {
+	config EvacuationWaveConfig
+	clock  clock.Clock
+
+	lock       sync.Mutex
+	instances  map[string]*evacuatingInstance
+	inFlight   int
+	perProcess map[string]int
+}
+
+func newEvacuationWaves(config EvacuationWaveConfig, clock clock.Clock) *evacuationWaves {
+	return &evacuationWaves{
+		config:     config,
+		clock:      clock,
+		instances:  make(map[string]*evacuatingInstance),
+		perProcess: make(map[string]int),
+	}
+}
+
+// Admit reports whether the container may be evacuated now.
+func (w *evacuationWaves) Admit(guid, processGuid string) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if _, found := w.instances[guid]; found {
+		return true
+	}
+
+	w.landDue()
+
+	if w.config.MaxInFlight > 0 && w.inFlight >= w.config.MaxInFlight {
+		return false
+	}
+
+	if w.config.MaxInFlightPerProcess > 0 && w.perProcess[processGuid] >= w.config.MaxInFlightPerProcess {
+		return false
+	}
+
+	w.instances[guid] = &evacuatingInstance{processGuid: processGuid, inFlight: true}
+	w.inFlight++
+	w.perProcess[processGuid]++
+
+	return true
+}
+
+// Evacuated records that the container's evacuation has been requested. Unless
+// waiting for its replacement, it leaves flight a wave interval later.
+func (w *evacuationWaves) Evacuated(guid string) {
+	if w.config.WaitForReplacement {
+		return
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	instance, found := w.instances[guid]
+	if !found || !instance.inFlight || !instance.landsAt.IsZero() {
+		return
+	}
+
+	instance.landsAt = w.clock.Now().Add(w.config.WaveInterval)
+}
+
+// Forget records that the container is no longer evacuating: its replacement
+// is running, or the container is gone.
+func (w *evacuationWaves) Forget(guid string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if instance, found := w.instances[guid]; found {
+		w.land(instance)
+		delete(w.instances, guid)
+	}
+}
+
+func (w *evacuationWaves) landDue() {
+	now := w.clock.Now()
+	for _, instance := range w.instances {
+		if instance.inFlight && !instance.landsAt.IsZero() && !now.Before(instance.landsAt) {
+			w.land(instance)
+		}
+	}
+}
+
+func (w *evacuationWaves) land(instance *evacuatingInstance) {
+	if !instance.inFlight {
+		return
+	}
+
+	instance.inFlight = false
+	w.inFlight--
+
+	w.perProcess[instance.processGuid]--
+	if w.perProcess[instance.processGuid] == 0 {
+		delete(w.perProcess, instance.processGuid)
+	}
+}
@@ -20,6 +20,16 @@ type FakeContainerDelegate struct {
 		result1 executor.Container
 		result2 bool
 	}
+	GetContainersStub        func(logger lager.Logger, guids []string) (map[string]executor.Container, error)
+	getContainersMutex       sync.RWMutex
+	getContainersArgsForCall []struct {
+		logger lager.Logger
+		guids  []string
+	}
+	getContainersReturns struct {
+		result1 map[string]executor.Container
+		result2 error
+	}
 	RunContainerStub        func(logger lager.Logger, guid string) bool
 	runContainerMutex       sync.RWMutex
 	runContainerArgsForCall []struct {
@@ -29,6 +39,15 @@ type FakeContainerDelegate struct {
 	runContainerReturns struct {
 		result1 bool
 	}
+	TryRunContainerStub        func(logger lager.Logger, guid string) error
+	tryRunContainerMutex       sync.RWMutex
+	tryRunContainerArgsForCall []struct {
+		logger lager.Logger
+		guid   string
+	}
+	tryRunContainerReturns struct {
+		result1 error
+	}
 	StopContainerStub        func(logger lager.Logger, guid string) bool
 	stopContainerMutex       sync.RWMutex
 	stopContainerArgsForCall []struct {
@@ -47,17 +66,30 @@ type FakeContainerDelegate struct {
 	deleteContainerReturns struct {
 		result1 bool
 	}
-	FetchContainerResultFileStub        func(logger lager.Logger, guid string, filename string) (string, error)
+	FetchContainerResultFileStub        func(logger lager.Logger, guid string, filename string, maxSize int) (string, error)
 	fetchContainerResultFileMutex       sync.RWMutex
 	fetchContainerResultFileArgsForCall []struct {
 		logger   lager.Logger
 		guid     string
 		filename string
+		maxSize  int
 	}
 	fetchContainerResultFileReturns struct {
 		result1 string
 		result2 error
 	}
+	FetchContainerFileTailStub        func(logger lager.Logger, guid string, filename string, maxSize int) (string, error)
+	fetchContainerFileTailMutex       sync.RWMutex
+	fetchContainerFileTailArgsForCall []struct {
+		logger   lager.Logger
+		guid     string
+		filename string
+		maxSize  int
+	}
+	fetchContainerFileTailReturns struct {
+		result1 string
+		result2 error
+	}
 }
 
 func (fake *FakeContainerDelegate) GetContainer(logger lager.Logger, guid string) (executor.Container, bool) {
@@ -94,6 +126,40 @@ func (fake *FakeContainerDelegate) GetContainerReturns(result1 executor.Containe
 	}{result1, result2}
 }
 
+func (fake *FakeContainerDelegate) GetContainers(logger lager.Logger, guids []string) (map[string]executor.Container, error) {
+	fake.getContainersMutex.Lock()
+	fake.getContainersArgsForCall = append(fake.getContainersArgsForCall, struct {
+		logger lager.Logger
+		guids  []string
+	}{logger, guids})
+	fake.getContainersMutex.Unlock()
+	if fake.GetContainersStub != nil {
+		return fake.GetContainersStub(logger, guids)
+	} else {
+		return fake.getContainersReturns.result1, fake.getContainersReturns.result2
+	}
+}
+
+func (fake *FakeContainerDelegate) GetContainersCallCount() int {
+	fake.getContainersMutex.RLock()
+	defer fake.getContainersMutex.RUnlock()
+	return len(fake.getContainersArgsForCall)
+}
+
+func (fake *FakeContainerDelegate) GetContainersArgsForCall(i int) (lager.Logger, []string) {
+	fake.getContainersMutex.RLock()
+	defer fake.getContainersMutex.RUnlock()
+	return fake.getContainersArgsForCall[i].logger, fake.getContainersArgsForCall[i].guids
+}
+
+func (fake *FakeContainerDelegate) GetContainersReturns(result1 map[string]executor.Container, result2 error) {
+	fake.GetContainersStub = nil
+	fake.getContainersReturns = struct {
+		result1 map[string]executor.Container
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeContainerDelegate) RunContainer(logger lager.Logger, guid string) bool {
 	fake.runContainerMutex.Lock()
 	fake.runContainerArgsForCall = append(fake.runContainerArgsForCall, struct {
@@ -127,6 +193,39 @@ func (fake *FakeContainerDelegate) RunContainerReturns(result1 bool) {
 	}{result1}
 }
 
+func (fake *FakeContainerDelegate) TryRunContainer(logger lager.Logger, guid string) error {
+	fake.tryRunContainerMutex.Lock()
+	fake.tryRunContainerArgsForCall = append(fake.tryRunContainerArgsForCall, struct {
+		logger lager.Logger
+		guid   string
+	}{logger, guid})
+	fake.tryRunContainerMutex.Unlock()
+	if fake.TryRunContainerStub != nil {
+		return fake.TryRunContainerStub(logger, guid)
+	} else {
+		return fake.tryRunContainerReturns.result1
+	}
+}
+
+func (fake *FakeContainerDelegate) TryRunContainerCallCount() int {
+	fake.tryRunContainerMutex.RLock()
+	defer fake.tryRunContainerMutex.RUnlock()
+	return len(fake.tryRunContainerArgsForCall)
+}
+
+func (fake *FakeContainerDelegate) TryRunContainerArgsForCall(i int) (lager.Logger, string) {
+	fake.tryRunContainerMutex.RLock()
+	defer fake.tryRunContainerMutex.RUnlock()
+	return fake.tryRunContainerArgsForCall[i].logger, fake.tryRunContainerArgsForCall[i].guid
+}
+
+func (fake *FakeContainerDelegate) TryRunContainerReturns(result1 error) {
+	fake.TryRunContainerStub = nil
+	fake.tryRunContainerReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeContainerDelegate) StopContainer(logger lager.Logger, guid string) bool {
 	fake.stopContainerMutex.Lock()
 	fake.stopContainerArgsForCall = append(fake.stopContainerArgsForCall, struct {
@@ -193,16 +292,17 @@ func (fake *FakeContainerDelegate) DeleteContainerReturns(result1 bool) {
 	}{result1}
 }
 
-func (fake *FakeContainerDelegate) FetchContainerResultFile(logger lager.Logger, guid string, filename string) (string, error) {
+func (fake *FakeContainerDelegate) FetchContainerResultFile(logger lager.Logger, guid string, filename string, maxSize int) (string, error) {
 	fake.fetchContainerResultFileMutex.Lock()
 	fake.fetchContainerResultFileArgsForCall = append(fake.fetchContainerResultFileArgsForCall, struct {
 		logger   lager.Logger
 		guid     string
 		filename string
-	}{logger, guid, filename})
+		maxSize  int
+	}{logger, guid, filename, maxSize})
 	fake.fetchContainerResultFileMutex.Unlock()
 	if fake.FetchContainerResultFileStub != nil {
-		return fake.FetchContainerResultFileStub(logger, guid, filename)
+		return fake.FetchContainerResultFileStub(logger, guid, filename, maxSize)
 	} else {
 		return fake.fetchContainerResultFileReturns.result1, fake.fetchContainerResultFileReturns.result2
 	}
@@ -214,10 +314,10 @@ func (fake *FakeContainerDelegate) FetchContainerResultFileCallCount() int {
 	return len(fake.fetchContainerResultFileArgsForCall)
 }
 
-func (fake *FakeContainerDelegate) FetchContainerResultFileArgsForCall(i int) (lager.Logger, string, string) {
+func (fake *FakeContainerDelegate) FetchContainerResultFileArgsForCall(i int) (lager.Logger, string, string, int) {
 	fake.fetchContainerResultFileMutex.RLock()
 	defer fake.fetchContainerResultFileMutex.RUnlock()
-	return fake.fetchContainerResultFileArgsForCall[i].logger, fake.fetchContainerResultFileArgsForCall[i].guid, fake.fetchContainerResultFileArgsForCall[i].filename
+	return fake.fetchContainerResultFileArgsForCall[i].logger, fake.fetchContainerResultFileArgsForCall[i].guid, fake.fetchContainerResultFileArgsForCall[i].filename, fake.fetchContainerResultFileArgsForCall[i].maxSize
 }
 
 func (fake *FakeContainerDelegate) FetchContainerResultFileReturns(result1 string, result2 error) {
@@ -228,4 +328,40 @@ func (fake *FakeContainerDelegate) FetchContainerResultFileReturns(result1 strin
 	}{result1, result2}
 }
 
+func (fake *FakeContainerDelegate) FetchContainerFileTail(logger lager.Logger, guid string, filename string, maxSize int) (string, error) {
+	fake.fetchContainerFileTailMutex.Lock()
+	fake.fetchContainerFileTailArgsForCall = append(fake.fetchContainerFileTailArgsForCall, struct {
+		logger   lager.Logger
+		guid     string
+		filename string
+		maxSize  int
+	}{logger, guid, filename, maxSize})
+	fake.fetchContainerFileTailMutex.Unlock()
+	if fake.FetchContainerFileTailStub != nil {
+		return fake.FetchContainerFileTailStub(logger, guid, filename, maxSize)
+	} else {
+		return fake.fetchContainerFileTailReturns.result1, fake.fetchContainerFileTailReturns.result2
+	}
+}
+
+func (fake *FakeContainerDelegate) FetchContainerFileTailCallCount() int {
+	fake.fetchContainerFileTailMutex.RLock()
+	defer fake.fetchContainerFileTailMutex.RUnlock()
+	return len(fake.fetchContainerFileTailArgsForCall)
+}
+
+func (fake *FakeContainerDelegate) FetchContainerFileTailArgsForCall(i int) (lager.Logger, string, string, int) {
+	fake.fetchContainerFileTailMutex.RLock()
+	defer fake.fetchContainerFileTailMutex.RUnlock()
+	return fake.fetchContainerFileTailArgsForCall[i].logger, fake.fetchContainerFileTailArgsForCall[i].guid, fake.fetchContainerFileTailArgsForCall[i].filename, fake.fetchContainerFileTailArgsForCall[i].maxSize
+}
+
+func (fake *FakeContainerDelegate) FetchContainerFileTailReturns(result1 string, result2 error) {
+	fake.FetchContainerFileTailStub = nil
+	fake.fetchContainerFileTailReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
 var _ internal.ContainerDelegate = new(FakeContainerDelegate)
@@ -0,0 +1,45 @@
+// This file was generated by counterfeiter
+package fake_internal
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/rep/generator/internal"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeDiagnosticsCollector struct {
+	CollectStub        func(logger lager.Logger, container executor.Container)
+	collectMutex       sync.RWMutex
+	collectArgsForCall []struct {
+		logger    lager.Logger
+		container executor.Container
+	}
+}
+
+func (fake *FakeDiagnosticsCollector) Collect(logger lager.Logger, container executor.Container) {
+	fake.collectMutex.Lock()
+	fake.collectArgsForCall = append(fake.collectArgsForCall, struct {
+		logger    lager.Logger
+		container executor.Container
+	}{logger, container})
+	fake.collectMutex.Unlock()
+	if fake.CollectStub != nil {
+		fake.CollectStub(logger, container)
+	}
+}
+
+func (fake *FakeDiagnosticsCollector) CollectCallCount() int {
+	fake.collectMutex.RLock()
+	defer fake.collectMutex.RUnlock()
+	return len(fake.collectArgsForCall)
+}
+
+func (fake *FakeDiagnosticsCollector) CollectArgsForCall(i int) (lager.Logger, executor.Container) {
+	fake.collectMutex.RLock()
+	defer fake.collectMutex.RUnlock()
+	return fake.collectArgsForCall[i].logger, fake.collectArgsForCall[i].container
+}
+
+var _ internal.DiagnosticsCollector = new(FakeDiagnosticsCollector)
@@ -3,8 +3,11 @@ package internal
 import (
 	"github.com/cloudfoundry-incubator/executor"
 	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context"
+	"github.com/cloudfoundry-incubator/rep/lrp_stopper"
+	"github.com/cloudfoundry-incubator/rep/quarantine"
 	"github.com/cloudfoundry-incubator/runtime-schema/bbs"
 	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/pivotal-golang/clock"
 	"github.com/pivotal-golang/lager"
 )
 
@@ -30,6 +33,7 @@ type LRPProcessor interface {
 
 type lrpProcessor struct {
 	evacuationReporter  evacuation_context.EvacuationReporter
+	waves               *evacuationWaves
 	ordinaryProcessor   LRPProcessor
 	evacuationProcessor LRPProcessor
 }
@@ -39,21 +43,29 @@ func NewLRPProcessor(
 	containerDelegate ContainerDelegate,
 	cellID string,
 	evacuationReporter evacuation_context.EvacuationReporter,
-	evacuationTTLInSeconds uint64,
+	evacuationTTLConfig EvacuationTTLConfig,
+	evacuationWaveConfig EvacuationWaveConfig,
+	drainer lrp_stopper.Drainer,
+	quarantine quarantine.Quarantine,
+	clock clock.Clock,
 ) LRPProcessor {
-	ordinaryProcessor := newOrdinaryLRPProcessor(bbs, containerDelegate, cellID)
-	evacuationProcessor := newEvacuationLRPProcessor(bbs, containerDelegate, cellID, evacuationTTLInSeconds)
+	evacuated := newEvacuatedContainers()
+	waves := newEvacuationWaves(evacuationWaveConfig, clock)
+	ordinaryProcessor := newOrdinaryLRPProcessor(bbs, containerDelegate, cellID, drainer, quarantine, evacuated)
+	evacuationProcessor := newEvacuationLRPProcessor(bbs, containerDelegate, cellID, evacuationTTLConfig, drainer, quarantine, evacuated, waves)
 	return &lrpProcessor{
 		evacuationReporter:  evacuationReporter,
+		waves:               waves,
 		ordinaryProcessor:   ordinaryProcessor,
 		evacuationProcessor: evacuationProcessor,
 	}
 }
 
 func (p *lrpProcessor) Process(logger lager.Logger, container executor.Container) {
-	if p.evacuationReporter.Evacuating() {
+	if p.evacuationReporter.Evacuating() && p.evacuationReporter.EvacuationScope().MatchesContainer(container) {
 		p.evacuationProcessor.Process(logger, container)
 	} else {
+		p.waves.Forget(container.Guid)
 		p.ordinaryProcessor.Process(logger, container)
 	}
 }
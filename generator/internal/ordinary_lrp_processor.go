@@ -3,6 +3,8 @@ package internal
 import (
 	"github.com/cloudfoundry-incubator/executor"
 	"github.com/cloudfoundry-incubator/rep"
+	"github.com/cloudfoundry-incubator/rep/lrp_stopper"
+	"github.com/cloudfoundry-incubator/rep/quarantine"
 	"github.com/cloudfoundry-incubator/runtime-schema/bbs"
 	"github.com/cloudfoundry-incubator/runtime-schema/bbs/bbserrors"
 	"github.com/pivotal-golang/lager"
@@ -12,17 +14,26 @@ type ordinaryLRPProcessor struct {
 	bbs               bbs.RepBBS
 	containerDelegate ContainerDelegate
 	cellID            string
+	drainer           lrp_stopper.Drainer
+	quarantine        quarantine.Quarantine
+	evacuated         *evacuatedContainers
 }
 
 func newOrdinaryLRPProcessor(
 	bbs bbs.RepBBS,
 	containerDelegate ContainerDelegate,
 	cellID string,
+	drainer lrp_stopper.Drainer,
+	quarantine quarantine.Quarantine,
+	evacuated *evacuatedContainers,
 ) LRPProcessor {
 	return &ordinaryLRPProcessor{
 		bbs:               bbs,
 		containerDelegate: containerDelegate,
 		cellID:            cellID,
+		drainer:           drainer,
+		quarantine:        quarantine,
+		evacuated:         evacuated,
 	}
 }
 
@@ -62,7 +73,10 @@ func (p *ordinaryLRPProcessor) Process(logger lager.Logger, container executor.C
 		p.processCompletedContainer(logger, lrpContainer)
 	default:
 		p.processInvalidContainer(logger, lrpContainer)
+		return
 	}
+
+	p.quarantine.Release(logger, container.Guid)
 }
 
 func (p *ordinaryLRPProcessor) processReservedContainer(logger lager.Logger, lrpContainer *lrpContainer) {
@@ -92,6 +106,11 @@ func (p *ordinaryLRPProcessor) processCreatedContainer(logger lager.Logger, lrpC
 func (p *ordinaryLRPProcessor) processRunningContainer(logger lager.Logger, lrpContainer *lrpContainer) {
 	logger = logger.Session("process-running-container")
 
+	if p.drainer.Draining(lrpContainer.Guid) {
+		logger.Info("skipped-draining-container")
+		return
+	}
+
 	logger.Debug("extracting-net-info-from-container")
 	netInfo, err := rep.ActualLRPNetInfoFromContainer(lrpContainer.Container)
 	if err != nil {
@@ -102,7 +121,29 @@ func (p *ordinaryLRPProcessor) processRunningContainer(logger lager.Logger, lrpC
 
 	err = p.bbs.StartActualLRP(logger, lrpContainer.ActualLRPKey, lrpContainer.ActualLRPInstanceKey, netInfo)
 	if err == bbserrors.ErrActualLRPCannotBeStarted {
+		// the instance never received traffic from this container, so there
+		// is nothing to drain
 		p.containerDelegate.StopContainer(logger, lrpContainer.Guid)
+		return
+	}
+
+	if err == nil {
+		p.removeEvacuatingLRP(logger, lrpContainer)
+	}
+}
+
+// removeEvacuatingLRP cleans up after a canceled evacuation, once the
+// container's instance ActualLRP is running here again or the container has
+// completed.
+func (p *ordinaryLRPProcessor) removeEvacuatingLRP(logger lager.Logger, lrpContainer *lrpContainer) {
+	if !p.evacuated.Remove(lrpContainer.Guid) {
+		return
+	}
+
+	logger.Info("removing-evacuating-lrp-after-canceled-evacuation")
+	err := p.bbs.RemoveEvacuatingActualLRP(logger, lrpContainer.ActualLRPKey, lrpContainer.ActualLRPInstanceKey)
+	if err != nil {
+		logger.Error("failed-to-remove-evacuating-lrp", err)
 	}
 }
 
@@ -112,15 +153,18 @@ func (p *ordinaryLRPProcessor) processCompletedContainer(logger lager.Logger, lr
 	if lrpContainer.RunResult.Stopped {
 		p.bbs.RemoveActualLRP(logger, lrpContainer.ActualLRPKey, lrpContainer.ActualLRPInstanceKey)
 	} else {
-		p.bbs.CrashActualLRP(logger, lrpContainer.ActualLRPKey, lrpContainer.ActualLRPInstanceKey, lrpContainer.RunResult.FailureReason)
+		p.bbs.CrashActualLRP(logger, lrpContainer.ActualLRPKey, lrpContainer.ActualLRPInstanceKey, classifyCrash(logger, lrpContainer))
 	}
 
+	p.removeEvacuatingLRP(logger, lrpContainer)
+
 	p.containerDelegate.DeleteContainer(logger, lrpContainer.Guid)
 }
 
 func (p *ordinaryLRPProcessor) processInvalidContainer(logger lager.Logger, lrpContainer *lrpContainer) {
 	logger = logger.Session("process-invalid-container")
 	logger.Error("not-processing-container-in-invalid-state", nil)
+	p.quarantine.Observe(logger, lrpContainer.Container)
 }
 
 func (p *ordinaryLRPProcessor) claimLRPContainer(logger lager.Logger, lrpContainer *lrpContainer) bool {
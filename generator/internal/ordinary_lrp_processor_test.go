@@ -3,15 +3,22 @@ package internal_test
 import (
 	"errors"
 	"strconv"
+	"time"
 
 	"github.com/cloudfoundry-incubator/executor"
 	"github.com/cloudfoundry-incubator/rep"
 	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context/fake_evacuation_context"
 	"github.com/cloudfoundry-incubator/rep/generator/internal"
 	"github.com/cloudfoundry-incubator/rep/generator/internal/fake_internal"
+	"github.com/cloudfoundry-incubator/rep/lrp_stopper/fake_lrp_stopper"
+	"github.com/cloudfoundry-incubator/rep/quarantine/fake_quarantine"
 	"github.com/cloudfoundry-incubator/runtime-schema/bbs/bbserrors"
 	"github.com/cloudfoundry-incubator/runtime-schema/bbs/fake_bbs"
+	"github.com/cloudfoundry-incubator/runtime-schema/bbs/shared"
 	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/cloudfoundry/dropsonde/metric_sender/fake"
+	"github.com/cloudfoundry/dropsonde/metrics"
+	"github.com/pivotal-golang/clock/fakeclock"
 	"github.com/pivotal-golang/lager/lagertest"
 
 	. "github.com/onsi/ginkgo"
@@ -27,13 +34,17 @@ var _ = Describe("OrdinaryLRPProcessor", func() {
 	var bbs *fake_bbs.FakeRepBBS
 	var containerDelegate *fake_internal.FakeContainerDelegate
 	var evacuationReporter *fake_evacuation_context.FakeEvacuationReporter
+	var drainer *fake_lrp_stopper.FakeDrainer
+	var fakeQuarantine *fake_quarantine.FakeQuarantine
 
 	BeforeEach(func() {
 		bbs = new(fake_bbs.FakeRepBBS)
 		containerDelegate = new(fake_internal.FakeContainerDelegate)
 		evacuationReporter = &fake_evacuation_context.FakeEvacuationReporter{}
 		evacuationReporter.EvacuatingReturns(false)
-		processor = internal.NewLRPProcessor(bbs, containerDelegate, expectedCellID, evacuationReporter, 124)
+		drainer = new(fake_lrp_stopper.FakeDrainer)
+		fakeQuarantine = new(fake_quarantine.FakeQuarantine)
+		processor = internal.NewLRPProcessor(bbs, containerDelegate, expectedCellID, evacuationReporter, internal.EvacuationTTLConfig{Default: 124 * time.Second}, internal.EvacuationWaveConfig{}, drainer, fakeQuarantine, fakeclock.NewFakeClock(time.Now()))
 		logger = lagertest.NewTestLogger("test")
 	})
 
@@ -71,8 +82,40 @@ var _ = Describe("OrdinaryLRPProcessor", func() {
 				It("logs an error", func() {
 					Ω(logger).Should(Say(expectedSessionName))
 				})
+
+				It("quarantines the container", func() {
+					Ω(fakeQuarantine.ObserveCallCount()).Should(Equal(1))
+					_, quarantined := fakeQuarantine.ObserveArgsForCall(0)
+					Ω(quarantined).Should(Equal(container))
+				})
+
+				It("does not release the container", func() {
+					Ω(fakeQuarantine.ReleaseCallCount()).Should(BeZero())
+				})
 			})
 
+			for _, state := range []executor.State{
+				executor.StateReserved,
+				executor.StateInitializing,
+				executor.StateCreated,
+				executor.StateRunning,
+				executor.StateCompleted,
+			} {
+				state := state
+
+				Context("and the container is "+string(state), func() {
+					BeforeEach(func() {
+						container.State = state
+					})
+
+					It("releases the container from quarantine", func() {
+						Ω(fakeQuarantine.ReleaseCallCount()).Should(Equal(1))
+						_, guid := fakeQuarantine.ReleaseArgsForCall(0)
+						Ω(guid).Should(Equal(container.Guid))
+					})
+				})
+			}
+
 			Context("and the container is RESERVED", func() {
 				BeforeEach(func() {
 					expectedSessionName = sessionPrefix + "process-reserved-container"
@@ -213,7 +256,11 @@ var _ = Describe("OrdinaryLRPProcessor", func() {
 							bbs.StartActualLRPReturns(bbserrors.ErrActualLRPCannotBeStarted)
 						})
 
-						It("stops the container", func() {
+						It("does not drain the instance", func() {
+							Ω(drainer.DrainAndStopCallCount()).Should(BeZero())
+						})
+
+						It("stops the container immediately", func() {
 							Ω(containerDelegate.StopContainerCallCount()).Should(Equal(1))
 							delegateLogger, containerGuid := containerDelegate.StopContainerArgsForCall(0)
 							Ω(containerGuid).Should(Equal(container.Guid))
@@ -221,6 +268,20 @@ var _ = Describe("OrdinaryLRPProcessor", func() {
 						})
 					})
 
+					Context("when the instance is being drained", func() {
+						BeforeEach(func() {
+							drainer.DrainingReturns(true)
+						})
+
+						It("does not start the actual LRP", func() {
+							Ω(bbs.StartActualLRPCallCount()).Should(Equal(0))
+						})
+
+						It("does not stop the container", func() {
+							Ω(containerDelegate.StopContainerCallCount()).Should(Equal(0))
+						})
+					})
+
 					Context("when starting fails for an unknown reason", func() {
 						BeforeEach(func() {
 							bbs.StartActualLRPReturns(errors.New("boom"))
@@ -231,6 +292,41 @@ var _ = Describe("OrdinaryLRPProcessor", func() {
 							Ω(containerDelegate.DeleteContainerCallCount()).Should(Equal(0))
 						})
 					})
+
+					It("does not remove an evacuating actual LRP", func() {
+						Ω(bbs.RemoveEvacuatingActualLRPCallCount()).Should(Equal(0))
+					})
+
+					Context("when the container was evacuated before evacuation was canceled", func() {
+						BeforeEach(func() {
+							bbs.EvacuateRunningActualLRPReturns(shared.KeepContainer, nil)
+							evacuationReporter.EvacuatingReturns(true)
+							processor.Process(logger, container)
+							evacuationReporter.EvacuatingReturns(false)
+						})
+
+						It("removes the evacuating actual LRP once the instance has started", func() {
+							Ω(bbs.RemoveEvacuatingActualLRPCallCount()).Should(Equal(1))
+							_, lrpKey, instanceKey := bbs.RemoveEvacuatingActualLRPArgsForCall(0)
+							Ω(lrpKey).Should(Equal(expectedLrpKey))
+							Ω(instanceKey).Should(Equal(expectedInstanceKey))
+						})
+
+						It("removes it only once", func() {
+							processor.Process(logger, container)
+							Ω(bbs.RemoveEvacuatingActualLRPCallCount()).Should(Equal(1))
+						})
+
+						Context("when starting fails", func() {
+							BeforeEach(func() {
+								bbs.StartActualLRPReturns(errors.New("boom"))
+							})
+
+							It("keeps the evacuating actual LRP", func() {
+								Ω(bbs.RemoveEvacuatingActualLRPCallCount()).Should(Equal(0))
+							})
+						})
+					})
 				})
 
 				Context("and the container is COMPLETED", func() {
@@ -296,6 +392,31 @@ var _ = Describe("OrdinaryLRPProcessor", func() {
 							Ω(containerGuid).Should(Equal(container.Guid))
 							Ω(delegateLogger.SessionName()).Should(Equal(expectedSessionName))
 						})
+
+						Context("and the container ran out of memory", func() {
+							var sender *fake.FakeMetricSender
+
+							BeforeEach(func() {
+								sender = fake.NewFakeMetricSender()
+								metrics.Initialize(sender)
+
+								container.RunResult.FailureReason = "Exited with status 137 (out of memory)"
+							})
+
+							It("records the classification in the crash reason", func() {
+								Ω(bbs.CrashActualLRPCallCount()).Should(Equal(1))
+								_, _, _, reason := bbs.CrashActualLRPArgsForCall(0)
+								Ω(reason).Should(Equal("oom: Exited with status 137 (out of memory)"))
+							})
+
+							It("increments the crash counter for the category", func() {
+								Ω(sender.GetCounter("RepLRPCrashes.oom")).Should(BeEquivalentTo(1))
+							})
+
+							It("increments the crash counter for the category in the domain", func() {
+								Ω(sender.GetCounter("RepLRPCrashes.oom.domain")).Should(BeEquivalentTo(1))
+							})
+						})
 					})
 				})
 
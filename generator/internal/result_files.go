@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+)
+
+// CompressedResultPrefix marks a task result that was gzipped and base64 encoded
+// before being written to the BBS.
+const CompressedResultPrefix = "gzip+base64:"
+
+type ResultFilePolicy struct {
+	// MaxSize is the largest result file, in bytes, that will be read from a container.
+	MaxSize int
+
+	// CompressionThreshold is the result size, in bytes, above which results are
+	// compressed. Zero disables compression.
+	CompressionThreshold int
+}
+
+type ResultFileConfig struct {
+	Default ResultFilePolicy
+	Domains map[string]ResultFilePolicy
+}
+
+func NewResultFileConfig(maxSize, compressionThreshold int) ResultFileConfig {
+	return ResultFileConfig{
+		Default: ResultFilePolicy{
+			MaxSize:              maxSize,
+			CompressionThreshold: compressionThreshold,
+		},
+		Domains: map[string]ResultFilePolicy{},
+	}
+}
+
+func (c ResultFileConfig) PolicyFor(domain string) ResultFilePolicy {
+	policy, ok := c.Domains[domain]
+	if !ok {
+		return c.Default
+	}
+	return policy
+}
+
+func CompressResult(result string) (string, error) {
+	buf := new(bytes.Buffer)
+
+	writer := gzip.NewWriter(buf)
+	_, err := writer.Write([]byte(result))
+	if err != nil {
+		return "", err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return "", err
+	}
+
+	return CompressedResultPrefix + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
@@ -0,0 +1,41 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/executor"
+)
+
+// RetryPolicy bounds how often running a task's container is attempted before
+// the task is failed. The backoff doubles after every failed attempt.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+func (p RetryPolicy) maxAttempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	return p.Backoff * time.Duration(1<<uint(attempt-1))
+}
+
+// IsTransientRunError reports whether running a container might succeed if
+// tried again. Errors describing the container itself are permanent; anything
+// else, such as failing to reach the executor, is assumed to be transient.
+func IsTransientRunError(err error) bool {
+	switch err {
+	case executor.ErrContainerNotFound,
+		executor.ErrInvalidTransition,
+		executor.ErrStepsInvalid,
+		executor.ErrLimitsInvalid,
+		executor.ErrGuidNotSpecified:
+		return false
+	default:
+		return true
+	}
+}
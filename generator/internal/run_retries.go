@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/pivotal-golang/clock"
+)
+
+// RunRetries records the failed attempts to run task containers, and reports
+// each container on Due once its next attempt may be made.
+type RunRetries struct {
+	clock clock.Clock
+	due   chan string
+
+	lock    sync.Mutex
+	retries map[string]*runRetry
+}
+
+// runRetry records the failed attempts to run a task's container, and when it
+// may be tried again.
+type runRetry struct {
+	failedAttempts []string
+	scheduledAt    time.Time
+	retryAt        time.Time
+	cancel         chan struct{}
+}
+
+func NewRunRetries(clock clock.Clock) *RunRetries {
+	return &RunRetries{
+		clock:   clock,
+		due:     make(chan string),
+		retries: make(map[string]*runRetry),
+	}
+}
+
+// Due reports the containers whose next attempt to run may now be made. A
+// container not received before its retry is forgotten is never reported.
+func (r *RunRetries) Due() <-chan string {
+	return r.due
+}
+
+// Schedule records the failed attempts to run the container and reports it
+// on Due once the backoff has passed.
+func (r *RunRetries) Schedule(guid string, failedAttempts []string, backoff time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := r.clock.Now()
+	scheduledAt := now
+	if previous, found := r.retries[guid]; found {
+		close(previous.cancel)
+		scheduledAt = previous.scheduledAt
+	}
+
+	retry := &runRetry{
+		failedAttempts: failedAttempts,
+		scheduledAt:    scheduledAt,
+		retryAt:        now.Add(backoff),
+		cancel:         make(chan struct{}),
+	}
+	r.retries[guid] = retry
+
+	timer := r.clock.NewTimer(backoff)
+	go func() {
+		defer timer.Stop()
+
+		select {
+		case <-timer.C():
+		case <-retry.cancel:
+			return
+		}
+
+		select {
+		case r.due <- guid:
+		case <-retry.cancel:
+		}
+	}()
+}
+
+func (r *RunRetries) get(guid string) (runRetry, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	retry, found := r.retries[guid]
+	if !found {
+		return runRetry{}, false
+	}
+
+	return *retry, true
+}
+
+// Forget drops the container's retry, if any.
+func (r *RunRetries) Forget(guid string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.forget(guid)
+}
+
+// Retain forgets the retries of containers missing from a listing made at
+// listedAt, as they no longer exist. Retries scheduled since then are kept.
+func (r *RunRetries) Retain(listedAt time.Time, listed map[string]executor.Container) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for guid, retry := range r.retries {
+		if _, found := listed[guid]; !found && retry.scheduledAt.Before(listedAt) {
+			r.forget(guid)
+		}
+	}
+}
+
+func (r *RunRetries) forget(guid string) {
+	if retry, found := r.retries[guid]; found {
+		close(retry.cancel)
+		delete(r.retries, guid)
+	}
+}
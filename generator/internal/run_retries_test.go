@@ -0,0 +1,76 @@
+package internal_test
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/rep/generator/internal"
+	"github.com/pivotal-golang/clock/fakeclock"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RunRetries", func() {
+	var (
+		fakeClock  *fakeclock.FakeClock
+		runRetries *internal.RunRetries
+	)
+
+	BeforeEach(func() {
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		runRetries = internal.NewRunRetries(fakeClock)
+		runRetries.Schedule("some-guid", []string{"attempt 1: boom"}, time.Second)
+	})
+
+	It("reports the container as due once the backoff has passed", func() {
+		fakeClock.Increment(time.Second - time.Millisecond)
+		Consistently(runRetries.Due()).ShouldNot(Receive())
+
+		fakeClock.Increment(time.Millisecond)
+		Eventually(runRetries.Due()).Should(Receive(Equal("some-guid")))
+	})
+
+	It("reports nothing once the retry is forgotten", func() {
+		runRetries.Forget("some-guid")
+
+		fakeClock.Increment(time.Second)
+		Consistently(runRetries.Due()).ShouldNot(Receive())
+	})
+
+	It("reports a rescheduled container only once its latest backoff has passed", func() {
+		runRetries.Schedule("some-guid", []string{"attempt 1: boom", "attempt 2: boom"}, time.Minute)
+
+		fakeClock.Increment(time.Second)
+		Consistently(runRetries.Due()).ShouldNot(Receive())
+
+		fakeClock.Increment(time.Minute)
+		Eventually(runRetries.Due()).Should(Receive(Equal("some-guid")))
+	})
+
+	Describe("Retain", func() {
+		It("forgets the retries of containers no longer listed", func() {
+			fakeClock.Increment(time.Millisecond)
+			runRetries.Retain(fakeClock.Now(), map[string]executor.Container{})
+
+			fakeClock.Increment(time.Second)
+			Consistently(runRetries.Due()).ShouldNot(Receive())
+		})
+
+		It("keeps the retries of containers still listed", func() {
+			fakeClock.Increment(time.Millisecond)
+			runRetries.Retain(fakeClock.Now(), map[string]executor.Container{"some-guid": {Guid: "some-guid"}})
+
+			fakeClock.Increment(time.Second)
+			Eventually(runRetries.Due()).Should(Receive(Equal("some-guid")))
+		})
+
+		It("keeps retries scheduled since the listing was made", func() {
+			listedAt := fakeClock.Now().Add(-time.Millisecond)
+			runRetries.Retain(listedAt, map[string]executor.Container{})
+
+			fakeClock.Increment(time.Second)
+			Eventually(runRetries.Due()).Should(Receive(Equal("some-guid")))
+		})
+	})
+})
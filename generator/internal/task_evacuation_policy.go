@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/rep"
+)
+
+const TaskCompletionReasonEvacuated = "cell evacuated before task completed"
+
+type TaskEvacuationMode string
+
+const (
+	// TaskEvacuationWait lets running tasks complete.
+	TaskEvacuationWait TaskEvacuationMode = "wait"
+
+	// TaskEvacuationFail fails running tasks as soon as evacuation starts.
+	TaskEvacuationFail TaskEvacuationMode = "fail"
+
+	// TaskEvacuationDeadline lets running tasks complete, failing those still
+	// running once their deadline has passed.
+	TaskEvacuationDeadline TaskEvacuationMode = "deadline"
+)
+
+func ParseTaskEvacuationMode(mode string) (TaskEvacuationMode, error) {
+	switch TaskEvacuationMode(mode) {
+	case TaskEvacuationWait, TaskEvacuationFail, TaskEvacuationDeadline:
+		return TaskEvacuationMode(mode), nil
+	default:
+		return "", fmt.Errorf("unknown task evacuation mode: %s", mode)
+	}
+}
+
+// TaskEvacuationPolicy determines what becomes of the tasks running on a cell
+// while it is evacuated. Deadlines are measured from when the task was first
+// seen during evacuation.
+type TaskEvacuationPolicy struct {
+	Mode     TaskEvacuationMode
+	Deadline time.Duration
+	Domains  map[string]time.Duration
+}
+
+func (p TaskEvacuationPolicy) DeadlineFor(container executor.Container) time.Duration {
+	if deadline, ok := p.Domains[container.Tags[rep.DomainTag]]; ok {
+		return deadline
+	}
+
+	return p.Deadline
+}
@@ -1,10 +1,19 @@
 package internal
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/cloudfoundry-incubator/executor"
 	"github.com/cloudfoundry-incubator/rep"
+	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context"
+	"github.com/cloudfoundry-incubator/rep/task_notifier"
 	"github.com/cloudfoundry-incubator/runtime-schema/bbs"
 	"github.com/cloudfoundry-incubator/runtime-schema/bbs/bbserrors"
+	"github.com/pivotal-golang/clock"
 	"github.com/pivotal-golang/lager"
 )
 
@@ -23,13 +32,47 @@ type taskProcessor struct {
 	bbs               bbs.RepBBS
 	containerDelegate ContainerDelegate
 	cellID            string
+	resultFileConfig  ResultFileConfig
+	retryPolicy       RetryPolicy
+	runRetries        *RunRetries
+	notifier          task_notifier.TaskNotifier
+	diagnostics       DiagnosticsCollector
+	clock             clock.Clock
+
+	evacuationReporter evacuation_context.EvacuationReporter
+	evacuationPolicy   TaskEvacuationPolicy
+
+	evacuationLock   sync.Mutex
+	evacuationSeenAt map[string]time.Time
 }
 
-func NewTaskProcessor(bbs bbs.RepBBS, containerDelegate ContainerDelegate, cellID string) TaskProcessor {
+func NewTaskProcessor(
+	bbs bbs.RepBBS,
+	containerDelegate ContainerDelegate,
+	cellID string,
+	resultFileConfig ResultFileConfig,
+	retryPolicy RetryPolicy,
+	runRetries *RunRetries,
+	evacuationReporter evacuation_context.EvacuationReporter,
+	evacuationPolicy TaskEvacuationPolicy,
+	notifier task_notifier.TaskNotifier,
+	diagnostics DiagnosticsCollector,
+	clock clock.Clock,
+) TaskProcessor {
 	return &taskProcessor{
 		bbs:               bbs,
 		containerDelegate: containerDelegate,
 		cellID:            cellID,
+		resultFileConfig:  resultFileConfig,
+		retryPolicy:       retryPolicy,
+		runRetries:        runRetries,
+		notifier:          notifier,
+		diagnostics:       diagnostics,
+		clock:             clock,
+
+		evacuationReporter: evacuationReporter,
+		evacuationPolicy:   evacuationPolicy,
+		evacuationSeenAt:   make(map[string]time.Time),
 	}
 }
 
@@ -42,6 +85,11 @@ func (p *taskProcessor) Process(logger lager.Logger, container executor.Containe
 	logger.Debug("starting")
 	defer logger.Debug("finished")
 
+	if p.evacuationExpired(logger, container) {
+		p.failEvacuatedTask(logger, container)
+		return
+	}
+
 	switch container.State {
 	case executor.StateReserved:
 		logger.Debug("processing-reserved-container")
@@ -62,19 +110,70 @@ func (p *taskProcessor) Process(logger lager.Logger, container executor.Containe
 }
 
 func (p *taskProcessor) processActiveContainer(logger lager.Logger, container executor.Container) {
+	retry, found := p.runRetries.get(container.Guid)
+	if found {
+		p.retryRunningContainer(logger, container, retry)
+		return
+	}
+
 	ok := p.startTask(logger, container.Guid)
 	if !ok {
 		return
 	}
 
-	ok = p.containerDelegate.RunContainer(logger, container.Guid)
-	if !ok {
-		p.failTask(logger, container.Guid, TaskCompletionReasonFailedToRunContainer)
+	p.runContainer(logger, container, nil)
+}
+
+func (p *taskProcessor) retryRunningContainer(logger lager.Logger, container executor.Container, retry runRetry) {
+	if container.State != executor.StateReserved {
+		// an attempt that seemed to fail went through after all
+		p.runRetries.Forget(container.Guid)
+		return
+	}
+
+	if p.clock.Now().Before(retry.retryAt) {
+		logger.Debug("waiting-to-retry-running-container", lager.Data{"retry-at": retry.retryAt})
+		return
+	}
+
+	p.runContainer(logger, container, retry.failedAttempts)
+}
+
+// runContainer makes one attempt to run the container. Should it fail
+// transiently, the next attempt is scheduled after a backoff rather than
+// waited for, so as not to hold up the worker. The container is then
+// processed again once the backoff has passed.
+func (p *taskProcessor) runContainer(logger lager.Logger, container executor.Container, failedAttempts []string) {
+	guid := container.Guid
+	attempt := len(failedAttempts) + 1
+
+	err := p.containerDelegate.TryRunContainer(logger, guid)
+	if err == nil {
+		p.runRetries.Forget(guid)
+		return
+	}
+
+	failedAttempts = append(failedAttempts, fmt.Sprintf("attempt %d: %s", attempt, err.Error()))
+
+	if IsTransientRunError(err) && attempt < p.retryPolicy.maxAttempts() {
+		backoff := p.retryPolicy.backoff(attempt)
+		logger.Info("retrying-running-container", lager.Data{"attempt": attempt, "backoff": backoff.String()})
+		p.runRetries.Schedule(guid, failedAttempts, backoff)
+		return
 	}
+
+	p.runRetries.Forget(guid)
+	p.containerDelegate.DeleteContainer(logger, guid)
+	p.failTask(logger, container, fmt.Sprintf("%s (%s)", TaskCompletionReasonFailedToRunContainer, strings.Join(failedAttempts, "; ")))
 }
 
 func (p *taskProcessor) processCompletedContainer(logger lager.Logger, container executor.Container) {
+	p.runRetries.Forget(container.Guid)
 	p.completeTask(logger, container)
+
+	// collected only once the task is complete, so that slow or large files
+	// never hold up its completion
+	p.diagnostics.Collect(logger, container)
 	p.containerDelegate.DeleteContainer(logger, container.Guid)
 }
 
@@ -106,11 +205,12 @@ func (p *taskProcessor) startTask(logger lager.Logger, guid string) bool {
 
 func (p *taskProcessor) completeTask(logger lager.Logger, container executor.Container) {
 	var result string
+	var resultSize int
 	var err error
 	if !container.RunResult.Failed {
-		result, err = p.containerDelegate.FetchContainerResultFile(logger, container.Guid, container.Tags[rep.ResultFileTag])
+		result, resultSize, err = p.fetchResult(logger, container)
 		if err != nil {
-			p.failTask(logger, container.Guid, TaskCompletionReasonFailedToFetchResult)
+			p.failTask(logger, container, TaskCompletionReasonFailedToFetchResult)
 			return
 		}
 	}
@@ -121,21 +221,138 @@ func (p *taskProcessor) completeTask(logger lager.Logger, container executor.Con
 		logger.Error("failed-completing-task", err)
 
 		if _, ok := err.(bbserrors.TaskStateTransitionError); ok {
-			p.failTask(logger, container.Guid, TaskCompletionReasonInvalidTransition)
+			p.failTask(logger, container, TaskCompletionReasonInvalidTransition)
 		}
 		return
 	}
 
 	logger.Info("succeeded-completing-task")
+
+	p.notifier.Notify(logger, task_notifier.Notification{
+		TaskGuid:      container.Guid,
+		Domain:        container.Tags[rep.DomainTag],
+		Failed:        container.RunResult.Failed,
+		FailureReason: container.RunResult.FailureReason,
+		ResultSize:    resultSize,
+	})
+}
+
+// fetchResult returns the result to write to the BBS, which may be
+// compressed, and its uncompressed size.
+func (p *taskProcessor) fetchResult(logger lager.Logger, container executor.Container) (string, int, error) {
+	policy := p.resultFileConfig.PolicyFor(container.Tags[rep.DomainTag])
+	resultFiles := rep.ParseResultFiles(container.Tags[rep.ResultFileTag])
+
+	results := make(map[string]string, len(resultFiles))
+	for _, resultFile := range resultFiles {
+		result, err := p.containerDelegate.FetchContainerResultFile(logger, container.Guid, resultFile.Path, policy.MaxSize)
+		if err == nil {
+			results[resultFile.Path] = result
+			continue
+		}
+
+		if !resultFile.Optional || err != ErrResultFileNotFound {
+			return "", 0, err
+		}
+
+		logger.Info("skipping-missing-optional-result-file", lager.Data{
+			"result-file": resultFile.Path,
+			"error":       err.Error(),
+		})
+	}
+
+	var result string
+	switch len(resultFiles) {
+	case 0:
+	case 1:
+		result = results[resultFiles[0].Path]
+	default:
+		payload, err := json.Marshal(results)
+		if err != nil {
+			return "", 0, err
+		}
+		result = string(payload)
+	}
+
+	if policy.CompressionThreshold > 0 && len(result) > policy.CompressionThreshold {
+		logger.Info("compressing-result", lager.Data{"size": len(result)})
+		compressed, err := CompressResult(result)
+		return compressed, len(result), err
+	}
+
+	return result, len(result), nil
 }
 
-func (p *taskProcessor) failTask(logger lager.Logger, guid string, reason string) {
+func (p *taskProcessor) failTask(logger lager.Logger, container executor.Container, reason string) {
 	logger.Info("failing-task")
-	err := p.bbs.FailTask(logger, guid, reason)
+	err := p.bbs.FailTask(logger, container.Guid, reason)
 	if err != nil {
 		logger.Error("failed-failing-task", err)
 		return
 	}
 
 	logger.Info("succeeded-failing-task")
+
+	p.notifier.Notify(logger, task_notifier.Notification{
+		TaskGuid:      container.Guid,
+		Domain:        container.Tags[rep.DomainTag],
+		Failed:        true,
+		FailureReason: reason,
+	})
+}
+
+// evacuationExpired reports whether an active task container should be failed
+// rather than left to complete, according to the task evacuation policy.
+func (p *taskProcessor) evacuationExpired(logger lager.Logger, container executor.Container) bool {
+	p.evacuationLock.Lock()
+	defer p.evacuationLock.Unlock()
+
+	if !p.evacuationReporter.Evacuating() {
+		p.evacuationSeenAt = make(map[string]time.Time)
+		return false
+	}
+
+	// Only this container is out of scope; the deadlines of those in scope
+	// keep running.
+	if !p.evacuationReporter.EvacuationScope().MatchesContainer(container) {
+		delete(p.evacuationSeenAt, container.Guid)
+		return false
+	}
+
+	if container.State == executor.StateCompleted {
+		delete(p.evacuationSeenAt, container.Guid)
+		return false
+	}
+
+	switch p.evacuationPolicy.Mode {
+	case TaskEvacuationFail:
+		return true
+
+	case TaskEvacuationDeadline:
+		now := p.clock.Now()
+		seenAt, ok := p.evacuationSeenAt[container.Guid]
+		if !ok {
+			seenAt = now
+			p.evacuationSeenAt[container.Guid] = seenAt
+		}
+
+		deadline := p.evacuationPolicy.DeadlineFor(container)
+		if now.Sub(seenAt) < deadline {
+			logger.Info("waiting-for-task-before-evacuation-deadline", lager.Data{"remaining": seenAt.Add(deadline).Sub(now).String()})
+			return false
+		}
+
+		delete(p.evacuationSeenAt, container.Guid)
+		return true
+
+	default:
+		return false
+	}
+}
+
+func (p *taskProcessor) failEvacuatedTask(logger lager.Logger, container executor.Container) {
+	logger.Info("failing-task-due-to-evacuation", lager.Data{"mode": p.evacuationPolicy.Mode})
+	p.runRetries.Forget(container.Guid)
+	p.failTask(logger, container, TaskCompletionReasonEvacuated)
+	p.containerDelegate.DeleteContainer(logger, container.Guid)
 }
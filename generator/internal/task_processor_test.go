@@ -1,15 +1,26 @@
 package internal_test
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
 	"errors"
+	"io/ioutil"
+	"strings"
+	"time"
 
 	"github.com/cloudfoundry-incubator/executor"
 	"github.com/cloudfoundry-incubator/rep"
+	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context"
+	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context/fake_evacuation_context"
 	"github.com/cloudfoundry-incubator/rep/generator/internal"
 	"github.com/cloudfoundry-incubator/rep/generator/internal/fake_internal"
+	"github.com/cloudfoundry-incubator/rep/task_notifier"
+	"github.com/cloudfoundry-incubator/rep/task_notifier/fake_task_notifier"
 	"github.com/cloudfoundry-incubator/runtime-schema/bbs"
 	"github.com/cloudfoundry-incubator/runtime-schema/models"
 	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/clock/fakeclock"
 	"github.com/pivotal-golang/lager"
 	"github.com/pivotal-golang/lager/lagertest"
 
@@ -21,28 +32,62 @@ const taskGuid = "my-guid"
 
 var processor internal.TaskProcessor
 
-var _ = Describe("Task <-> Container table", func() {
-	var (
-		containerDelegate *fake_internal.FakeContainerDelegate
-	)
+var _ = Describe("TaskProcessor", func() {
 	const (
-		localCellID   = "a"
-		otherCellID   = "w"
-		sessionPrefix = "task-table-test"
+		localCellID = "a"
+		otherCellID = "w"
+	)
+
+	var (
+		containerDelegate  *fake_internal.FakeContainerDelegate
+		resultFileConfig   internal.ResultFileConfig
+		retryPolicy        internal.RetryPolicy
+		runRetries         *internal.RunRetries
+		evacuationReporter *fake_evacuation_context.FakeEvacuationReporter
+		evacuationPolicy   internal.TaskEvacuationPolicy
+		notifier           *fake_task_notifier.FakeTaskNotifier
+		collector          *fake_internal.FakeDiagnosticsCollector
+		fakeClock          *fakeclock.FakeClock
+		logger             *lagertest.TestLogger
 	)
 
 	BeforeEach(func() {
 		etcdRunner.Reset()
 		BBS = bbs.NewBBS(etcdClient, clock.NewClock(), lagertest.NewTestLogger("test-bbs"))
-		containerDelegate = new(fake_internal.FakeContainerDelegate)
-		processor = internal.NewTaskProcessor(BBS, containerDelegate, localCellID)
 
+		containerDelegate = new(fake_internal.FakeContainerDelegate)
 		containerDelegate.DeleteContainerReturns(true)
 		containerDelegate.StopContainerReturns(true)
-		containerDelegate.RunContainerReturns(true)
+		containerDelegate.TryRunContainerReturns(nil)
+
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		resultFileConfig = internal.NewResultFileConfig(internal.MAX_RESULT_SIZE, 0)
+		retryPolicy = internal.RetryPolicy{MaxAttempts: 1}
+		runRetries = internal.NewRunRetries(fakeClock)
+		evacuationReporter = new(fake_evacuation_context.FakeEvacuationReporter)
+		evacuationPolicy = internal.TaskEvacuationPolicy{}
+		notifier = new(fake_task_notifier.FakeTaskNotifier)
+		collector = new(fake_internal.FakeDiagnosticsCollector)
+		logger = lagertest.NewTestLogger("test")
 	})
 
-	itDeletesTheContainer := func(logger *lagertest.TestLogger) {
+	JustBeforeEach(func() {
+		processor = internal.NewTaskProcessor(
+			BBS,
+			containerDelegate,
+			localCellID,
+			resultFileConfig,
+			retryPolicy,
+			runRetries,
+			evacuationReporter,
+			evacuationPolicy,
+			notifier,
+			collector,
+			fakeClock,
+		)
+	})
+
+	itDeletesTheContainer := func() {
 		It("deletes the container", func() {
 			Ω(containerDelegate.DeleteContainerCallCount()).Should(Equal(1))
 			_, containerGuid := containerDelegate.DeleteContainerArgsForCall(0)
@@ -50,344 +95,796 @@ var _ = Describe("Task <-> Container table", func() {
 		})
 	}
 
-	itCompletesTheTaskWithFailure := func(reason string) func(*lagertest.TestLogger) {
-		return func(logger *lagertest.TestLogger) {
-			It("completes the task with failure", func() {
-				task, err := BBS.TaskByGuid(taskGuid)
-				Ω(err).ShouldNot(HaveOccurred())
+	itCompletesTheTaskWithFailure := func(reason string) {
+		It("completes the task with failure", func() {
+			task, err := BBS.TaskByGuid(taskGuid)
+			Ω(err).ShouldNot(HaveOccurred())
 
-				Ω(task.State).Should(Equal(models.TaskStateCompleted))
-				Ω(task.Failed).Should(BeTrue())
-				Ω(task.FailureReason).Should(Equal(reason))
-			})
-		}
+			Ω(task.State).Should(Equal(models.TaskStateCompleted))
+			Ω(task.Failed).Should(BeTrue())
+			Ω(task.FailureReason).Should(Equal(reason))
+		})
 	}
 
-	successfulRunResult := executor.ContainerRunResult{
-		Failed: false,
+	itLeavesTheTaskRunning := func() {
+		It("leaves the task running", func() {
+			task, err := BBS.TaskByGuid(taskGuid)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(task.State).Should(Equal(models.TaskStateRunning))
+			Ω(containerDelegate.DeleteContainerCallCount()).Should(BeZero())
+		})
 	}
 
-	itCompletesTheSuccessfulTaskAndDeletesTheContainer := func(logger *lagertest.TestLogger) {
-		Context("when fetching the result succeeds", func() {
-			BeforeEach(func() {
-				containerDelegate.FetchContainerResultFileReturns("some-result", nil)
+	Describe("Task <-> Container table", func() {
+		const sessionPrefix = "task-table-test"
+
+		deletesTheContainer := func(*lagertest.TestLogger) {
+			itDeletesTheContainer()
+		}
 
-				containerDelegate.DeleteContainerStub = func(logger lager.Logger, guid string) bool {
+		completesTheTaskWithFailure := func(reason string) TaskTest {
+			return func(*lagertest.TestLogger) {
+				itCompletesTheTaskWithFailure(reason)
+			}
+		}
+
+		successfulRunResult := executor.ContainerRunResult{
+			Failed: false,
+		}
+
+		itCompletesTheSuccessfulTaskAndDeletesTheContainer := func(logger *lagertest.TestLogger) {
+			Context("when fetching the result succeeds", func() {
+				BeforeEach(func() {
+					containerDelegate.FetchContainerResultFileReturns("some-result", nil)
+
+					containerDelegate.DeleteContainerStub = func(logger lager.Logger, guid string) bool {
+						task, err := BBS.TaskByGuid(taskGuid)
+						Ω(err).ShouldNot(HaveOccurred())
+
+						Ω(task.State).Should(Equal(models.TaskStateCompleted))
+
+						return true
+					}
+				})
+
+				It("completes the task with the result", func() {
 					task, err := BBS.TaskByGuid(taskGuid)
 					Ω(err).ShouldNot(HaveOccurred())
 
-					Ω(task.State).Should(Equal(models.TaskStateCompleted))
+					Ω(task.Failed).Should(BeFalse())
 
-					return true
-				}
+					_, guid, filename, maxSize := containerDelegate.FetchContainerResultFileArgsForCall(0)
+					Ω(guid).Should(Equal(taskGuid))
+					Ω(filename).Should(Equal("some-result-filename"))
+					Ω(maxSize).Should(Equal(internal.MAX_RESULT_SIZE))
+					Ω(task.Result).Should(Equal("some-result"))
+				})
+
+				itDeletesTheContainer()
 			})
 
-			It("completes the task with the result", func() {
+			Context("when fetching the result fails", func() {
+				disaster := errors.New("nope")
+
+				BeforeEach(func() {
+					containerDelegate.FetchContainerResultFileReturns("", disaster)
+				})
+
+				itCompletesTheTaskWithFailure("failed to fetch result")
+
+				itDeletesTheContainer()
+			})
+		}
+
+		failedRunResult := executor.ContainerRunResult{
+			Failed:        true,
+			FailureReason: "because",
+		}
+
+		itCompletesTheFailedTaskAndDeletesTheContainer := func(logger *lagertest.TestLogger) {
+			It("does not attempt to fetch the result", func() {
+				Ω(containerDelegate.FetchContainerResultFileCallCount()).Should(BeZero())
+			})
+
+			itCompletesTheTaskWithFailure("because")
+
+			itDeletesTheContainer()
+		}
+
+		itSetsTheTaskToRunning := func(logger *lagertest.TestLogger) {
+			It("transitions the task to the running state", func() {
 				task, err := BBS.TaskByGuid(taskGuid)
 				Ω(err).ShouldNot(HaveOccurred())
 
-				Ω(task.Failed).Should(BeFalse())
+				Ω(task.State).Should(Equal(models.TaskStateRunning))
+			})
+		}
+
+		itRunsTheContainer := func(logger *lagertest.TestLogger) {
+			itSetsTheTaskToRunning(logger)
+
+			It("runs the container", func() {
+				Ω(containerDelegate.TryRunContainerCallCount()).Should(Equal(1))
+				_, containerGuid := containerDelegate.TryRunContainerArgsForCall(0)
+				Ω(containerGuid).Should(Equal(taskGuid))
+			})
+
+			Context("when running the container fails", func() {
+				BeforeEach(func() {
+					containerDelegate.TryRunContainerReturns(errors.New("nope"))
+				})
+
+				itCompletesTheTaskWithFailure("failed to run container (attempt 1: nope)")
+
+				itDeletesTheContainer()
+			})
+		}
+
+		itDoesNothing := func(logger *lagertest.TestLogger) {
+			It("does not run the container", func() {
+				Ω(containerDelegate.TryRunContainerCallCount()).Should(Equal(0))
+			})
 
-				_, guid, filename := containerDelegate.FetchContainerResultFileArgsForCall(0)
-				Ω(guid).Should(Equal(taskGuid))
-				Ω(filename).Should(Equal("some-result-filename"))
-				Ω(task.Result).Should(Equal("some-result"))
+			It("does not stop the container", func() {
+				Ω(containerDelegate.StopContainerCallCount()).Should(Equal(0))
 			})
 
-			itDeletesTheContainer(logger)
+			It("does not delete the container", func() {
+				Ω(containerDelegate.DeleteContainerCallCount()).Should(Equal(0))
+			})
+		}
+
+		table := TaskTable{
+			LocalCellID: localCellID,
+			Logger:      lagertest.NewTestLogger(sessionPrefix),
+			Rows: []Row{
+				// container reserved
+				ConceivableTaskScenario( // task deleted? (operator/etcd?)
+					NewContainer(executor.StateReserved),
+					nil,
+					deletesTheContainer,
+				),
+				ExpectedTaskScenario( // container is reserved for a pending container
+					NewContainer(executor.StateReserved),
+					NewTask("", models.TaskStatePending),
+					itRunsTheContainer,
+				),
+				ExpectedTaskScenario( // task is started before we run the container. it should eventually transition to initializing or be reaped if things really go wrong.
+					NewContainer(executor.StateReserved),
+					NewTask("a", models.TaskStateRunning),
+					itDoesNothing,
+				),
+				ConceivableTaskScenario( // maybe the rep reserved the container and failed to report success back to the auctioneer
+					NewContainer(executor.StateReserved),
+					NewTask("w", models.TaskStateRunning),
+					deletesTheContainer,
+				),
+				ConceivableTaskScenario( // if the Run call to the executor fails we complete the task with failure, and try to remove the reservation, but there's a time window.
+					NewContainer(executor.StateReserved),
+					NewTask("a", models.TaskStateCompleted),
+					deletesTheContainer,
+				),
+				ConceivableTaskScenario( // maybe the rep reserved the container and failed to report success back to the auctioneer
+					NewContainer(executor.StateReserved),
+					NewTask("w", models.TaskStateCompleted),
+					deletesTheContainer,
+				),
+				ConceivableTaskScenario( // caller is processing failure from Run call
+					NewContainer(executor.StateReserved),
+					NewTask("a", models.TaskStateResolving),
+					deletesTheContainer,
+				),
+				ConceivableTaskScenario( // maybe the rep reserved the container and failed to report success back to the auctioneer
+					NewContainer(executor.StateReserved),
+					NewTask("w", models.TaskStateResolving),
+					deletesTheContainer,
+				),
+
+				// container initializing
+				ConceivableTaskScenario( // task deleted? (operator/etcd?)
+					NewContainer(executor.StateInitializing),
+					nil,
+					deletesTheContainer,
+				),
+				InconceivableTaskScenario( // task should be started before anyone tries to run
+					NewContainer(executor.StateInitializing),
+					NewTask("", models.TaskStatePending),
+					itRunsTheContainer,
+				),
+				ExpectedTaskScenario( // task is running throughout initializing, completed, and running
+					NewContainer(executor.StateInitializing),
+					NewTask("a", models.TaskStateRunning),
+					itDoesNothing,
+				),
+				InconceivableTaskScenario( // state machine borked? no other cell should get this far.
+					NewContainer(executor.StateInitializing),
+					NewTask("w", models.TaskStateRunning),
+					deletesTheContainer,
+				),
+				ConceivableTaskScenario( // task was cancelled
+					NewContainer(executor.StateInitializing),
+					NewTask("a", models.TaskStateCompleted),
+					deletesTheContainer,
+				),
+				InconceivableTaskScenario( // state machine borked? no other cell should get this far.
+					NewContainer(executor.StateInitializing),
+					NewTask("w", models.TaskStateCompleted),
+					deletesTheContainer,
+				),
+				ConceivableTaskScenario( // task was cancelled
+					NewContainer(executor.StateInitializing),
+					NewTask("a", models.TaskStateResolving),
+					deletesTheContainer,
+				),
+				InconceivableTaskScenario( // state machine borked? no other cell should get this far.
+					NewContainer(executor.StateInitializing),
+					NewTask("w", models.TaskStateResolving),
+					deletesTheContainer,
+				),
+
+				// container created
+				ConceivableTaskScenario( // task deleted? (operator/etcd?)
+					NewContainer(executor.StateCreated),
+					nil,
+					deletesTheContainer,
+				),
+				InconceivableTaskScenario( // task should be started before anyone tries to run
+					NewContainer(executor.StateCreated),
+					NewTask("", models.TaskStatePending),
+					itSetsTheTaskToRunning,
+				),
+				ExpectedTaskScenario( // task is running throughout initializing, completed, and running
+					NewContainer(executor.StateCreated),
+					NewTask("a", models.TaskStateRunning),
+					itDoesNothing,
+				),
+				InconceivableTaskScenario( // state machine borked? no other cell should get this far.
+					NewContainer(executor.StateCreated),
+					NewTask("w", models.TaskStateRunning),
+					deletesTheContainer,
+				),
+				ConceivableTaskScenario( // task was cancelled
+					NewContainer(executor.StateCreated),
+					NewTask("a", models.TaskStateCompleted),
+					deletesTheContainer,
+				),
+				InconceivableTaskScenario( // state machine borked? no other cell should get this far.
+					NewContainer(executor.StateCreated),
+					NewTask("w", models.TaskStateCompleted),
+					deletesTheContainer,
+				),
+				ConceivableTaskScenario( // task was cancelled
+					NewContainer(executor.StateCreated),
+					NewTask("a", models.TaskStateResolving),
+					deletesTheContainer,
+				),
+				InconceivableTaskScenario( // state machine borked? no other cell should get this far.
+					NewContainer(executor.StateCreated),
+					NewTask("w", models.TaskStateResolving),
+					deletesTheContainer,
+				),
+
+				// container running
+				ConceivableTaskScenario( // task deleted? (operator/etcd?)
+					NewContainer(executor.StateRunning),
+					nil,
+					deletesTheContainer,
+				),
+				InconceivableTaskScenario( // task should be started before anyone tries to run
+					NewContainer(executor.StateRunning),
+					NewTask("", models.TaskStatePending),
+					itSetsTheTaskToRunning,
+				),
+				ExpectedTaskScenario( // task is running throughout initializing, completed, and running
+					NewContainer(executor.StateRunning),
+					NewTask("a", models.TaskStateRunning),
+					itDoesNothing,
+				),
+				InconceivableTaskScenario( // state machine borked? no other cell should get this far.
+					NewContainer(executor.StateRunning),
+					NewTask("w", models.TaskStateRunning),
+					deletesTheContainer,
+				),
+				ConceivableTaskScenario( // task was cancelled
+					NewContainer(executor.StateRunning),
+					NewTask("a", models.TaskStateCompleted),
+					deletesTheContainer,
+				),
+				InconceivableTaskScenario( // state machine borked? no other cell should get this far.
+					NewContainer(executor.StateRunning),
+					NewTask("w", models.TaskStateCompleted),
+					deletesTheContainer,
+				),
+				ConceivableTaskScenario( // task was cancelled
+					NewContainer(executor.StateRunning),
+					NewTask("a", models.TaskStateResolving),
+					deletesTheContainer,
+				),
+				InconceivableTaskScenario( // state machine borked? no other cell should get this far.
+					NewContainer(executor.StateRunning),
+					NewTask("w", models.TaskStateResolving),
+					deletesTheContainer,
+				),
+
+				// container completed
+				ConceivableTaskScenario( // task deleted? (operator/etcd?)
+					NewCompletedContainer(failedRunResult),
+					nil,
+					deletesTheContainer,
+				),
+				InconceivableTaskScenario( // task should be walked through lifecycle by the time we get here
+					NewCompletedContainer(failedRunResult),
+					NewTask("", models.TaskStatePending),
+					completesTheTaskWithFailure("invalid state transition"),
+				),
+				ExpectedTaskScenario( // container completed and failed; complete the task with its failure reason
+					NewCompletedContainer(failedRunResult),
+					NewTask("a", models.TaskStateRunning),
+					itCompletesTheFailedTaskAndDeletesTheContainer,
+				),
+				ExpectedTaskScenario( // container completed and succeeded; complete the task with its result
+					NewCompletedContainer(successfulRunResult),
+					NewTask("a", models.TaskStateRunning),
+					itCompletesTheSuccessfulTaskAndDeletesTheContainer,
+				),
+				InconceivableTaskScenario( // state machine borked? no other cell should get this far.
+					NewCompletedContainer(failedRunResult),
+					NewTask("w", models.TaskStateRunning),
+					deletesTheContainer,
+				),
+				ConceivableTaskScenario( // may have completed the task and then failed to delete the container
+					NewCompletedContainer(failedRunResult),
+					NewTask("a", models.TaskStateCompleted),
+					deletesTheContainer,
+				),
+				InconceivableTaskScenario( // state machine borked? no other cell should get this far.
+					NewCompletedContainer(failedRunResult),
+					NewTask("w", models.TaskStateCompleted),
+					deletesTheContainer,
+				),
+				ConceivableTaskScenario( // may have completed the task and then failed to delete the container, and someone started processing the completion
+					NewCompletedContainer(failedRunResult),
+					NewTask("a", models.TaskStateResolving),
+					deletesTheContainer,
+				),
+				InconceivableTaskScenario( // state machine borked? no other cell should get this far.
+					NewCompletedContainer(failedRunResult),
+					NewTask("w", models.TaskStateResolving),
+					deletesTheContainer,
+				),
+			},
+		}
+
+		table.Test()
+	})
+
+	Describe("container run retries", func() {
+		const backoff = time.Second
+
+		var runErrors []error
+
+		BeforeEach(func() {
+			retryPolicy = internal.RetryPolicy{MaxAttempts: 3, Backoff: backoff}
+
+			runErrors = nil
+			containerDelegate.TryRunContainerStub = func(lager.Logger, string) error {
+				attempt := containerDelegate.TryRunContainerCallCount() - 1
+				if attempt < len(runErrors) {
+					return runErrors[attempt]
+				}
+				return nil
+			}
+
+			walkToState(logger, BBS, *NewTask("", models.TaskStatePending))
 		})
 
-		Context("when fetching the result fails", func() {
-			disaster := errors.New("nope")
+		JustBeforeEach(func() {
+			processor.Process(logger, NewContainer(executor.StateReserved))
+		})
 
+		Context("when running the container fails transiently and then succeeds", func() {
 			BeforeEach(func() {
-				containerDelegate.FetchContainerResultFileReturns("", disaster)
+				runErrors = []error{errors.New("connection refused")}
+			})
+
+			It("does not wait for the backoff before returning", func() {
+				Ω(containerDelegate.TryRunContainerCallCount()).Should(Equal(1))
+			})
+
+			itLeavesTheTaskRunning()
+
+			It("reports the container as due once the backoff has elapsed", func() {
+				Consistently(runRetries.Due()).ShouldNot(Receive())
+
+				fakeClock.Increment(backoff)
+				Eventually(runRetries.Due()).Should(Receive(Equal(taskGuid)))
 			})
 
-			itCompletesTheTaskWithFailure("failed to fetch result")(logger)
+			Context("when the container is processed again before the backoff has elapsed", func() {
+				It("does not retry yet", func() {
+					fakeClock.Increment(backoff - time.Millisecond)
+					processor.Process(logger, NewContainer(executor.StateReserved))
+					Ω(containerDelegate.TryRunContainerCallCount()).Should(Equal(1))
+				})
+			})
 
-			itDeletesTheContainer(logger)
+			Context("when the container is processed again once the backoff has elapsed", func() {
+				JustBeforeEach(func() {
+					fakeClock.Increment(backoff)
+					processor.Process(logger, NewContainer(executor.StateReserved))
+				})
+
+				It("retries running the container", func() {
+					Ω(containerDelegate.TryRunContainerCallCount()).Should(Equal(2))
+				})
+
+				It("does not run it again once it has started", func() {
+					fakeClock.Increment(time.Minute)
+					processor.Process(logger, NewContainer(executor.StateRunning))
+					Ω(containerDelegate.TryRunContainerCallCount()).Should(Equal(2))
+				})
+			})
 		})
-	}
 
-	failedRunResult := executor.ContainerRunResult{
-		Failed:        true,
-		FailureReason: "because",
-	}
+		Context("when running the container keeps failing transiently", func() {
+			BeforeEach(func() {
+				runErrors = []error{errors.New("connection refused"), errors.New("timeout"), errors.New("connection reset")}
+			})
+
+			JustBeforeEach(func() {
+				fakeClock.Increment(backoff)
+				processor.Process(logger, NewContainer(executor.StateReserved))
+				fakeClock.Increment(2 * backoff)
+				processor.Process(logger, NewContainer(executor.StateReserved))
+			})
+
+			It("gives up after the maximum number of attempts", func() {
+				Ω(containerDelegate.TryRunContainerCallCount()).Should(Equal(3))
+			})
+
+			itCompletesTheTaskWithFailure("failed to run container (attempt 1: connection refused; attempt 2: timeout; attempt 3: connection reset)")
 
-	itCompletesTheFailedTaskAndDeletesTheContainer := func(logger *lagertest.TestLogger) {
-		It("does not attempt to fetch the result", func() {
-			Ω(containerDelegate.FetchContainerResultFileCallCount()).Should(BeZero())
+			itDeletesTheContainer()
 		})
 
-		itCompletesTheTaskWithFailure("because")(logger)
+		Context("when running the container fails permanently", func() {
+			BeforeEach(func() {
+				runErrors = []error{executor.ErrStepsInvalid}
+			})
 
-		itDeletesTheContainer(logger)
-	}
+			It("does not retry", func() {
+				fakeClock.Increment(time.Minute)
+				Consistently(runRetries.Due()).ShouldNot(Receive())
 
-	itSetsTheTaskToRunning := func(logger *lagertest.TestLogger) {
-		It("transitions the task to the running state", func() {
-			task, err := BBS.TaskByGuid(taskGuid)
-			Ω(err).ShouldNot(HaveOccurred())
+				processor.Process(logger, NewContainer(executor.StateReserved))
+				Ω(containerDelegate.TryRunContainerCallCount()).Should(Equal(1))
+			})
 
-			Ω(task.State).Should(Equal(models.TaskStateRunning))
+			It("fails the task", func() {
+				task, err := BBS.TaskByGuid(taskGuid)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(task.Failed).Should(BeTrue())
+				Ω(task.FailureReason).Should(HavePrefix(internal.TaskCompletionReasonFailedToRunContainer))
+			})
 		})
-	}
+	})
 
-	itRunsTheContainer := func(logger *lagertest.TestLogger) {
-		itSetsTheTaskToRunning(logger)
+	Describe("completed containers", func() {
+		var container executor.Container
 
-		It("runs the container", func() {
-			Ω(containerDelegate.RunContainerCallCount()).Should(Equal(1))
-			_, containerGuid := containerDelegate.RunContainerArgsForCall(0)
-			Ω(containerGuid).Should(Equal(taskGuid))
+		BeforeEach(func() {
+			walkToState(logger, BBS, *NewTask(localCellID, models.TaskStateRunning))
+
+			container = NewCompletedContainer(executor.ContainerRunResult{})
+			container.Tags[rep.DomainTag] = "domain"
+		})
+
+		JustBeforeEach(func() {
+			processor.Process(logger, container)
 		})
 
-		Context("when running the container fails", func() {
+		Describe("result files", func() {
+			itCompletesTheTaskWithResult := func(result string) {
+				It("completes the task with the result", func() {
+					task, err := BBS.TaskByGuid(taskGuid)
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(task.Failed).Should(BeFalse())
+					Ω(task.Result).Should(Equal(result))
+				})
+			}
+
+			Context("when the domain overrides the maximum result size", func() {
+				BeforeEach(func() {
+					resultFileConfig.Domains["domain"] = internal.ResultFilePolicy{MaxSize: 42}
+					containerDelegate.FetchContainerResultFileReturns("some-result", nil)
+				})
+
+				It("fetches the result with the domain's limit", func() {
+					Ω(containerDelegate.FetchContainerResultFileCallCount()).Should(Equal(1))
+					_, _, _, maxSize := containerDelegate.FetchContainerResultFileArgsForCall(0)
+					Ω(maxSize).Should(Equal(42))
+				})
+			})
+
+			Context("when the task has multiple result files", func() {
+				BeforeEach(func() {
+					container.Tags[rep.ResultFileTag] = "/tmp/a,/tmp/b"
+					containerDelegate.FetchContainerResultFileStub = func(_ lager.Logger, _ string, filename string, _ int) (string, error) {
+						return "contents of " + filename, nil
+					}
+				})
+
+				itCompletesTheTaskWithResult(`{"/tmp/a":"contents of /tmp/a","/tmp/b":"contents of /tmp/b"}`)
+			})
+
+			for _, example := range []struct {
+				description string
+				resultFile  string
+				fetchErr    error
+				failed      bool
+			}{
+				{"a required result file is missing", "/tmp/result", internal.ErrResultFileNotFound, true},
+				{"an optional result file is missing", "?/tmp/result", internal.ErrResultFileNotFound, false},
+				{"an optional result file is too large", "?/tmp/result", internal.ErrResultFileTooLarge, true},
+				{"fetching an optional result file fails for another reason", "?/tmp/result", errors.New("connection reset"), true},
+			} {
+				example := example
+
+				Context("when "+example.description, func() {
+					BeforeEach(func() {
+						container.Tags[rep.ResultFileTag] = example.resultFile
+						containerDelegate.FetchContainerResultFileReturns("", example.fetchErr)
+					})
+
+					if example.failed {
+						itCompletesTheTaskWithFailure(internal.TaskCompletionReasonFailedToFetchResult)
+					} else {
+						itCompletesTheTaskWithResult("")
+					}
+				})
+			}
+
+			Context("when the result exceeds the compression threshold", func() {
+				var result string
+
+				BeforeEach(func() {
+					result = strings.Repeat("x", 100)
+					resultFileConfig.Default.CompressionThreshold = 10
+					containerDelegate.FetchContainerResultFileReturns(result, nil)
+				})
+
+				It("writes the compressed result", func() {
+					task, err := BBS.TaskByGuid(taskGuid)
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(task.Result).Should(HavePrefix(internal.CompressedResultPrefix))
+
+					compressed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(task.Result, internal.CompressedResultPrefix))
+					Ω(err).ShouldNot(HaveOccurred())
+
+					reader, err := gzip.NewReader(bytes.NewReader(compressed))
+					Ω(err).ShouldNot(HaveOccurred())
+
+					decompressed, err := ioutil.ReadAll(reader)
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(string(decompressed)).Should(Equal(result))
+				})
+			})
+		})
+
+		Describe("completion notifications", func() {
 			BeforeEach(func() {
-				containerDelegate.RunContainerReturns(false)
+				container.Tags[rep.ResultFileTag] = "/tmp/result"
+			})
+
+			Context("when the task completes", func() {
+				BeforeEach(func() {
+					containerDelegate.FetchContainerResultFileReturns("some-result", nil)
+				})
+
+				It("notifies of the completion", func() {
+					Ω(notifier.NotifyCallCount()).Should(Equal(1))
+					_, notification := notifier.NotifyArgsForCall(0)
+					Ω(notification).Should(Equal(task_notifier.Notification{
+						TaskGuid:   taskGuid,
+						Domain:     "domain",
+						ResultSize: len("some-result"),
+					}))
+				})
+
+				Context("when the result is compressed", func() {
+					BeforeEach(func() {
+						resultFileConfig.Default.CompressionThreshold = 5
+					})
+
+					It("reports the size of the uncompressed result", func() {
+						Ω(notifier.NotifyCallCount()).Should(Equal(1))
+						_, notification := notifier.NotifyArgsForCall(0)
+						Ω(notification.ResultSize).Should(Equal(len("some-result")))
+					})
+				})
 			})
 
-			itCompletesTheTaskWithFailure("failed to run container")(logger)
+			Context("when the task fails", func() {
+				BeforeEach(func() {
+					containerDelegate.FetchContainerResultFileReturns("", errors.New("nope"))
+				})
+
+				It("notifies of the failure", func() {
+					Ω(notifier.NotifyCallCount()).Should(Equal(1))
+					_, notification := notifier.NotifyArgsForCall(0)
+					Ω(notification).Should(Equal(task_notifier.Notification{
+						TaskGuid:      taskGuid,
+						Domain:        "domain",
+						Failed:        true,
+						FailureReason: internal.TaskCompletionReasonFailedToFetchResult,
+					}))
+				})
+			})
+
+			Context("when completing the task in the BBS fails", func() {
+				BeforeEach(func() {
+					etcdRunner.Reset()
+				})
+
+				It("does not notify", func() {
+					Ω(notifier.NotifyCallCount()).Should(Equal(0))
+				})
+			})
 		})
-	}
 
-	itDoesNothing := func(logger *lagertest.TestLogger) {
-		It("does not run the container", func() {
-			Ω(containerDelegate.RunContainerCallCount()).Should(Equal(0))
+		Describe("failure diagnostics", func() {
+			var collectedFirst, completedFirst bool
+
+			BeforeEach(func() {
+				collectedFirst = false
+				completedFirst = false
+				collector.CollectStub = func(lager.Logger, executor.Container) {
+					task, err := BBS.TaskByGuid(taskGuid)
+					completedFirst = err == nil && task.State == models.TaskStateCompleted
+				}
+				containerDelegate.DeleteContainerStub = func(lager.Logger, string) bool {
+					collectedFirst = collector.CollectCallCount() == 1
+					return true
+				}
+
+				container.RunResult = executor.ContainerRunResult{Failed: true, FailureReason: "boom"}
+				container.Tags[rep.DiagnosticFilesTag] = "/tmp/crash.log"
+			})
+
+			It("collects diagnostics from the completed container", func() {
+				Ω(collector.CollectCallCount()).Should(Equal(1))
+				_, collected := collector.CollectArgsForCall(0)
+				Ω(collected).Should(Equal(container))
+			})
+
+			It("collects diagnostics before deleting the container", func() {
+				Ω(containerDelegate.DeleteContainerCallCount()).Should(Equal(1))
+				Ω(collectedFirst).Should(BeTrue())
+			})
+
+			It("completes the task before collecting diagnostics", func() {
+				Ω(collector.CollectCallCount()).Should(Equal(1))
+				Ω(completedFirst).Should(BeTrue())
+			})
 		})
+	})
+
+	Describe("evacuation policy", func() {
+		var container executor.Container
+
+		BeforeEach(func() {
+			evacuationReporter.EvacuatingReturns(true)
 
-		It("does not stop the container", func() {
-			Ω(containerDelegate.StopContainerCallCount()).Should(Equal(0))
+			walkToState(logger, BBS, *NewTask(localCellID, models.TaskStateRunning))
+
+			container = NewContainer(executor.StateRunning)
+			container.Tags[rep.DomainTag] = "domain"
 		})
 
-		It("does not delete the container", func() {
-			Ω(containerDelegate.DeleteContainerCallCount()).Should(Equal(0))
+		JustBeforeEach(func() {
+			processor.Process(logger, container)
 		})
-	}
 
-	table := TaskTable{
-		LocalCellID: localCellID,
-		Logger:      lagertest.NewTestLogger(sessionPrefix),
-		Rows: []Row{
-			// container reserved
-			ConceivableTaskScenario( // task deleted? (operator/etcd?)
-				NewContainer(executor.StateReserved),
-				nil,
-				itDeletesTheContainer,
-			),
-			ExpectedTaskScenario( // container is reserved for a pending container
-				NewContainer(executor.StateReserved),
-				NewTask("", models.TaskStatePending),
-				itRunsTheContainer,
-			),
-			ExpectedTaskScenario( // task is started before we run the container. it should eventually transition to initializing or be reaped if things really go wrong.
-				NewContainer(executor.StateReserved),
-				NewTask("a", models.TaskStateRunning),
-				itDoesNothing,
-			),
-			ConceivableTaskScenario( // maybe the rep reserved the container and failed to report success back to the auctioneer
-				NewContainer(executor.StateReserved),
-				NewTask("w", models.TaskStateRunning),
-				itDeletesTheContainer,
-			),
-			ConceivableTaskScenario( // if the Run call to the executor fails we complete the task with failure, and try to remove the reservation, but there's a time window.
-				NewContainer(executor.StateReserved),
-				NewTask("a", models.TaskStateCompleted),
-				itDeletesTheContainer,
-			),
-			ConceivableTaskScenario( // maybe the rep reserved the container and failed to report success back to the auctioneer
-				NewContainer(executor.StateReserved),
-				NewTask("w", models.TaskStateCompleted),
-				itDeletesTheContainer,
-			),
-			ConceivableTaskScenario( // caller is processing failure from Run call
-				NewContainer(executor.StateReserved),
-				NewTask("a", models.TaskStateResolving),
-				itDeletesTheContainer,
-			),
-			ConceivableTaskScenario( // maybe the rep reserved the container and failed to report success back to the auctioneer
-				NewContainer(executor.StateReserved),
-				NewTask("w", models.TaskStateResolving),
-				itDeletesTheContainer,
-			),
-
-			// container initializing
-			ConceivableTaskScenario( // task deleted? (operator/etcd?)
-				NewContainer(executor.StateInitializing),
-				nil,
-				itDeletesTheContainer,
-			),
-			InconceivableTaskScenario( // task should be started before anyone tries to run
-				NewContainer(executor.StateInitializing),
-				NewTask("", models.TaskStatePending),
-				itRunsTheContainer,
-			),
-			ExpectedTaskScenario( // task is running throughout initializing, completed, and running
-				NewContainer(executor.StateInitializing),
-				NewTask("a", models.TaskStateRunning),
-				itDoesNothing,
-			),
-			InconceivableTaskScenario( // state machine borked? no other cell should get this far.
-				NewContainer(executor.StateInitializing),
-				NewTask("w", models.TaskStateRunning),
-				itDeletesTheContainer,
-			),
-			ConceivableTaskScenario( // task was cancelled
-				NewContainer(executor.StateInitializing),
-				NewTask("a", models.TaskStateCompleted),
-				itDeletesTheContainer,
-			),
-			InconceivableTaskScenario( // state machine borked? no other cell should get this far.
-				NewContainer(executor.StateInitializing),
-				NewTask("w", models.TaskStateCompleted),
-				itDeletesTheContainer,
-			),
-			ConceivableTaskScenario( // task was cancelled
-				NewContainer(executor.StateInitializing),
-				NewTask("a", models.TaskStateResolving),
-				itDeletesTheContainer,
-			),
-			InconceivableTaskScenario( // state machine borked? no other cell should get this far.
-				NewContainer(executor.StateInitializing),
-				NewTask("w", models.TaskStateResolving),
-				itDeletesTheContainer,
-			),
-
-			// container created
-			ConceivableTaskScenario( // task deleted? (operator/etcd?)
-				NewContainer(executor.StateCreated),
-				nil,
-				itDeletesTheContainer,
-			),
-			InconceivableTaskScenario( // task should be started before anyone tries to run
-				NewContainer(executor.StateCreated),
-				NewTask("", models.TaskStatePending),
-				itSetsTheTaskToRunning,
-			),
-			ExpectedTaskScenario( // task is running throughout initializing, completed, and running
-				NewContainer(executor.StateCreated),
-				NewTask("a", models.TaskStateRunning),
-				itDoesNothing,
-			),
-			InconceivableTaskScenario( // state machine borked? no other cell should get this far.
-				NewContainer(executor.StateCreated),
-				NewTask("w", models.TaskStateRunning),
-				itDeletesTheContainer,
-			),
-			ConceivableTaskScenario( // task was cancelled
-				NewContainer(executor.StateCreated),
-				NewTask("a", models.TaskStateCompleted),
-				itDeletesTheContainer,
-			),
-			InconceivableTaskScenario( // state machine borked? no other cell should get this far.
-				NewContainer(executor.StateCreated),
-				NewTask("w", models.TaskStateCompleted),
-				itDeletesTheContainer,
-			),
-			ConceivableTaskScenario( // task was cancelled
-				NewContainer(executor.StateCreated),
-				NewTask("a", models.TaskStateResolving),
-				itDeletesTheContainer,
-			),
-			InconceivableTaskScenario( // state machine borked? no other cell should get this far.
-				NewContainer(executor.StateCreated),
-				NewTask("w", models.TaskStateResolving),
-				itDeletesTheContainer,
-			),
-
-			// container running
-			ConceivableTaskScenario( // task deleted? (operator/etcd?)
-				NewContainer(executor.StateRunning),
-				nil,
-				itDeletesTheContainer,
-			),
-			InconceivableTaskScenario( // task should be started before anyone tries to run
-				NewContainer(executor.StateRunning),
-				NewTask("", models.TaskStatePending),
-				itSetsTheTaskToRunning,
-			),
-			ExpectedTaskScenario( // task is running throughout initializing, completed, and running
-				NewContainer(executor.StateRunning),
-				NewTask("a", models.TaskStateRunning),
-				itDoesNothing,
-			),
-			InconceivableTaskScenario( // state machine borked? no other cell should get this far.
-				NewContainer(executor.StateRunning),
-				NewTask("w", models.TaskStateRunning),
-				itDeletesTheContainer,
-			),
-			ConceivableTaskScenario( // task was cancelled
-				NewContainer(executor.StateRunning),
-				NewTask("a", models.TaskStateCompleted),
-				itDeletesTheContainer,
-			),
-			InconceivableTaskScenario( // state machine borked? no other cell should get this far.
-				NewContainer(executor.StateRunning),
-				NewTask("w", models.TaskStateCompleted),
-				itDeletesTheContainer,
-			),
-			ConceivableTaskScenario( // task was cancelled
-				NewContainer(executor.StateRunning),
-				NewTask("a", models.TaskStateResolving),
-				itDeletesTheContainer,
-			),
-			InconceivableTaskScenario( // state machine borked? no other cell should get this far.
-				NewContainer(executor.StateRunning),
-				NewTask("w", models.TaskStateResolving),
-				itDeletesTheContainer,
-			),
-
-			// container completed
-			ConceivableTaskScenario( // task deleted? (operator/etcd?)
-				NewCompletedContainer(failedRunResult),
-				nil,
-				itDeletesTheContainer,
-			),
-			InconceivableTaskScenario( // task should be walked through lifecycle by the time we get here
-				NewCompletedContainer(failedRunResult),
-				NewTask("", models.TaskStatePending),
-				itCompletesTheTaskWithFailure("invalid state transition"),
-			),
-			ExpectedTaskScenario( // container completed and failed; complete the task with its failure reason
-				NewCompletedContainer(failedRunResult),
-				NewTask("a", models.TaskStateRunning),
-				itCompletesTheFailedTaskAndDeletesTheContainer,
-			),
-			ExpectedTaskScenario( // container completed and succeeded; complete the task with its result
-				NewCompletedContainer(successfulRunResult),
-				NewTask("a", models.TaskStateRunning),
-				itCompletesTheSuccessfulTaskAndDeletesTheContainer,
-			),
-			InconceivableTaskScenario( // state machine borked? no other cell should get this far.
-				NewCompletedContainer(failedRunResult),
-				NewTask("w", models.TaskStateRunning),
-				itDeletesTheContainer,
-			),
-			ConceivableTaskScenario( // may have completed the task and then failed to delete the container
-				NewCompletedContainer(failedRunResult),
-				NewTask("a", models.TaskStateCompleted),
-				itDeletesTheContainer,
-			),
-			InconceivableTaskScenario( // state machine borked? no other cell should get this far.
-				NewCompletedContainer(failedRunResult),
-				NewTask("w", models.TaskStateCompleted),
-				itDeletesTheContainer,
-			),
-			ConceivableTaskScenario( // may have completed the task and then failed to delete the container, and someone started processing the completion
-				NewCompletedContainer(failedRunResult),
-				NewTask("a", models.TaskStateResolving),
-				itDeletesTheContainer,
-			),
-			InconceivableTaskScenario( // state machine borked? no other cell should get this far.
-				NewCompletedContainer(failedRunResult),
-				NewTask("w", models.TaskStateResolving),
-				itDeletesTheContainer,
-			),
-		},
-	}
+		itFailsTheTaskAsEvacuated := func() {
+			task, err := BBS.TaskByGuid(taskGuid)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(task.State).Should(Equal(models.TaskStateCompleted))
+			Ω(task.Failed).Should(BeTrue())
+			Ω(task.FailureReason).Should(Equal(internal.TaskCompletionReasonEvacuated))
+
+			Ω(containerDelegate.DeleteContainerCallCount()).Should(Equal(1))
+			_, guid := containerDelegate.DeleteContainerArgsForCall(0)
+			Ω(guid).Should(Equal(taskGuid))
+		}
 
-	table.Test()
+		Context("when waiting for tasks", func() {
+			BeforeEach(func() {
+				evacuationPolicy = internal.TaskEvacuationPolicy{Mode: internal.TaskEvacuationWait}
+			})
+
+			itLeavesTheTaskRunning()
+		})
+
+		Context("when failing tasks", func() {
+			BeforeEach(func() {
+				evacuationPolicy = internal.TaskEvacuationPolicy{Mode: internal.TaskEvacuationFail}
+			})
+
+			It("fails the task so that it can be resubmitted", func() {
+				itFailsTheTaskAsEvacuated()
+			})
+
+			Context("when the task is outside the evacuation scope", func() {
+				BeforeEach(func() {
+					evacuationReporter.EvacuationScopeReturns(evacuation_context.Scope{Domains: []string{"other-domain"}})
+				})
+
+				itLeavesTheTaskRunning()
+			})
+
+			Context("when the cell is not evacuating", func() {
+				BeforeEach(func() {
+					evacuationReporter.EvacuatingReturns(false)
+				})
+
+				itLeavesTheTaskRunning()
+			})
+		})
+
+		Context("when waiting up to a deadline", func() {
+			BeforeEach(func() {
+				evacuationPolicy = internal.TaskEvacuationPolicy{
+					Mode:     internal.TaskEvacuationDeadline,
+					Deadline: time.Minute,
+					Domains:  map[string]time.Duration{"slow-domain": time.Hour},
+				}
+			})
+
+			itLeavesTheTaskRunning()
+
+			Context("once the deadline has passed", func() {
+				JustBeforeEach(func() {
+					fakeClock.Increment(time.Minute)
+					processor.Process(logger, container)
+				})
+
+				It("fails the task so that it can be resubmitted", func() {
+					itFailsTheTaskAsEvacuated()
+				})
+
+				Context("when the task's domain has a longer deadline", func() {
+					BeforeEach(func() {
+						container.Tags[rep.DomainTag] = "slow-domain"
+					})
+
+					itLeavesTheTaskRunning()
+				})
+			})
+
+			Context("when a container outside the evacuation scope is processed before the deadline", func() {
+				BeforeEach(func() {
+					evacuationReporter.EvacuationScopeReturns(evacuation_context.Scope{Domains: []string{"domain"}})
+				})
+
+				JustBeforeEach(func() {
+					other := NewContainer(executor.StateRunning)
+					other.Guid = "other-task-guid"
+					other.Tags[rep.DomainTag] = "other-domain"
+					processor.Process(logger, other)
+
+					fakeClock.Increment(time.Minute)
+					processor.Process(logger, container)
+				})
+
+				It("still fails the task once its own deadline has passed", func() {
+					task, err := BBS.TaskByGuid(taskGuid)
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(task.State).Should(Equal(models.TaskStateCompleted))
+					Ω(task.FailureReason).Should(Equal(internal.TaskCompletionReasonEvacuated))
+				})
+			})
+		})
+	})
 })
 
 type TaskTable struct {
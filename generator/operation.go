@@ -38,6 +38,10 @@ func (o *ResidualInstanceLRPOperation) Key() string {
 	return o.InstanceGuid
 }
 
+func (o *ResidualInstanceLRPOperation) Lifecycle() string {
+	return rep.LRPLifecycle
+}
+
 func (o *ResidualInstanceLRPOperation) Execute() {
 	logger := o.logger.Session("executing-residual-instance-lrp-operation", lager.Data{
 		"lrp-key":          o.ActualLRPKey,
@@ -83,6 +87,10 @@ func (o *ResidualEvacuatingLRPOperation) Key() string {
 	return o.InstanceGuid
 }
 
+func (o *ResidualEvacuatingLRPOperation) Lifecycle() string {
+	return rep.LRPLifecycle
+}
+
 func (o *ResidualEvacuatingLRPOperation) Execute() {
 	logger := o.logger.Session("executing-residual-evacuating-lrp-operation", lager.Data{
 		"lrp-key":          o.ActualLRPKey,
@@ -128,6 +136,10 @@ func (o *ResidualJointLRPOperation) Key() string {
 	return o.InstanceGuid
 }
 
+func (o *ResidualJointLRPOperation) Lifecycle() string {
+	return rep.LRPLifecycle
+}
+
 func (o *ResidualJointLRPOperation) Execute() {
 	logger := o.logger.Session("executing-residual-joint-lrp-operation", lager.Data{
 		"lrp-key":          o.ActualLRPKey,
@@ -172,6 +184,10 @@ func (o *ResidualTaskOperation) Key() string {
 	return o.TaskGuid
 }
 
+func (o *ResidualTaskOperation) Lifecycle() string {
+	return rep.TaskLifecycle
+}
+
 func (o *ResidualTaskOperation) Execute() {
 	logger := o.logger.Session("executing-residual-task-operation", lager.Data{
 		"task-guid": o.TaskGuid,
@@ -199,6 +215,7 @@ type ContainerOperation struct {
 	taskProcessor     internal.TaskProcessor
 	containerDelegate internal.ContainerDelegate
 	Guid              string
+	lifecycle         string
 }
 
 func NewContainerOperation(
@@ -207,6 +224,7 @@ func NewContainerOperation(
 	taskProcessor internal.TaskProcessor,
 	containerDelegate internal.ContainerDelegate,
 	guid string,
+	lifecycle string,
 ) *ContainerOperation {
 	return &ContainerOperation{
 		logger:            logger,
@@ -214,6 +232,7 @@ func NewContainerOperation(
 		taskProcessor:     taskProcessor,
 		containerDelegate: containerDelegate,
 		Guid:              guid,
+		lifecycle:         lifecycle,
 	}
 }
 
@@ -221,6 +240,12 @@ func (o *ContainerOperation) Key() string {
 	return o.Guid
 }
 
+// Lifecycle is the lifecycle the container had when the operation was created.
+// Execute still processes the container by its current tags.
+func (o *ContainerOperation) Lifecycle() string {
+	return o.lifecycle
+}
+
 func (o *ContainerOperation) Execute() {
 	logger := o.logger.Session("executing-container-operation", lager.Data{
 		"container-guid": o.Guid,
@@ -342,7 +342,7 @@ var _ = Describe("Operation", func() {
 			lrpProcessor = new(fake_internal.FakeLRPProcessor)
 			taskProcessor = new(fake_internal.FakeTaskProcessor)
 			guid = "the-guid"
-			containerOperation = generator.NewContainerOperation(logger, lrpProcessor, taskProcessor, containerDelegate, guid)
+			containerOperation = generator.NewContainerOperation(logger, lrpProcessor, taskProcessor, containerDelegate, guid, rep.LRPLifecycle)
 		})
 
 		Describe("Key", func() {
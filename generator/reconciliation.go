@@ -0,0 +1,123 @@
+package generator
+
+import (
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/pivotal-golang/operationq"
+)
+
+const (
+	ContainerOperationType          = "ContainerOperation"
+	ResidualInstanceOperationType   = "ResidualInstance"
+	ResidualEvacuatingOperationType = "ResidualEvacuating"
+	ResidualJointOperationType      = "ResidualJoint"
+	ResidualTaskOperationType       = "ResidualTask"
+	UnknownOperationType            = "Unknown"
+)
+
+// Reconciliation is the executor and BBS view of a single guid on the cell,
+// together with the operation chosen to harmonize them.
+type Reconciliation struct {
+	Guid          string
+	Container     *executor.Container
+	InstanceLRP   *models.ActualLRP
+	EvacuatingLRP *models.ActualLRP
+	Task          *models.Task
+	OperationType string
+	Operation     operationq.Operation
+}
+
+// ReconciliationSummary is the part of a Reconciliation that is safe to
+// report: the guid, the state and cell of each record, and the operation
+// type. Container and BBS records carry environment variables and other
+// credentials, so they are never reported whole.
+type ReconciliationSummary struct {
+	Guid          string         `json:"guid"`
+	Container     *RecordSummary `json:"container,omitempty"`
+	InstanceLRP   *RecordSummary `json:"instance_lrp,omitempty"`
+	EvacuatingLRP *RecordSummary `json:"evacuating_lrp,omitempty"`
+	Task          *RecordSummary `json:"task,omitempty"`
+	OperationType string         `json:"operation_type"`
+}
+
+// RecordSummary is the state of a container or BBS record and, for BBS
+// records, the cell it is on.
+type RecordSummary struct {
+	State  string `json:"state"`
+	CellID string `json:"cell_id,omitempty"`
+}
+
+// Summary returns the reportable part of the reconciliation.
+func (r Reconciliation) Summary() ReconciliationSummary {
+	summary := ReconciliationSummary{
+		Guid:          r.Guid,
+		OperationType: r.OperationType,
+	}
+
+	if r.Container != nil {
+		summary.Container = &RecordSummary{State: string(r.Container.State)}
+	}
+
+	if r.InstanceLRP != nil {
+		summary.InstanceLRP = &RecordSummary{State: string(r.InstanceLRP.State), CellID: r.InstanceLRP.CellID}
+	}
+
+	if r.EvacuatingLRP != nil {
+		summary.EvacuatingLRP = &RecordSummary{State: string(r.EvacuatingLRP.State), CellID: r.EvacuatingLRP.CellID}
+	}
+
+	if r.Task != nil {
+		summary.Task = &RecordSummary{State: taskStateName(r.Task.State), CellID: r.Task.CellID}
+	}
+
+	return summary
+}
+
+func taskStateName(state models.TaskState) string {
+	switch state {
+	case models.TaskStatePending:
+		return "PENDING"
+	case models.TaskStateRunning:
+		return "RUNNING"
+	case models.TaskStateCompleted:
+		return "COMPLETED"
+	case models.TaskStateResolving:
+		return "RESOLVING"
+	default:
+		return "INVALID"
+	}
+}
+
+// OperationType names the kind of operation created by the generator.
+func OperationType(operation operationq.Operation) string {
+	switch operation.(type) {
+	case *ContainerOperation:
+		return ContainerOperationType
+	case *ResidualInstanceLRPOperation:
+		return ResidualInstanceOperationType
+	case *ResidualEvacuatingLRPOperation:
+		return ResidualEvacuatingOperationType
+	case *ResidualJointLRPOperation:
+		return ResidualJointOperationType
+	case *ResidualTaskOperation:
+		return ResidualTaskOperationType
+	default:
+		return UnknownOperationType
+	}
+}
+
+// LifecycleOperation is an operation that knows whether it works on an LRP
+// or a Task.
+type LifecycleOperation interface {
+	operationq.Operation
+	Lifecycle() string
+}
+
+// OperationLifecycle reports whether an operation works on an LRP or a Task,
+// returning an empty string when it cannot tell.
+func OperationLifecycle(operation operationq.Operation) string {
+	if op, ok := operation.(LifecycleOperation); ok {
+		return op.Lifecycle()
+	}
+	return ""
+}
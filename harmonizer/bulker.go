@@ -1,7 +1,10 @@
 package harmonizer
 
 import (
+	"fmt"
+	"math/rand"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context"
@@ -13,55 +16,108 @@ import (
 )
 
 const repBulkSyncDuration = metric.Duration("RepBulkSyncDuration")
+const repBulkSyncInterval = metric.Duration("RepBulkSyncInterval")
+const repBulkSyncOperations = metric.Metric("RepBulkSyncOperations")
+const repBulkSyncFailures = metric.Counter("RepBulkSyncFailures")
 
 type Bulker struct {
 	logger lager.Logger
 
 	pollInterval           time.Duration
+	maxPollInterval        time.Duration
+	pollJitter             float64
 	evacuationPollInterval time.Duration
 	evacuationNotifier     evacuation_context.EvacuationNotifier
 	clock                  clock.Clock
 	generator              generator.Generator
 	queue                  operationq.Queue
+
+	random *rand.Rand
+
+	triggers chan syncTrigger
+	stopped  chan struct{}
+
+	lastSyncLock sync.Mutex
+	lastSync     time.Time
 }
 
+type syncTrigger struct {
+	logger lager.Logger
+	filter SyncFilter
+	result chan<- syncResult
+}
+
+type syncResult struct {
+	queued int
+	err    error
+}
+
+// NewBulker creates a Bulker that syncs every pollInterval. After consecutive
+// syncs that find no drift the interval doubles, up to maxPollInterval; a sync
+// that finds drift or fails halves it again, down to pollInterval. Each wait is
+// randomly lengthened or shortened by up to pollJitter of the interval, which
+// must be at least 0 and less than 1 so that no wait drops to zero.
 func NewBulker(
 	logger lager.Logger,
 	pollInterval time.Duration,
+	maxPollInterval time.Duration,
+	pollJitter float64,
 	evacuationPollInterval time.Duration,
 	evacuationNotifier evacuation_context.EvacuationNotifier,
 	clock clock.Clock,
 	generator generator.Generator,
 	queue operationq.Queue,
 ) *Bulker {
+	if maxPollInterval < pollInterval {
+		maxPollInterval = pollInterval
+	}
+
 	return &Bulker{
 		logger: logger,
 
 		pollInterval:           pollInterval,
+		maxPollInterval:        maxPollInterval,
+		pollJitter:             pollJitter,
 		evacuationPollInterval: evacuationPollInterval,
 		evacuationNotifier:     evacuationNotifier,
 		clock:                  clock,
 		generator:              generator,
 		queue:                  queue,
+
+		random: rand.New(rand.NewSource(clock.Now().UnixNano())),
+
+		triggers: make(chan syncTrigger),
+		stopped:  make(chan struct{}),
 	}
 }
 
 func (b *Bulker) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
 	evacuateNotify := b.evacuationNotifier.EvacuateNotify()
-	close(ready)
+	cancelNotify := b.evacuationNotifier.CancelNotify()
 
 	logger := b.logger.Session("running-bulker")
 
 	logger.Info("starting", lager.Data{
-		"interval": b.pollInterval.String(),
+		"interval":     b.pollInterval.String(),
+		"max-interval": b.maxPollInterval.String(),
+		"jitter":       b.pollJitter,
 	})
 	defer logger.Info("finished")
+	defer close(b.stopped)
+
+	// Until the first sync, the bulker is as current as when it started.
+	b.recordSync(b.clock.Now())
 
 	interval := b.pollInterval
+	evacuating := false
+
+	repBulkSyncInterval.Send(interval)
 
-	timer := b.clock.NewTimer(interval)
+	timer := b.clock.NewTimer(b.jittered(interval))
 	defer timer.Stop()
 
+	close(ready)
+
 	for {
 		select {
 		case <-timer.C():
@@ -70,20 +126,77 @@ func (b *Bulker) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
 			evacuateNotify = nil
 
 			logger.Info("notified-of-evacuation")
+			evacuating = true
 			interval = b.evacuationPollInterval
+			repBulkSyncInterval.Send(interval)
+
+		case <-cancelNotify:
+			evacuateNotify = b.evacuationNotifier.EvacuateNotify()
+			cancelNotify = b.evacuationNotifier.CancelNotify()
+
+			logger.Info("notified-of-evacuation-cancellation")
+			evacuating = false
+			interval = b.pollInterval
+			repBulkSyncInterval.Send(interval)
+
+		case trigger := <-b.triggers:
+			queued, err := b.triggeredSync(trigger.logger, trigger.filter)
+			trigger.result <- syncResult{queued: queued, err: err}
+			continue
 
 		case signal := <-signals:
 			logger.Info("received-signal", lager.Data{"signal": signal.String()})
 			return nil
 		}
 
-		b.sync(logger)
+		drifted := b.sync(logger)
+
+		if !evacuating {
+			interval = b.adapt(logger, interval, drifted)
+		}
+
+		timer.Reset(b.jittered(interval))
+	}
+}
+
+// adapt halves the interval after a sync that found drift and doubles it
+// after one that did not, keeping it between pollInterval and maxPollInterval.
+func (b *Bulker) adapt(logger lager.Logger, interval time.Duration, drifted bool) time.Duration {
+	next := interval * 2
+	if drifted {
+		next = interval / 2
+	}
+
+	if next < b.pollInterval {
+		next = b.pollInterval
+	}
+	if next > b.maxPollInterval {
+		next = b.maxPollInterval
+	}
+
+	if next != interval {
+		logger.Info("adapted-interval", lager.Data{
+			"interval": next.String(),
+			"drifted":  drifted,
+		})
+		repBulkSyncInterval.Send(next)
+	}
+
+	return next
+}
 
-		timer.Reset(interval)
+func (b *Bulker) jittered(interval time.Duration) time.Duration {
+	if b.pollJitter <= 0 {
+		return interval
 	}
+
+	offset := (b.random.Float64()*2 - 1) * b.pollJitter * float64(interval)
+	return interval + time.Duration(offset)
 }
 
-func (b *Bulker) sync(logger lager.Logger) {
+// sync pushes a batch of operations onto the queue and reports whether the
+// executor and the BBS had drifted apart, or the batch could not be generated.
+func (b *Bulker) sync(logger lager.Logger) bool {
 	logger = logger.Session("sync")
 
 	logger.Info("starting")
@@ -99,10 +212,91 @@ func (b *Bulker) sync(logger lager.Logger) {
 
 	if err != nil {
 		logger.Error("failed-to-generate-operations", err)
-		return
+		repBulkSyncFailures.Increment()
+		return true
 	}
 
+	repBulkSyncOperations.Send(len(ops))
+	b.recordSync(endTime)
+
+	drifted := false
 	for _, operation := range ops {
+		if generator.OperationType(operation) != generator.ContainerOperationType {
+			drifted = true
+		}
 		b.queue.Push(operation)
 	}
+
+	return drifted
+}
+
+func (b *Bulker) recordSync(at time.Time) {
+	b.lastSyncLock.Lock()
+	b.lastSync = at
+	b.lastSyncLock.Unlock()
+}
+
+// LastSync is when a bulk sync last succeeded, or when the Bulker started if
+// none has yet. It is zero before the Bulker runs.
+func (b *Bulker) LastSync() time.Time {
+	b.lastSyncLock.Lock()
+	defer b.lastSyncLock.Unlock()
+
+	return b.lastSync
+}
+
+// CheckSync returns an error unless a bulk sync has succeeded within maxAge.
+func (b *Bulker) CheckSync(maxAge time.Duration) error {
+	lastSync := b.LastSync()
+	if lastSync.IsZero() {
+		return ErrBulkerNotRunning
+	}
+
+	age := b.clock.Now().Sub(lastSync)
+	if age > maxAge {
+		return fmt.Errorf("last bulk sync succeeded %s ago", age)
+	}
+
+	return nil
+}
+
+// Trigger asks the running Bulker to sync the guids matching the filter now,
+// without waiting for the poll interval, and does not affect its schedule.
+func (b *Bulker) Trigger(logger lager.Logger, filter SyncFilter) (int, error) {
+	result := make(chan syncResult, 1)
+
+	select {
+	case b.triggers <- syncTrigger{logger: logger, filter: filter, result: result}:
+	case <-b.stopped:
+		return 0, ErrBulkerNotRunning
+	}
+
+	r := <-result
+	return r.queued, r.err
+}
+
+func (b *Bulker) triggeredSync(logger lager.Logger, filter SyncFilter) (int, error) {
+	logger = logger.Session("triggered-sync", lager.Data{"filter": filter})
+
+	logger.Info("starting")
+	defer logger.Info("finished")
+
+	diff, err := b.generator.Diff(logger)
+	if err != nil {
+		logger.Error("failed-to-generate-operations", err)
+		return 0, err
+	}
+
+	queued := 0
+	for _, reconciliation := range diff {
+		if !filter.Matches(reconciliation) {
+			continue
+		}
+
+		b.queue.Push(reconciliation.Operation)
+		queued++
+	}
+
+	logger.Info("queued-operations", lager.Data{"queued": queued})
+	return queued, nil
 }
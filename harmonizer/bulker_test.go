@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context"
+	"github.com/cloudfoundry-incubator/rep/generator"
 	"github.com/cloudfoundry-incubator/rep/generator/fake_generator"
 	"github.com/cloudfoundry-incubator/rep/harmonizer"
 	"github.com/cloudfoundry/dropsonde/metric_sender/fake"
@@ -27,6 +28,8 @@ var _ = Describe("Bulker", func() {
 
 		logger                 *lagertest.TestLogger
 		pollInterval           time.Duration
+		maxPollInterval        time.Duration
+		pollJitter             float64
 		evacuationPollInterval time.Duration
 		fakeClock              *fakeclock.FakeClock
 		fakeGenerator          *fake_generator.FakeGenerator
@@ -44,17 +47,18 @@ var _ = Describe("Bulker", func() {
 
 		logger = lagertest.NewTestLogger("test")
 		pollInterval = 30 * time.Second
+		maxPollInterval = pollInterval
+		pollJitter = 0
 		evacuationPollInterval = 10 * time.Second
 		fakeClock = fakeclock.NewFakeClock(time.Unix(123, 456))
 		fakeGenerator = new(fake_generator.FakeGenerator)
 		fakeQueue = new(fake_operationq.FakeQueue)
 
 		evacuatable, _, evacuationNotifier = evacuation_context.New()
-
-		bulker = harmonizer.NewBulker(logger, pollInterval, evacuationPollInterval, evacuationNotifier, fakeClock, fakeGenerator, fakeQueue)
 	})
 
 	JustBeforeEach(func() {
+		bulker = harmonizer.NewBulker(logger, pollInterval, maxPollInterval, pollJitter, evacuationPollInterval, evacuationNotifier, fakeClock, fakeGenerator, fakeQueue)
 		process = ifrit.Invoke(bulker)
 	})
 
@@ -97,6 +101,12 @@ var _ = Describe("Bulker", func() {
 				Ω(reportedDuration.Unit).Should(Equal("nanos"))
 				Ω(reportedDuration.Value).Should(BeNumerically("==", 10*time.Second))
 			})
+
+			It("emits the number of operations in the batch", func() {
+				Eventually(fakeQueue.PushCallCount).Should(Equal(2))
+
+				Ω(sender.GetValue("RepBulkSyncOperations").Value).Should(BeEquivalentTo(2))
+			})
 		})
 
 		Context("when generating the batch operations fails", func() {
@@ -110,6 +120,12 @@ var _ = Describe("Bulker", func() {
 				Eventually(logger).Should(gbytes.Say("failed-to-generate-operations"))
 				Eventually(logger).Should(gbytes.Say("nope"))
 			})
+
+			It("counts the failure", func() {
+				Eventually(func() uint64 {
+					return sender.GetCounter("RepBulkSyncFailures")
+				}).Should(BeNumerically(">=", 1))
+			})
 		})
 	}
 
@@ -158,5 +174,192 @@ var _ = Describe("Bulker", func() {
 				Consistently(fakeGenerator.BatchOperationsCallCount).Should(Equal(2))
 			})
 		})
+
+		Context("and is then canceled", func() {
+			It("batches operations on the regular poll interval again", func() {
+				Eventually(fakeGenerator.BatchOperationsCallCount).Should(Equal(1))
+
+				evacuatable.CancelEvacuation()
+				Eventually(fakeGenerator.BatchOperationsCallCount).Should(Equal(2))
+
+				fakeClock.Increment(evacuationPollInterval + time.Second)
+				Consistently(fakeGenerator.BatchOperationsCallCount).Should(Equal(2))
+
+				fakeClock.Increment(pollInterval)
+				Eventually(fakeGenerator.BatchOperationsCallCount).Should(Equal(3))
+			})
+
+			It("notices the next evacuation", func() {
+				Eventually(fakeGenerator.BatchOperationsCallCount).Should(Equal(1))
+
+				evacuatable.CancelEvacuation()
+				Eventually(fakeGenerator.BatchOperationsCallCount).Should(Equal(2))
+
+				evacuatable.Evacuate()
+				Eventually(fakeGenerator.BatchOperationsCallCount).Should(Equal(3))
+			})
+		})
+	})
+
+	It("emits the current poll interval", func() {
+		Eventually(func() float64 {
+			return sender.GetValue("RepBulkSyncInterval").Value
+		}).Should(BeNumerically("==", pollInterval))
+	})
+
+	Context("when the poll interval may adapt", func() {
+		BeforeEach(func() {
+			maxPollInterval = 4 * pollInterval
+			fakeGenerator.BatchOperationsReturns(map[string]operationq.Operation{}, nil)
+		})
+
+		It("lengthens the interval after a clean sync", func() {
+			fakeClock.Increment(pollInterval + 1)
+			Eventually(fakeGenerator.BatchOperationsCallCount).Should(Equal(1))
+
+			Eventually(func() float64 {
+				return sender.GetValue("RepBulkSyncInterval").Value
+			}).Should(BeNumerically("==", 2*pollInterval))
+
+			fakeClock.Increment(pollInterval + 1)
+			Consistently(fakeGenerator.BatchOperationsCallCount).Should(Equal(1))
+
+			fakeClock.Increment(pollInterval)
+			Eventually(fakeGenerator.BatchOperationsCallCount).Should(Equal(2))
+		})
+
+		It("never lengthens the interval beyond the maximum", func() {
+			for i, wait := range []time.Duration{pollInterval, 2 * pollInterval, 4 * pollInterval, 4 * pollInterval} {
+				fakeClock.Increment(wait + 1)
+				Eventually(fakeGenerator.BatchOperationsCallCount).Should(Equal(i + 1))
+			}
+
+			Eventually(func() float64 {
+				return sender.GetValue("RepBulkSyncInterval").Value
+			}).Should(BeNumerically("==", maxPollInterval))
+		})
+
+		Context("when a sync fails after a clean one", func() {
+			It("shortens the interval again", func() {
+				fakeClock.Increment(pollInterval + 1)
+				Eventually(fakeGenerator.BatchOperationsCallCount).Should(Equal(1))
+
+				fakeGenerator.BatchOperationsReturns(nil, errors.New("nope"))
+
+				fakeClock.Increment(2 * pollInterval)
+				Eventually(fakeGenerator.BatchOperationsCallCount).Should(Equal(2))
+
+				Eventually(func() float64 {
+					return sender.GetValue("RepBulkSyncInterval").Value
+				}).Should(BeNumerically("==", pollInterval))
+			})
+		})
+	})
+
+	Context("when the poll interval is jittered", func() {
+		BeforeEach(func() {
+			pollJitter = 0.5
+		})
+
+		It("syncs within the jittered window", func() {
+			fakeClock.Increment(pollInterval/2 - 1)
+			Consistently(fakeGenerator.BatchOperationsCallCount).Should(BeZero())
+
+			fakeClock.Increment(pollInterval + 2)
+			Eventually(fakeGenerator.BatchOperationsCallCount).Should(Equal(1))
+		})
+	})
+
+	Describe("CheckSync", func() {
+		It("counts the time since the bulker started until the first sync", func() {
+			Ω(bulker.CheckSync(pollInterval)).Should(Succeed())
+
+			fakeGenerator.BatchOperationsReturns(nil, errors.New("nope"))
+			fakeClock.Increment(pollInterval + 1)
+			Eventually(fakeGenerator.BatchOperationsCallCount).Should(Equal(1))
+
+			Ω(bulker.CheckSync(pollInterval)).ShouldNot(Succeed())
+		})
+
+		Context("when a sync succeeds", func() {
+			JustBeforeEach(func() {
+				fakeClock.Increment(pollInterval + 1)
+				Eventually(bulker.LastSync).Should(Equal(fakeClock.Now()))
+			})
+
+			It("counts the time since that sync", func() {
+				fakeClock.Increment(time.Second)
+
+				Ω(bulker.CheckSync(time.Second)).Should(Succeed())
+				Ω(bulker.CheckSync(time.Second - 1)).Should(MatchError(ContainSubstring("last bulk sync succeeded")))
+			})
+		})
+	})
+
+	Describe("Trigger", func() {
+		var (
+			operation1 *fake_operationq.FakeOperation
+			operation2 *fake_operationq.FakeOperation
+			filter     harmonizer.SyncFilter
+		)
+
+		BeforeEach(func() {
+			operation1 = new(fake_operationq.FakeOperation)
+			operation2 = new(fake_operationq.FakeOperation)
+			filter = harmonizer.SyncFilter{Guid: "guid1"}
+
+			fakeGenerator.DiffReturns(map[string]generator.Reconciliation{
+				"guid1": {Guid: "guid1", Operation: operation1},
+				"guid2": {Guid: "guid2", Operation: operation2},
+			}, nil)
+		})
+
+		It("queues the operations matching the filter", func() {
+			queued, err := bulker.Trigger(logger, filter)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(queued).Should(Equal(1))
+
+			Ω(fakeQueue.PushCallCount()).Should(Equal(1))
+			Ω(fakeQueue.PushArgsForCall(0)).Should(Equal(operation1))
+		})
+
+		It("does not wait for the poll interval", func() {
+			_, err := bulker.Trigger(logger, filter)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(fakeGenerator.DiffCallCount()).Should(Equal(1))
+			Ω(fakeGenerator.BatchOperationsCallCount()).Should(BeZero())
+		})
+
+		It("does not change when the next sync happens", func() {
+			_, err := bulker.Trigger(logger, filter)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			fakeClock.Increment(pollInterval + 1)
+			Eventually(fakeGenerator.BatchOperationsCallCount).Should(Equal(1))
+		})
+
+		Context("when generating the diff fails", func() {
+			BeforeEach(func() {
+				fakeGenerator.DiffReturns(nil, errors.New("nope"))
+			})
+
+			It("returns the error", func() {
+				_, err := bulker.Trigger(logger, filter)
+				Ω(err).Should(MatchError("nope"))
+				Ω(fakeQueue.PushCallCount()).Should(BeZero())
+			})
+		})
+
+		Context("when the bulker has stopped", func() {
+			JustBeforeEach(func() {
+				process.Signal(os.Interrupt)
+				Eventually(process.Wait()).Should(Receive())
+			})
+
+			It("returns ErrBulkerNotRunning", func() {
+				_, err := bulker.Trigger(logger, filter)
+				Ω(err).Should(Equal(harmonizer.ErrBulkerNotRunning))
+			})
+		})
 	})
 })
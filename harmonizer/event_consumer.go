@@ -2,29 +2,43 @@ package harmonizer
 
 import (
 	"os"
+	"sync"
 
 	"github.com/cloudfoundry-incubator/executor"
 	"github.com/cloudfoundry-incubator/rep/generator"
+	"github.com/cloudfoundry-incubator/runtime-schema/metric"
+	"github.com/pivotal-golang/clock"
 	"github.com/pivotal-golang/lager"
 	"github.com/pivotal-golang/operationq"
 )
 
+const (
+	repEventStreamOperations = metric.Counter("RepEventStreamOperations")
+	repEventStreamDelivery   = metric.Duration("RepEventStreamDeliveryDuration")
+)
+
 type EventConsumer struct {
 	logger         lager.Logger
 	executorClient executor.Client
 	generator      generator.Generator
 	queue          operationq.Queue
+	clock          clock.Clock
+
+	subscribedLock sync.Mutex
+	subscribed     bool
 }
 
 func NewEventConsumer(
 	logger lager.Logger,
 	generator generator.Generator,
 	queue operationq.Queue,
+	clock clock.Clock,
 ) *EventConsumer {
 	return &EventConsumer{
 		logger:    logger,
 		generator: generator,
 		queue:     queue,
+		clock:     clock,
 	}
 }
 
@@ -41,18 +55,25 @@ func (consumer *EventConsumer) Run(signals <-chan os.Signal, ready chan<- struct
 	}
 	logger.Info("succeeded-subscribing-to-operation-stream")
 
+	consumer.setSubscribed(true)
+	defer consumer.setSubscribed(false)
+
 	close(ready)
 	logger.Info("started")
 
 	for {
 		select {
-		case op, ok := <-stream:
+		case streamed, ok := <-stream:
 			if !ok {
 				logger.Info("event-stream-closed")
 				return nil
 			}
 
-			consumer.queue.Push(op)
+			repEventStreamOperations.Increment()
+			consumer.queue.Push(streamed.Operation)
+			// executor events carry no emission time, so this only covers the
+			// rep's own handling of the event, not its trip from the executor
+			repEventStreamDelivery.Send(consumer.clock.Now().Sub(streamed.ReceivedAt))
 
 		case signal := <-signals:
 			logger.Info("received-signal", lager.Data{"signal": signal.String()})
@@ -62,3 +83,17 @@ func (consumer *EventConsumer) Run(signals <-chan os.Signal, ready chan<- struct
 
 	return nil
 }
+
+// Subscribed reports whether the consumer is receiving the operation stream.
+func (consumer *EventConsumer) Subscribed() bool {
+	consumer.subscribedLock.Lock()
+	defer consumer.subscribedLock.Unlock()
+
+	return consumer.subscribed
+}
+
+func (consumer *EventConsumer) setSubscribed(subscribed bool) {
+	consumer.subscribedLock.Lock()
+	consumer.subscribed = subscribed
+	consumer.subscribedLock.Unlock()
+}
@@ -3,11 +3,16 @@ package harmonizer_test
 import (
 	"errors"
 	"os"
+	"time"
 
+	"github.com/cloudfoundry-incubator/rep/generator"
 	"github.com/cloudfoundry-incubator/rep/generator/fake_generator"
 	"github.com/cloudfoundry-incubator/rep/harmonizer"
+	"github.com/cloudfoundry/dropsonde/metric_sender/fake"
+	"github.com/cloudfoundry/dropsonde/metrics"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/clock/fakeclock"
 	"github.com/pivotal-golang/lager/lagertest"
 	"github.com/pivotal-golang/operationq"
 	"github.com/pivotal-golang/operationq/fake_operationq"
@@ -16,7 +21,10 @@ import (
 
 var _ = Describe("EventConsumer", func() {
 	var (
+		sender *fake.FakeMetricSender
+
 		logger        *lagertest.TestLogger
+		fakeClock     *fakeclock.FakeClock
 		fakeGenerator *fake_generator.FakeGenerator
 		fakeQueue     *fake_operationq.FakeQueue
 
@@ -25,11 +33,15 @@ var _ = Describe("EventConsumer", func() {
 	)
 
 	BeforeEach(func() {
+		sender = fake.NewFakeMetricSender()
+		metrics.Initialize(sender)
+
 		logger = lagertest.NewTestLogger("test")
+		fakeClock = fakeclock.NewFakeClock(time.Now())
 		fakeGenerator = new(fake_generator.FakeGenerator)
 		fakeQueue = new(fake_operationq.FakeQueue)
 
-		consumer = harmonizer.NewEventConsumer(logger, fakeGenerator, fakeQueue)
+		consumer = harmonizer.NewEventConsumer(logger, fakeGenerator, fakeQueue, fakeClock)
 	})
 
 	JustBeforeEach(func() {
@@ -43,29 +55,61 @@ var _ = Describe("EventConsumer", func() {
 
 	Context("when subscribing to the operation stream succeeds", func() {
 		var (
-			receivedOperations chan<- operationq.Operation
+			receivedOperations chan<- generator.StreamedOperation
 		)
 
 		BeforeEach(func() {
-			operations := make(chan operationq.Operation)
+			operations := make(chan generator.StreamedOperation)
 			receivedOperations = operations
 
 			fakeGenerator.OperationStreamReturns(operations, nil)
 		})
 
+		It("reports that it is subscribed", func() {
+			Eventually(consumer.Subscribed).Should(BeTrue())
+		})
+
 		Context("when an operation is received", func() {
-			var fakeOperation *fake_operationq.FakeOperation
+			var (
+				fakeOperation *fake_operationq.FakeOperation
+				streamed      generator.StreamedOperation
+			)
 
 			BeforeEach(func() {
 				fakeOperation = new(fake_operationq.FakeOperation)
+				streamed = generator.StreamedOperation{
+					Operation:  fakeOperation,
+					ReceivedAt: fakeClock.Now(),
+				}
 			})
 
 			It("pushes it onto the queue", func() {
-				receivedOperations <- fakeOperation
+				receivedOperations <- streamed
 
 				Eventually(fakeQueue.PushCallCount).Should(Equal(1))
 				Ω(fakeQueue.PushArgsForCall(0)).Should(Equal(fakeOperation))
 			})
+
+			It("counts it", func() {
+				receivedOperations <- streamed
+
+				Eventually(func() uint64 {
+					return sender.GetCounter("RepEventStreamOperations")
+				}).Should(BeEquivalentTo(1))
+			})
+
+			It("emits how long it took from reading the event to queueing the operation", func() {
+				fakeQueue.PushStub = func(operationq.Operation) {
+					fakeClock.Increment(time.Second)
+				}
+
+				receivedOperations <- streamed
+
+				Eventually(func() float64 {
+					return sender.GetValue("RepEventStreamDeliveryDuration").Value
+				}).Should(BeEquivalentTo(time.Second))
+				Ω(sender.GetValue("RepEventStreamDeliveryDuration").Unit).Should(Equal("nanos"))
+			})
 		})
 
 		Context("when the operation stream terminates", func() {
@@ -74,6 +118,12 @@ var _ = Describe("EventConsumer", func() {
 
 				Eventually(process.Wait()).Should(Receive(BeNil()))
 			})
+
+			It("reports that it is no longer subscribed", func() {
+				close(receivedOperations)
+
+				Eventually(consumer.Subscribed).Should(BeFalse())
+			})
 		})
 	})
 
@@ -87,5 +137,10 @@ var _ = Describe("EventConsumer", func() {
 		It("exits with failure", func() {
 			Eventually(process.Wait()).Should(Receive(Equal(disaster)))
 		})
+
+		It("does not report that it is subscribed", func() {
+			Eventually(process.Wait()).Should(Receive())
+			Ω(consumer.Subscribed()).Should(BeFalse())
+		})
 	})
 })
@@ -0,0 +1,58 @@
+// This file was generated by counterfeiter
+package fake_harmonizer
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/rep/harmonizer"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeSyncTrigger struct {
+	TriggerStub        func(logger lager.Logger, filter harmonizer.SyncFilter) (int, error)
+	triggerMutex       sync.RWMutex
+	triggerArgsForCall []struct {
+		logger lager.Logger
+		filter harmonizer.SyncFilter
+	}
+	triggerReturns struct {
+		result1 int
+		result2 error
+	}
+}
+
+func (fake *FakeSyncTrigger) Trigger(logger lager.Logger, filter harmonizer.SyncFilter) (int, error) {
+	fake.triggerMutex.Lock()
+	fake.triggerArgsForCall = append(fake.triggerArgsForCall, struct {
+		logger lager.Logger
+		filter harmonizer.SyncFilter
+	}{logger, filter})
+	fake.triggerMutex.Unlock()
+	if fake.TriggerStub != nil {
+		return fake.TriggerStub(logger, filter)
+	} else {
+		return fake.triggerReturns.result1, fake.triggerReturns.result2
+	}
+}
+
+func (fake *FakeSyncTrigger) TriggerCallCount() int {
+	fake.triggerMutex.RLock()
+	defer fake.triggerMutex.RUnlock()
+	return len(fake.triggerArgsForCall)
+}
+
+func (fake *FakeSyncTrigger) TriggerArgsForCall(i int) (lager.Logger, harmonizer.SyncFilter) {
+	fake.triggerMutex.RLock()
+	defer fake.triggerMutex.RUnlock()
+	return fake.triggerArgsForCall[i].logger, fake.triggerArgsForCall[i].filter
+}
+
+func (fake *FakeSyncTrigger) TriggerReturns(result1 int, result2 error) {
+	fake.TriggerStub = nil
+	fake.triggerReturns = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+var _ harmonizer.SyncTrigger = new(FakeSyncTrigger)
@@ -0,0 +1,97 @@
+package harmonizer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/rep/generator"
+	"github.com/cloudfoundry-incubator/runtime-schema/metric"
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/operationq"
+)
+
+const repOperationQueueDepth = metric.Metric("RepOperationQueueDepth")
+
+func operationsQueuedCounter(operationType string) metric.Counter {
+	return metric.Counter(fmt.Sprintf("RepOperationsQueued.%s", operationType))
+}
+
+func operationQueueLatency(operationType string) metric.Duration {
+	return metric.Duration(fmt.Sprintf("RepOperationQueueLatency.%s", operationType))
+}
+
+func operationDuration(operationType string) metric.Duration {
+	return metric.Duration(fmt.Sprintf("RepOperationDuration.%s", operationType))
+}
+
+type instrumentedQueue struct {
+	queue operationq.Queue
+	clock clock.Clock
+
+	waiting     map[string]*instrumentedOperation
+	waitingLock sync.Mutex
+}
+
+// NewInstrumentedQueue wraps a queue to report its depth and, for every type
+// of operation, how many were queued, how long they waited to execute and how
+// long they took to execute.
+//
+// The depth is the number of keys with an operation waiting to execute: a
+// queue that slides newer operations over waiting ones counts each key once.
+func NewInstrumentedQueue(queue operationq.Queue, clock clock.Clock) operationq.Queue {
+	return &instrumentedQueue{
+		queue:   queue,
+		clock:   clock,
+		waiting: make(map[string]*instrumentedOperation),
+	}
+}
+
+func (q *instrumentedQueue) Push(operation operationq.Operation) {
+	op := &instrumentedOperation{
+		Operation:     operation,
+		operationType: generator.OperationType(operation),
+		queue:         q,
+		queuedAt:      q.clock.Now(),
+	}
+
+	operationsQueuedCounter(op.operationType).Increment()
+
+	q.waitingLock.Lock()
+	q.waiting[op.Key()] = op
+	depth := len(q.waiting)
+	q.waitingLock.Unlock()
+
+	repOperationQueueDepth.Send(depth)
+
+	q.queue.Push(op)
+}
+
+func (q *instrumentedQueue) started(op *instrumentedOperation) {
+	q.waitingLock.Lock()
+	if q.waiting[op.Key()] == op {
+		delete(q.waiting, op.Key())
+	}
+	depth := len(q.waiting)
+	q.waitingLock.Unlock()
+
+	repOperationQueueDepth.Send(depth)
+}
+
+type instrumentedOperation struct {
+	operationq.Operation
+
+	operationType string
+	queue         *instrumentedQueue
+	queuedAt      time.Time
+}
+
+func (o *instrumentedOperation) Execute() {
+	startedAt := o.queue.clock.Now()
+	o.queue.started(o)
+	operationQueueLatency(o.operationType).Send(startedAt.Sub(o.queuedAt))
+
+	o.Operation.Execute()
+
+	operationDuration(o.operationType).Send(o.queue.clock.Now().Sub(startedAt))
+}
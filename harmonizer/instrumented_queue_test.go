@@ -0,0 +1,103 @@
+package harmonizer_test
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/rep/generator"
+	"github.com/cloudfoundry-incubator/rep/harmonizer"
+	"github.com/cloudfoundry/dropsonde/metric_sender/fake"
+	"github.com/cloudfoundry/dropsonde/metrics"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager/lagertest"
+	"github.com/pivotal-golang/operationq"
+	"github.com/pivotal-golang/operationq/fake_operationq"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("InstrumentedQueue", func() {
+	var (
+		sender    *fake.FakeMetricSender
+		fakeClock *fakeclock.FakeClock
+		fakeQueue *fake_operationq.FakeQueue
+		queue     operationq.Queue
+
+		operation *fake_operationq.FakeOperation
+	)
+
+	BeforeEach(func() {
+		sender = fake.NewFakeMetricSender()
+		metrics.Initialize(sender)
+
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		fakeQueue = new(fake_operationq.FakeQueue)
+		queue = harmonizer.NewInstrumentedQueue(fakeQueue, fakeClock)
+
+		operation = new(fake_operationq.FakeOperation)
+		operation.KeyReturns("guid")
+		operation.ExecuteStub = func() {
+			fakeClock.Increment(3 * time.Second)
+		}
+	})
+
+	It("pushes the operation onto the wrapped queue", func() {
+		queue.Push(operation)
+
+		Ω(fakeQueue.PushCallCount()).Should(Equal(1))
+		Ω(fakeQueue.PushArgsForCall(0).Key()).Should(Equal("guid"))
+
+		fakeQueue.PushArgsForCall(0).Execute()
+		Ω(operation.ExecuteCallCount()).Should(Equal(1))
+	})
+
+	It("counts the operations queued by type", func() {
+		queue.Push(operation)
+		queue.Push(generator.NewResidualTaskOperation(lagertest.NewTestLogger("test"), nil, nil, "task-guid"))
+
+		Ω(sender.GetCounter("RepOperationsQueued.Unknown")).Should(BeEquivalentTo(1))
+		Ω(sender.GetCounter("RepOperationsQueued.ResidualTask")).Should(BeEquivalentTo(1))
+	})
+
+	It("reports the number of keys waiting to execute", func() {
+		otherOperation := new(fake_operationq.FakeOperation)
+		otherOperation.KeyReturns("other-guid")
+
+		queue.Push(operation)
+		queue.Push(operation)
+		Ω(sender.GetValue("RepOperationQueueDepth").Value).Should(BeEquivalentTo(1))
+
+		queue.Push(otherOperation)
+		Ω(sender.GetValue("RepOperationQueueDepth").Value).Should(BeEquivalentTo(2))
+
+		fakeQueue.PushArgsForCall(2).Execute()
+		Ω(sender.GetValue("RepOperationQueueDepth").Value).Should(BeEquivalentTo(1))
+	})
+
+	Context("when a superseded operation executes", func() {
+		It("still counts the newer operation as waiting", func() {
+			queue.Push(operation)
+			queue.Push(operation)
+
+			fakeQueue.PushArgsForCall(0).Execute()
+			Ω(sender.GetValue("RepOperationQueueDepth").Value).Should(BeEquivalentTo(1))
+
+			fakeQueue.PushArgsForCall(1).Execute()
+			Ω(sender.GetValue("RepOperationQueueDepth").Value).Should(BeEquivalentTo(0))
+		})
+	})
+
+	It("reports how long the operation waited and took to execute", func() {
+		queue.Push(operation)
+		fakeClock.Increment(2 * time.Second)
+		fakeQueue.PushArgsForCall(0).Execute()
+
+		latency := sender.GetValue("RepOperationQueueLatency.Unknown")
+		Ω(latency.Unit).Should(Equal("nanos"))
+		Ω(latency.Value).Should(BeNumerically("==", 2*time.Second))
+
+		duration := sender.GetValue("RepOperationDuration.Unknown")
+		Ω(duration.Unit).Should(Equal("nanos"))
+		Ω(duration.Value).Should(BeNumerically("==", 3*time.Second))
+	})
+})
@@ -0,0 +1,71 @@
+package harmonizer
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry-incubator/rep"
+	"github.com/cloudfoundry-incubator/rep/generator"
+	"github.com/pivotal-golang/lager"
+)
+
+var ErrBulkerNotRunning = errors.New("bulker is not running")
+
+//go:generate counterfeiter -o fake_harmonizer/fake_sync_trigger.go . SyncTrigger
+
+// SyncTrigger runs a sync outside of the regular polling interval.
+type SyncTrigger interface {
+	// Trigger syncs the guids matching the filter and returns the number of
+	// operations queued.
+	Trigger(logger lager.Logger, filter SyncFilter) (int, error)
+}
+
+// SyncFilter limits a triggered sync. Empty fields match everything.
+type SyncFilter struct {
+	Guid        string `json:"guid,omitempty"`
+	ProcessGuid string `json:"process_guid,omitempty"`
+	Domain      string `json:"domain,omitempty"`
+}
+
+func (f SyncFilter) Matches(r generator.Reconciliation) bool {
+	if f.Guid != "" && f.Guid != r.Guid {
+		return false
+	}
+
+	if f.ProcessGuid != "" && f.ProcessGuid != processGuidOf(r) {
+		return false
+	}
+
+	if f.Domain != "" && f.Domain != domainOf(r) {
+		return false
+	}
+
+	return true
+}
+
+func processGuidOf(r generator.Reconciliation) string {
+	switch {
+	case r.InstanceLRP != nil:
+		return r.InstanceLRP.ProcessGuid
+	case r.EvacuatingLRP != nil:
+		return r.EvacuatingLRP.ProcessGuid
+	case r.Container != nil && r.Container.Tags[rep.LifecycleTag] == rep.LRPLifecycle:
+		return r.Container.Tags[rep.ProcessGuidTag]
+	default:
+		return ""
+	}
+}
+
+func domainOf(r generator.Reconciliation) string {
+	switch {
+	case r.InstanceLRP != nil:
+		return r.InstanceLRP.Domain
+	case r.EvacuatingLRP != nil:
+		return r.EvacuatingLRP.Domain
+	case r.Task != nil:
+		return r.Task.Domain
+	case r.Container != nil:
+		return r.Container.Tags[rep.DomainTag]
+	default:
+		return ""
+	}
+}
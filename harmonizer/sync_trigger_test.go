@@ -0,0 +1,73 @@
+package harmonizer_test
+
+import (
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/rep"
+	"github.com/cloudfoundry-incubator/rep/generator"
+	"github.com/cloudfoundry-incubator/rep/harmonizer"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SyncFilter", func() {
+	var (
+		lrpContainer    generator.Reconciliation
+		residualLRP     generator.Reconciliation
+		residualTask    generator.Reconciliation
+		reconciliations []generator.Reconciliation
+	)
+
+	BeforeEach(func() {
+		lrpContainer = generator.Reconciliation{
+			Guid: "process-guid-instance-guid",
+			Container: &executor.Container{
+				Guid: "process-guid-instance-guid",
+				Tags: executor.Tags{
+					rep.LifecycleTag:   rep.LRPLifecycle,
+					rep.ProcessGuidTag: "process-guid",
+					rep.DomainTag:      "domain",
+				},
+			},
+		}
+
+		lrp := models.ActualLRP{ActualLRPKey: models.NewActualLRPKey("other-process-guid", 0, "domain")}
+		residualLRP = generator.Reconciliation{Guid: "other-process-guid-instance-guid", InstanceLRP: &lrp}
+
+		residualTask = generator.Reconciliation{Guid: "task-guid", Task: &models.Task{TaskGuid: "task-guid", Domain: "other-domain"}}
+
+		reconciliations = []generator.Reconciliation{lrpContainer, residualLRP, residualTask}
+	})
+
+	matching := func(filter harmonizer.SyncFilter) []string {
+		guids := []string{}
+		for _, r := range reconciliations {
+			if filter.Matches(r) {
+				guids = append(guids, r.Guid)
+			}
+		}
+		return guids
+	}
+
+	It("matches everything when empty", func() {
+		Ω(matching(harmonizer.SyncFilter{})).Should(ConsistOf(lrpContainer.Guid, residualLRP.Guid, residualTask.Guid))
+	})
+
+	It("matches by guid", func() {
+		Ω(matching(harmonizer.SyncFilter{Guid: "task-guid"})).Should(ConsistOf(residualTask.Guid))
+	})
+
+	It("matches by process guid", func() {
+		Ω(matching(harmonizer.SyncFilter{ProcessGuid: "process-guid"})).Should(ConsistOf(lrpContainer.Guid))
+		Ω(matching(harmonizer.SyncFilter{ProcessGuid: "other-process-guid"})).Should(ConsistOf(residualLRP.Guid))
+	})
+
+	It("matches by domain", func() {
+		Ω(matching(harmonizer.SyncFilter{Domain: "domain"})).Should(ConsistOf(lrpContainer.Guid, residualLRP.Guid))
+	})
+
+	It("requires every field to match", func() {
+		Ω(matching(harmonizer.SyncFilter{ProcessGuid: "process-guid", Domain: "other-domain"})).Should(BeEmpty())
+	})
+})
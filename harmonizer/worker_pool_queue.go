@@ -0,0 +1,289 @@
+package harmonizer
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/rep"
+	"github.com/cloudfoundry-incubator/rep/generator"
+	"github.com/cloudfoundry-incubator/runtime-schema/metric"
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/operationq"
+)
+
+const repOperationTimeouts = metric.Counter("RepOperationTimeouts")
+
+const (
+	lrpLane = iota
+	taskLane
+	laneCount
+)
+
+type WorkerPoolConfig struct {
+	// Workers is the number of operations that may execute at once.
+	Workers int
+
+	// OperationTimeout is how long a worker waits for an operation before
+	// moving on to the next one. Zero waits forever.
+	OperationTimeout time.Duration
+
+	// MaxTimedOut is how many operations that exceeded the timeout may keep
+	// running once their workers have moved on. Beyond it, a worker waits for
+	// its operation however long it takes. Zero allows as many as there are
+	// workers.
+	MaxTimedOut int
+}
+
+// WorkerPoolQueue executes operations on a fixed number of workers.
+//
+// Operations with the same key never execute concurrently, and at most one
+// operation waits per key: pushing a newer one replaces it, as with
+// operationq.NewSlidingQueue(1). Waiting LRP and Task operations are taken in
+// turn, so neither can starve the other.
+//
+// An operation that exceeds the timeout keeps its key until it returns, but no
+// longer holds a worker, so long as no more than MaxTimedOut are still running.
+// At most Workers + MaxTimedOut operations therefore execute at once.
+type WorkerPoolQueue struct {
+	logger lager.Logger
+	clock  clock.Clock
+	config WorkerPoolConfig
+
+	lock     sync.Mutex
+	cond     *sync.Cond
+	waiting  map[string]waitingOperation
+	running  map[string]bool
+	lanes    [laneCount][]laneEntry
+	nextLane int
+	sequence uint64
+	timedOut int
+	stopped  bool
+}
+
+type waitingOperation struct {
+	operation operationq.Operation
+	lane      int
+	sequence  uint64
+}
+
+// laneEntry is a key's place in a lane. It is stale once the key is no longer
+// waiting with the same sequence number, and is dropped when next passed over.
+type laneEntry struct {
+	key      string
+	sequence uint64
+}
+
+func NewWorkerPoolQueue(logger lager.Logger, clock clock.Clock, config WorkerPoolConfig) *WorkerPoolQueue {
+	if config.Workers < 1 {
+		config.Workers = 1
+	}
+
+	if config.MaxTimedOut < 1 {
+		config.MaxTimedOut = config.Workers
+	}
+
+	q := &WorkerPoolQueue{
+		logger:  logger,
+		clock:   clock,
+		config:  config,
+		waiting: make(map[string]waitingOperation),
+		running: make(map[string]bool),
+	}
+	q.cond = sync.NewCond(&q.lock)
+
+	return q
+}
+
+func (q *WorkerPoolQueue) Push(operation operationq.Operation) {
+	key := operation.Key()
+	lane := laneFor(operation)
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if waiting, found := q.waiting[key]; found && waiting.lane == lane {
+		waiting.operation = operation
+		q.waiting[key] = waiting
+		return
+	}
+
+	q.sequence++
+	q.waiting[key] = waitingOperation{operation: operation, lane: lane, sequence: q.sequence}
+	q.lanes[lane] = append(q.lanes[lane], laneEntry{key: key, sequence: q.sequence})
+
+	q.cond.Signal()
+}
+
+func (q *WorkerPoolQueue) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	logger := q.logger.Session("worker-pool-queue", lager.Data{
+		"workers": q.config.Workers,
+		"timeout": q.config.OperationTimeout.String(),
+	})
+	logger.Info("starting")
+	defer logger.Info("finished")
+
+	wg := new(sync.WaitGroup)
+	for i := 0; i < q.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(logger)
+		}()
+	}
+
+	close(ready)
+
+	signal := <-signals
+	logger.Info("received-signal", lager.Data{"signal": signal.String()})
+
+	q.lock.Lock()
+	q.stopped = true
+	q.cond.Broadcast()
+	q.lock.Unlock()
+
+	wg.Wait()
+	return nil
+}
+
+func (q *WorkerPoolQueue) work(logger lager.Logger) {
+	for {
+		key, operation, ok := q.next()
+		if !ok {
+			return
+		}
+
+		q.execute(logger, key, operation)
+	}
+}
+
+// next blocks until an operation whose key is not already executing is
+// waiting, or the queue is stopped.
+func (q *WorkerPoolQueue) next() (string, operationq.Operation, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	for {
+		if q.stopped {
+			return "", nil, false
+		}
+
+		for i := 0; i < laneCount; i++ {
+			lane := (q.nextLane + i) % laneCount
+
+			key, operation, ok := q.takeFromLane(lane)
+			if ok {
+				q.nextLane = (lane + 1) % laneCount
+				return key, operation, true
+			}
+		}
+
+		q.cond.Wait()
+	}
+}
+
+// takeFromLane takes the first operation in the lane whose key is not already
+// executing, dropping the stale entries it passes over.
+func (q *WorkerPoolQueue) takeFromLane(lane int) (string, operationq.Operation, bool) {
+	entries := q.lanes[lane]
+	kept := entries[:0]
+
+	for i, entry := range entries {
+		waiting, found := q.waiting[entry.key]
+		if !found || waiting.sequence != entry.sequence {
+			continue
+		}
+
+		if q.running[entry.key] {
+			kept = append(kept, entry)
+			continue
+		}
+
+		q.lanes[lane] = append(kept, entries[i+1:]...)
+
+		delete(q.waiting, entry.key)
+		q.running[entry.key] = true
+
+		return entry.key, waiting.operation, true
+	}
+
+	q.lanes[lane] = kept
+	return "", nil, false
+}
+
+func (q *WorkerPoolQueue) execute(logger lager.Logger, key string, operation operationq.Operation) {
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		defer q.finished(key)
+		operation.Execute()
+	}()
+
+	if q.config.OperationTimeout <= 0 {
+		<-done
+		return
+	}
+
+	timer := q.clock.NewTimer(q.config.OperationTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return
+	case <-timer.C():
+		logger.Info("operation-timed-out", lager.Data{"key": key})
+		repOperationTimeouts.Increment()
+	}
+
+	if !q.leaveTimedOut() {
+		logger.Info("waiting-for-timed-out-operation", lager.Data{"key": key})
+		<-done
+		return
+	}
+
+	go func() {
+		<-done
+		q.timedOutReturned()
+	}()
+}
+
+// leaveTimedOut reports whether a worker may move on from an operation that
+// exceeded the timeout, counting the operation as timed out if so.
+func (q *WorkerPoolQueue) leaveTimedOut() bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.timedOut >= q.config.MaxTimedOut {
+		return false
+	}
+
+	q.timedOut++
+	return true
+}
+
+func (q *WorkerPoolQueue) timedOutReturned() {
+	q.lock.Lock()
+	q.timedOut--
+	q.lock.Unlock()
+}
+
+func (q *WorkerPoolQueue) finished(key string) {
+	q.lock.Lock()
+	delete(q.running, key)
+	q.cond.Broadcast()
+	q.lock.Unlock()
+}
+
+func laneFor(operation operationq.Operation) int {
+	if instrumented, ok := operation.(*instrumentedOperation); ok {
+		operation = instrumented.Operation
+	}
+
+	if generator.OperationLifecycle(operation) == rep.TaskLifecycle {
+		return taskLane
+	}
+
+	return lrpLane
+}
@@ -0,0 +1,250 @@
+package harmonizer_test
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/rep"
+	"github.com/cloudfoundry-incubator/rep/harmonizer"
+	"github.com/cloudfoundry/dropsonde/metric_sender/fake"
+	"github.com/cloudfoundry/dropsonde/metrics"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager/lagertest"
+	"github.com/pivotal-golang/operationq/fake_operationq"
+	"github.com/tedsuo/ifrit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type lifecycleOperation struct {
+	*fake_operationq.FakeOperation
+	lifecycle string
+}
+
+func (o lifecycleOperation) Lifecycle() string {
+	return o.lifecycle
+}
+
+var _ = Describe("WorkerPoolQueue", func() {
+	var (
+		sender    *fake.FakeMetricSender
+		fakeClock *fakeclock.FakeClock
+		config    harmonizer.WorkerPoolConfig
+
+		queue   *harmonizer.WorkerPoolQueue
+		process ifrit.Process
+
+		executedLock sync.Mutex
+		executed     []string
+	)
+
+	newOperation := func(key, lifecycle string, release <-chan struct{}) lifecycleOperation {
+		operation := new(fake_operationq.FakeOperation)
+		operation.KeyReturns(key)
+		operation.ExecuteStub = func() {
+			executedLock.Lock()
+			executed = append(executed, key)
+			executedLock.Unlock()
+
+			if release != nil {
+				<-release
+			}
+		}
+
+		return lifecycleOperation{FakeOperation: operation, lifecycle: lifecycle}
+	}
+
+	executedOperations := func() []string {
+		executedLock.Lock()
+		defer executedLock.Unlock()
+
+		return append([]string{}, executed...)
+	}
+
+	BeforeEach(func() {
+		sender = fake.NewFakeMetricSender()
+		metrics.Initialize(sender)
+
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		config = harmonizer.WorkerPoolConfig{Workers: 2}
+		executed = nil
+	})
+
+	JustBeforeEach(func() {
+		queue = harmonizer.NewWorkerPoolQueue(lagertest.NewTestLogger("test"), fakeClock, config)
+		process = ifrit.Invoke(queue)
+	})
+
+	AfterEach(func() {
+		process.Signal(os.Interrupt)
+		Eventually(process.Wait()).Should(Receive())
+	})
+
+	It("executes pushed operations", func() {
+		queue.Push(newOperation("guid", rep.LRPLifecycle, nil))
+		Eventually(executedOperations).Should(ConsistOf("guid"))
+	})
+
+	It("executes no more operations at once than there are workers", func() {
+		release := make(chan struct{})
+		defer close(release)
+
+		queue.Push(newOperation("guid-1", rep.LRPLifecycle, release))
+		queue.Push(newOperation("guid-2", rep.LRPLifecycle, release))
+		queue.Push(newOperation("guid-3", rep.LRPLifecycle, release))
+
+		Eventually(executedOperations).Should(HaveLen(2))
+		Consistently(executedOperations).Should(HaveLen(2))
+
+		release <- struct{}{}
+		Eventually(executedOperations).Should(HaveLen(3))
+	})
+
+	It("never executes operations with the same key at once", func() {
+		release := make(chan struct{})
+		defer close(release)
+
+		queue.Push(newOperation("guid", rep.LRPLifecycle, release))
+		Eventually(executedOperations).Should(HaveLen(1))
+
+		queue.Push(newOperation("guid", rep.LRPLifecycle, release))
+		Consistently(executedOperations).Should(HaveLen(1))
+
+		release <- struct{}{}
+		Eventually(executedOperations).Should(HaveLen(2))
+	})
+
+	It("replaces an operation waiting for its key with a newer one", func() {
+		release := make(chan struct{})
+
+		first := newOperation("guid", rep.LRPLifecycle, release)
+		superseded := newOperation("guid", rep.LRPLifecycle, nil)
+		newest := newOperation("guid", rep.LRPLifecycle, nil)
+
+		queue.Push(first)
+		Eventually(first.ExecuteCallCount).Should(Equal(1))
+
+		queue.Push(superseded)
+		queue.Push(newest)
+		close(release)
+
+		Eventually(newest.ExecuteCallCount).Should(Equal(1))
+		Consistently(superseded.ExecuteCallCount).Should(BeZero())
+	})
+
+	It("executes an operation replaced by one for the other lifecycle only once", func() {
+		release := make(chan struct{})
+
+		blocker := newOperation("guid", rep.LRPLifecycle, release)
+		superseded := newOperation("guid", rep.LRPLifecycle, nil)
+		newest := newOperation("guid", rep.TaskLifecycle, nil)
+
+		queue.Push(blocker)
+		Eventually(blocker.ExecuteCallCount).Should(Equal(1))
+
+		queue.Push(superseded)
+		queue.Push(newest)
+		close(release)
+
+		Eventually(newest.ExecuteCallCount).Should(Equal(1))
+		Consistently(superseded.ExecuteCallCount).Should(BeZero())
+		Ω(executedOperations()).Should(Equal([]string{"guid", "guid"}))
+	})
+
+	Context("when LRP and Task operations are waiting", func() {
+		BeforeEach(func() {
+			config.Workers = 1
+		})
+
+		It("takes them in turn", func() {
+			release := make(chan struct{})
+
+			queue.Push(newOperation("blocker", rep.LRPLifecycle, release))
+			Eventually(executedOperations).Should(HaveLen(1))
+
+			queue.Push(newOperation("task-1", rep.TaskLifecycle, nil))
+			queue.Push(newOperation("task-2", rep.TaskLifecycle, nil))
+			queue.Push(newOperation("task-3", rep.TaskLifecycle, nil))
+			queue.Push(newOperation("lrp-1", rep.LRPLifecycle, nil))
+			queue.Push(newOperation("lrp-2", rep.LRPLifecycle, nil))
+			close(release)
+
+			Eventually(executedOperations).Should(Equal([]string{
+				"blocker", "task-1", "lrp-1", "task-2", "lrp-2", "task-3",
+			}))
+		})
+	})
+
+	Context("when an operation exceeds the timeout", func() {
+		var release chan struct{}
+
+		BeforeEach(func() {
+			config.Workers = 1
+			config.OperationTimeout = time.Minute
+			release = make(chan struct{})
+		})
+
+		AfterEach(func() {
+			close(release)
+		})
+
+		It("moves on to the next operation", func() {
+			queue.Push(newOperation("slow-guid", rep.LRPLifecycle, release))
+			Eventually(executedOperations).Should(HaveLen(1))
+
+			queue.Push(newOperation("guid", rep.LRPLifecycle, nil))
+			Consistently(executedOperations).Should(HaveLen(1))
+
+			Eventually(func() []string {
+				fakeClock.Increment(time.Minute)
+				return executedOperations()
+			}).Should(Equal([]string{"slow-guid", "guid"}))
+
+			Ω(sender.GetCounter("RepOperationTimeouts")).Should(BeNumerically(">=", 1))
+		})
+
+		It("keeps the slow operation's key until it returns", func() {
+			queue.Push(newOperation("slow-guid", rep.LRPLifecycle, release))
+			Eventually(executedOperations).Should(HaveLen(1))
+
+			Eventually(func() uint64 {
+				fakeClock.Increment(time.Minute)
+				return sender.GetCounter("RepOperationTimeouts")
+			}).Should(BeNumerically(">=", 1))
+
+			queue.Push(newOperation("slow-guid", rep.LRPLifecycle, nil))
+			Consistently(executedOperations).Should(HaveLen(1))
+		})
+
+		Context("when as many operations as allowed have timed out", func() {
+			BeforeEach(func() {
+				config.MaxTimedOut = 1
+			})
+
+			It("waits for the next slow operation instead of moving on", func() {
+				timeouts := func() uint64 {
+					fakeClock.Increment(time.Minute)
+					return sender.GetCounter("RepOperationTimeouts")
+				}
+
+				queue.Push(newOperation("slow-guid-1", rep.LRPLifecycle, release))
+				Eventually(timeouts).Should(BeNumerically("==", 1))
+
+				slowRelease := make(chan struct{})
+				queue.Push(newOperation("slow-guid-2", rep.LRPLifecycle, slowRelease))
+				Eventually(timeouts).Should(BeNumerically("==", 2))
+
+				queue.Push(newOperation("guid", rep.LRPLifecycle, nil))
+				Consistently(func() []string {
+					fakeClock.Increment(time.Minute)
+					return executedOperations()
+				}).Should(Equal([]string{"slow-guid-1", "slow-guid-2"}))
+
+				close(slowRelease)
+				Eventually(executedOperations).Should(Equal([]string{"slow-guid-1", "slow-guid-2", "guid"}))
+			})
+		})
+	})
+})
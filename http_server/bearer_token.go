@@ -0,0 +1,25 @@
+package http_server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+const bearerPrefix = "Bearer "
+
+// bearerTokenAuthorized reports whether the request bears the token. An empty
+// token authorizes no request.
+func bearerTokenAuthorized(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, bearerPrefix) {
+		return false
+	}
+
+	requestToken := strings.TrimPrefix(authorization, bearerPrefix)
+	return subtle.ConstantTimeCompare([]byte(requestToken), []byte(token)) == 1
+}
@@ -0,0 +1,33 @@
+package http_server
+
+import (
+	"net/http"
+
+	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context"
+	"github.com/pivotal-golang/lager"
+)
+
+type CancelEvacuationHandler struct {
+	evacuatable evacuation_context.Evacuatable
+	logger      lager.Logger
+}
+
+func NewCancelEvacuationHandler(
+	logger lager.Logger,
+	evacuatable evacuation_context.Evacuatable,
+) *CancelEvacuationHandler {
+	return &CancelEvacuationHandler{
+		evacuatable: evacuatable,
+		logger:      logger,
+	}
+}
+
+func (h *CancelEvacuationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := h.logger.Session("handling-cancel-evacuation")
+	logger.Info("starting")
+	defer logger.Info("finished")
+
+	h.evacuatable.CancelEvacuation()
+
+	w.WriteHeader(http.StatusNoContent)
+}
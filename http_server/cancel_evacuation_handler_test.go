@@ -0,0 +1,42 @@
+package http_server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context/fake_evacuation_context"
+	"github.com/cloudfoundry-incubator/rep/http_server"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CancelEvacuationHandler", func() {
+	Describe("ServeHTTP", func() {
+		var (
+			fakeEvacuatable  *fake_evacuation_context.FakeEvacuatable
+			handler          *http_server.CancelEvacuationHandler
+			responseRecorder *httptest.ResponseRecorder
+		)
+
+		BeforeEach(func() {
+			fakeEvacuatable = new(fake_evacuation_context.FakeEvacuatable)
+			handler = http_server.NewCancelEvacuationHandler(lagertest.NewTestLogger("test"), fakeEvacuatable)
+			responseRecorder = httptest.NewRecorder()
+
+			request, err := http.NewRequest("DELETE", "/evacuate", nil)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			handler.ServeHTTP(responseRecorder, request)
+		})
+
+		It("cancels evacuation", func() {
+			Ω(fakeEvacuatable.CancelEvacuationCallCount()).Should(Equal(1))
+		})
+
+		It("responds with 204 NO CONTENT", func() {
+			Ω(responseRecorder.Code).Should(Equal(http.StatusNoContent))
+		})
+	})
+})
@@ -0,0 +1,60 @@
+package http_server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/cloudfoundry-incubator/rep/diagnostics"
+	"github.com/pivotal-golang/lager"
+)
+
+type DiagnosticsHandler struct {
+	logger lager.Logger
+	token  string
+	store  diagnostics.Store
+}
+
+// NewDiagnosticsHandler creates a handler that serves the diagnostics captured
+// for a failed task to requests bearing the given token, as they may include
+// the contents of the task's files. An empty token rejects every request.
+func NewDiagnosticsHandler(logger lager.Logger, token string, store diagnostics.Store) *DiagnosticsHandler {
+	return &DiagnosticsHandler{
+		logger: logger,
+		token:  token,
+		store:  store,
+	}
+}
+
+func (h *DiagnosticsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	taskGuid := r.FormValue(":task_guid")
+
+	logger := h.logger.Session("handling-diagnostics", lager.Data{
+		"task-guid": taskGuid,
+	})
+
+	if !bearerTokenAuthorized(r, h.token) {
+		logger.Info("unauthorized")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	captured, found := h.store.Get(taskGuid)
+	if !found {
+		logger.Info("diagnostics-not-found")
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	jsonBytes, err := json.Marshal(captured)
+	if err != nil {
+		logger.Error("failed-to-marshal-response-payload", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(jsonBytes)))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonBytes)
+}
@@ -0,0 +1,93 @@
+package http_server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/cloudfoundry-incubator/rep/diagnostics"
+	"github.com/cloudfoundry-incubator/rep/http_server"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DiagnosticsHandler", func() {
+	var (
+		store   diagnostics.Store
+		handler *http_server.DiagnosticsHandler
+		resp    *httptest.ResponseRecorder
+		req     *http.Request
+	)
+
+	BeforeEach(func() {
+		store = diagnostics.NewStore(10)
+		handler = http_server.NewDiagnosticsHandler(lagertest.NewTestLogger("test"), "secret-token", store)
+		resp = httptest.NewRecorder()
+
+		var err error
+		req, err = http.NewRequest("GET", "", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		req.Header.Set("Authorization", "Bearer secret-token")
+
+		values := make(url.Values)
+		values.Set(":task_guid", "task-guid")
+		req.URL.RawQuery = values.Encode()
+	})
+
+	JustBeforeEach(func() {
+		handler.ServeHTTP(resp, req)
+	})
+
+	Context("when diagnostics were captured for the task", func() {
+		BeforeEach(func() {
+			store.Put(diagnostics.Diagnostics{
+				TaskGuid:      "task-guid",
+				FailureReason: "boom",
+				Files:         map[string]string{"/tmp/crash.log": "last words"},
+			})
+		})
+
+		It("responds with 200 OK", func() {
+			Ω(resp.Code).Should(Equal(http.StatusOK))
+		})
+
+		It("responds with the diagnostics", func() {
+			var captured diagnostics.Diagnostics
+			err := json.Unmarshal(resp.Body.Bytes(), &captured)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(captured.FailureReason).Should(Equal("boom"))
+			Ω(captured.Files).Should(Equal(map[string]string{"/tmp/crash.log": "last words"}))
+		})
+	})
+
+	Context("when no diagnostics were captured for the task", func() {
+		It("responds with 404 Not Found", func() {
+			Ω(resp.Code).Should(Equal(http.StatusNotFound))
+		})
+	})
+	Context("when the request does not bear the token", func() {
+		BeforeEach(func() {
+			store.Put(diagnostics.Diagnostics{TaskGuid: "task-guid", FailureReason: "boom"})
+			req.Header.Set("Authorization", "Bearer wrong-token")
+		})
+
+		It("responds with 401 Unauthorized", func() {
+			Ω(resp.Code).Should(Equal(http.StatusUnauthorized))
+			Ω(resp.Body.Len()).Should(BeZero())
+		})
+	})
+
+	Context("when no token is configured", func() {
+		BeforeEach(func() {
+			store.Put(diagnostics.Diagnostics{TaskGuid: "task-guid", FailureReason: "boom"})
+			handler = http_server.NewDiagnosticsHandler(lagertest.NewTestLogger("test"), "", store)
+		})
+
+		It("responds with 401 Unauthorized", func() {
+			Ω(resp.Code).Should(Equal(http.StatusUnauthorized))
+		})
+	})
+})
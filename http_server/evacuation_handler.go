@@ -29,7 +29,19 @@ func (h *EvacuationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	logger.Info("starting")
 	defer logger.Info("finished")
 
-	h.evacuatable.Evacuate()
+	query := r.URL.Query()
+	scope := evacuation_context.Scope{
+		Domains:       query["domain"],
+		ProcessGuids:  query["process_guid"],
+		InstanceGuids: query["instance_guid"],
+	}
+
+	if scope.All() {
+		h.evacuatable.Evacuate()
+	} else {
+		logger.Info("evacuating-scope", lager.Data{"scope": scope})
+		h.evacuatable.EvacuateScope(scope)
+	}
 
 	jsonBytes, err := json.Marshal(map[string]string{"ping_path": "/ping"})
 	if err != nil {
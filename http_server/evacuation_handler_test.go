@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 
+	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context"
 	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context/fake_evacuation_context"
 	"github.com/cloudfoundry-incubator/rep/http_server"
 	"github.com/pivotal-golang/lager/lagertest"
@@ -57,5 +58,31 @@ var _ = Describe("EvacuationHandler", func() {
 				Ω(responseValues["ping_path"]).Should(Equal("/ping"))
 			})
 		})
+
+		Context("when receiving a request scoped to domains, processes or instances", func() {
+			BeforeEach(func() {
+				responseRecorder = httptest.NewRecorder()
+
+				var err error
+				request, err = http.NewRequest("POST", "/evacuate?domain=domain-1&domain=domain-2&process_guid=process-guid&instance_guid=instance-guid", nil)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				handler.ServeHTTP(responseRecorder, request)
+			})
+
+			It("evacuates only that scope", func() {
+				Ω(fakeEvacuatable.EvacuateCallCount()).Should(Equal(0))
+				Ω(fakeEvacuatable.EvacuateScopeCallCount()).Should(Equal(1))
+				Ω(fakeEvacuatable.EvacuateScopeArgsForCall(0)).Should(Equal(evacuation_context.Scope{
+					Domains:       []string{"domain-1", "domain-2"},
+					ProcessGuids:  []string{"process-guid"},
+					InstanceGuids: []string{"instance-guid"},
+				}))
+			})
+
+			It("responds with 202 ACCEPTED", func() {
+				Ω(responseRecorder.Code).Should(Equal(http.StatusAccepted))
+			})
+		})
 	})
 })
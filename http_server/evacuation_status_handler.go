@@ -0,0 +1,47 @@
+package http_server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/cloudfoundry-incubator/rep/evacuation"
+	"github.com/pivotal-golang/lager"
+)
+
+type EvacuationStatusHandler struct {
+	logger         lager.Logger
+	statusReporter evacuation.StatusReporter
+}
+
+func NewEvacuationStatusHandler(logger lager.Logger, statusReporter evacuation.StatusReporter) *EvacuationStatusHandler {
+	return &EvacuationStatusHandler{
+		logger:         logger,
+		statusReporter: statusReporter,
+	}
+}
+
+func (h *EvacuationStatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := h.logger.Session("handling-evacuation-status")
+	logger.Info("starting")
+	defer logger.Info("finished")
+
+	status, err := h.statusReporter.Status(logger)
+	if err != nil {
+		logger.Error("failed-to-get-status", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	jsonBytes, err := json.Marshal(status)
+	if err != nil {
+		logger.Error("failed-to-marshal-response-payload", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(jsonBytes)))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonBytes)
+}
@@ -0,0 +1,75 @@
+package http_server_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/cloudfoundry-incubator/rep/evacuation"
+	"github.com/cloudfoundry-incubator/rep/evacuation/fake_evacuation"
+	"github.com/cloudfoundry-incubator/rep/http_server"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EvacuationStatusHandler", func() {
+	var (
+		statusReporter *fake_evacuation.FakeStatusReporter
+		handler        *http_server.EvacuationStatusHandler
+		resp           *httptest.ResponseRecorder
+		req            *http.Request
+	)
+
+	BeforeEach(func() {
+		statusReporter = new(fake_evacuation.FakeStatusReporter)
+		handler = http_server.NewEvacuationStatusHandler(lagertest.NewTestLogger("test"), statusReporter)
+		resp = httptest.NewRecorder()
+
+		var err error
+		req, err = http.NewRequest("GET", "/evacuation", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	JustBeforeEach(func() {
+		handler.ServeHTTP(resp, req)
+	})
+
+	Context("when the status can be determined", func() {
+		BeforeEach(func() {
+			statusReporter.StatusReturns(evacuation.Status{
+				Active:                 true,
+				TimeRemainingInSeconds: 42,
+				Containers: evacuation.ContainerCounts{
+					Total:       1,
+					ByLifecycle: map[string]int{"lrp": 1},
+				},
+			}, nil)
+		})
+
+		It("responds with 200 OK", func() {
+			Ω(resp.Code).Should(Equal(http.StatusOK))
+		})
+
+		It("responds with the status", func() {
+			var status evacuation.Status
+			err := json.Unmarshal(resp.Body.Bytes(), &status)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(status.Active).Should(BeTrue())
+			Ω(status.TimeRemainingInSeconds).Should(BeEquivalentTo(42))
+			Ω(status.Containers.ByLifecycle).Should(Equal(map[string]int{"lrp": 1}))
+		})
+	})
+
+	Context("when the status cannot be determined", func() {
+		BeforeEach(func() {
+			statusReporter.StatusReturns(evacuation.Status{}, errors.New("boom"))
+		})
+
+		It("responds with 500 Internal Server Error", func() {
+			Ω(resp.Code).Should(Equal(http.StatusInternalServerError))
+		})
+	})
+})
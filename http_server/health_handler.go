@@ -0,0 +1,124 @@
+package http_server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+// HealthCheck checks one dependency or subsystem of the rep, returning an
+// error when it is unhealthy.
+type HealthCheck func(logger lager.Logger) error
+
+type HealthResponse struct {
+	Healthy bool                         `json:"healthy"`
+	Checks  map[string]HealthCheckResult `json:"checks"`
+}
+
+type HealthCheckResult struct {
+	Healthy              bool   `json:"healthy"`
+	LatencyInNanoseconds int64  `json:"latency_in_nanoseconds"`
+	Error                string `json:"error,omitempty"`
+}
+
+// HealthHandler runs every check at once, responding 200 OK when all of them
+// pass and 503 Service Unavailable otherwise. A check that has not returned
+// within the timeout is reported as failed.
+type HealthHandler struct {
+	logger  lager.Logger
+	clock   clock.Clock
+	timeout time.Duration
+	checks  map[string]HealthCheck
+}
+
+func NewHealthHandler(logger lager.Logger, clock clock.Clock, timeout time.Duration, checks map[string]HealthCheck) *HealthHandler {
+	return &HealthHandler{
+		logger:  logger,
+		clock:   clock,
+		timeout: timeout,
+		checks:  checks,
+	}
+}
+
+func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := h.logger.Session("handling-health")
+
+	response := HealthResponse{
+		Healthy: true,
+		Checks:  make(map[string]HealthCheckResult, len(h.checks)),
+	}
+
+	lock := new(sync.Mutex)
+	wg := new(sync.WaitGroup)
+
+	for name, check := range h.checks {
+		wg.Add(1)
+		go func(name string, check HealthCheck) {
+			defer wg.Done()
+
+			result := h.run(logger.Session(name), check)
+
+			lock.Lock()
+			response.Checks[name] = result
+			if !result.Healthy {
+				response.Healthy = false
+			}
+			lock.Unlock()
+		}(name, check)
+	}
+
+	wg.Wait()
+
+	status := http.StatusOK
+	if !response.Healthy {
+		logger.Info("unhealthy", lager.Data{"checks": response.Checks})
+		status = http.StatusServiceUnavailable
+	}
+
+	jsonBytes, err := json.Marshal(response)
+	if err != nil {
+		logger.Error("failed-to-marshal-response-payload", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(jsonBytes)))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(jsonBytes)
+}
+
+func (h *HealthHandler) run(logger lager.Logger, check HealthCheck) HealthCheckResult {
+	timer := h.clock.NewTimer(h.timeout)
+	defer timer.Stop()
+
+	startTime := h.clock.Now()
+
+	// the check is left to finish on its own when it times out
+	errs := make(chan error, 1)
+	go func() {
+		errs <- check(logger)
+	}()
+
+	var err error
+	select {
+	case err = <-errs:
+	case <-timer.C():
+		err = fmt.Errorf("timed out after %s", h.timeout)
+	}
+
+	latency := h.clock.Now().Sub(startTime)
+
+	if err != nil {
+		logger.Error("failed", err)
+		return HealthCheckResult{Healthy: false, LatencyInNanoseconds: int64(latency), Error: err.Error()}
+	}
+
+	return HealthCheckResult{Healthy: true, LatencyInNanoseconds: int64(latency)}
+}
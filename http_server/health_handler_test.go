@@ -0,0 +1,148 @@
+package http_server_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/cloudfoundry-incubator/rep/http_server"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HealthHandler", func() {
+	const timeout = 5 * time.Second
+
+	var (
+		fakeClock *fakeclock.FakeClock
+		checks    map[string]http_server.HealthCheck
+		resp      *httptest.ResponseRecorder
+		req       *http.Request
+		served    chan struct{}
+	)
+
+	BeforeEach(func() {
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		checks = map[string]http_server.HealthCheck{
+			"executor": func(lager.Logger) error {
+				fakeClock.Increment(time.Millisecond)
+				return nil
+			},
+			"bbs": func(lager.Logger) error {
+				return nil
+			},
+		}
+		resp = httptest.NewRecorder()
+
+		var err error
+		req, err = http.NewRequest("GET", "/health", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	JustBeforeEach(func() {
+		handler := http_server.NewHealthHandler(lagertest.NewTestLogger("test"), fakeClock, timeout, checks)
+
+		served = make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			handler.ServeHTTP(resp, req)
+			close(served)
+		}()
+	})
+
+	decodeResponse := func() http_server.HealthResponse {
+		var response http_server.HealthResponse
+		err := json.Unmarshal(resp.Body.Bytes(), &response)
+		Ω(err).ShouldNot(HaveOccurred())
+		return response
+	}
+
+	Context("when every check passes", func() {
+		JustBeforeEach(func() {
+			Eventually(served).Should(BeClosed())
+		})
+
+		It("responds with 200 OK", func() {
+			Ω(resp.Code).Should(Equal(http.StatusOK))
+		})
+
+		It("reports each check as healthy", func() {
+			response := decodeResponse()
+			Ω(response.Healthy).Should(BeTrue())
+			Ω(response.Checks).Should(HaveLen(2))
+			Ω(response.Checks["executor"].Healthy).Should(BeTrue())
+			Ω(response.Checks["bbs"].Healthy).Should(BeTrue())
+		})
+
+		It("reports how long each check took", func() {
+			response := decodeResponse()
+			Ω(response.Checks["executor"].LatencyInNanoseconds).Should(BeNumerically(">=", int64(time.Millisecond)))
+		})
+	})
+
+	Context("when a check fails", func() {
+		BeforeEach(func() {
+			checks["bbs"] = func(lager.Logger) error {
+				return errors.New("etcd is down")
+			}
+		})
+
+		JustBeforeEach(func() {
+			Eventually(served).Should(BeClosed())
+		})
+
+		It("responds with 503 Service Unavailable", func() {
+			Ω(resp.Code).Should(Equal(http.StatusServiceUnavailable))
+		})
+
+		It("reports which check failed and why", func() {
+			response := decodeResponse()
+			Ω(response.Healthy).Should(BeFalse())
+			Ω(response.Checks["executor"].Healthy).Should(BeTrue())
+			Ω(response.Checks["bbs"].Healthy).Should(BeFalse())
+			Ω(response.Checks["bbs"].Error).Should(Equal("etcd is down"))
+		})
+	})
+	Context("when a check does not return in time", func() {
+		var release chan struct{}
+
+		BeforeEach(func() {
+			release = make(chan struct{})
+			checks["bbs"] = func(lager.Logger) error {
+				<-release
+				return nil
+			}
+		})
+
+		JustBeforeEach(func() {
+			Consistently(served).ShouldNot(BeClosed())
+
+			Eventually(func() <-chan struct{} {
+				fakeClock.Increment(timeout)
+				return served
+			}).Should(BeClosed())
+		})
+
+		AfterEach(func() {
+			close(release)
+		})
+
+		It("responds with 503 Service Unavailable", func() {
+			Ω(resp.Code).Should(Equal(http.StatusServiceUnavailable))
+		})
+
+		It("reports the check as timed out", func() {
+			response := decodeResponse()
+			Ω(response.Healthy).Should(BeFalse())
+			Ω(response.Checks["executor"].Healthy).Should(BeTrue())
+			Ω(response.Checks["bbs"].Healthy).Should(BeFalse())
+			Ω(response.Checks["bbs"].Error).Should(Equal("timed out after 5s"))
+		})
+	})
+})
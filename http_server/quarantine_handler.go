@@ -0,0 +1,38 @@
+package http_server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/cloudfoundry-incubator/rep/quarantine"
+	"github.com/pivotal-golang/lager"
+)
+
+type QuarantineHandler struct {
+	logger     lager.Logger
+	quarantine quarantine.Quarantine
+}
+
+func NewQuarantineHandler(logger lager.Logger, quarantine quarantine.Quarantine) *QuarantineHandler {
+	return &QuarantineHandler{
+		logger:     logger,
+		quarantine: quarantine,
+	}
+}
+
+func (h *QuarantineHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := h.logger.Session("handling-quarantine")
+
+	jsonBytes, err := json.Marshal(h.quarantine.Entries())
+	if err != nil {
+		logger.Error("failed-to-marshal-response-payload", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(jsonBytes)))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonBytes)
+}
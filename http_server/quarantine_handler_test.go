@@ -0,0 +1,69 @@
+package http_server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/rep/http_server"
+	"github.com/cloudfoundry-incubator/rep/quarantine"
+	"github.com/cloudfoundry-incubator/rep/quarantine/fake_quarantine"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("QuarantineHandler", func() {
+	Describe("ServeHTTP", func() {
+		var (
+			logger         *lagertest.TestLogger
+			fakeQuarantine *fake_quarantine.FakeQuarantine
+			handler        *http_server.QuarantineHandler
+			entries        []quarantine.Entry
+
+			responseRecorder *httptest.ResponseRecorder
+			request          *http.Request
+		)
+
+		BeforeEach(func() {
+			logger = lagertest.NewTestLogger("test")
+			fakeQuarantine = new(fake_quarantine.FakeQuarantine)
+			handler = http_server.NewQuarantineHandler(logger, fakeQuarantine)
+
+			firstSeen := time.Unix(1234, 0).UTC()
+			entries = []quarantine.Entry{
+				{
+					Guid:      "container-guid",
+					State:     executor.StateInvalid,
+					FirstSeen: firstSeen,
+					LastSeen:  firstSeen.Add(time.Minute),
+				},
+			}
+			fakeQuarantine.EntriesReturns(entries)
+
+			responseRecorder = httptest.NewRecorder()
+
+			var err error
+			request, err = http.NewRequest("GET", "/quarantine", nil)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		JustBeforeEach(func() {
+			handler.ServeHTTP(responseRecorder, request)
+		})
+
+		It("responds with 200 OK", func() {
+			Ω(responseRecorder.Code).Should(Equal(http.StatusOK))
+		})
+
+		It("responds with the quarantined containers", func() {
+			var response []quarantine.Entry
+			err := json.Unmarshal(responseRecorder.Body.Bytes(), &response)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(response).Should(Equal(entries))
+		})
+	})
+})
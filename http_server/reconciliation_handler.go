@@ -0,0 +1,63 @@
+package http_server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/cloudfoundry-incubator/rep/generator"
+	"github.com/pivotal-golang/lager"
+)
+
+type ReconciliationHandler struct {
+	logger    lager.Logger
+	token     string
+	generator generator.Generator
+}
+
+// NewReconciliationHandler creates a handler that reports a summary of the
+// cell's reconciliation to requests bearing the given token. An empty token
+// rejects every request.
+func NewReconciliationHandler(logger lager.Logger, token string, generator generator.Generator) *ReconciliationHandler {
+	return &ReconciliationHandler{
+		logger:    logger,
+		token:     token,
+		generator: generator,
+	}
+}
+
+func (h *ReconciliationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := h.logger.Session("handling-reconciliation")
+	logger.Info("starting")
+	defer logger.Info("finished")
+
+	if !bearerTokenAuthorized(r, h.token) {
+		logger.Info("unauthorized")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	diff, err := h.generator.Diff(logger)
+	if err != nil {
+		logger.Error("failed-to-diff", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	summaries := make(map[string]generator.ReconciliationSummary, len(diff))
+	for guid, reconciliation := range diff {
+		summaries[guid] = reconciliation.Summary()
+	}
+
+	jsonBytes, err := json.Marshal(summaries)
+	if err != nil {
+		logger.Error("failed-to-marshal-response-payload", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(jsonBytes)))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonBytes)
+}
@@ -0,0 +1,117 @@
+package http_server_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/rep/generator"
+	"github.com/cloudfoundry-incubator/rep/generator/fake_generator"
+	"github.com/cloudfoundry-incubator/rep/http_server"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReconciliationHandler", func() {
+	Describe("ServeHTTP", func() {
+		var (
+			logger        *lagertest.TestLogger
+			fakeGenerator *fake_generator.FakeGenerator
+			handler       *http_server.ReconciliationHandler
+
+			responseRecorder *httptest.ResponseRecorder
+			request          *http.Request
+		)
+
+		BeforeEach(func() {
+			logger = lagertest.NewTestLogger("test")
+			fakeGenerator = new(fake_generator.FakeGenerator)
+			handler = http_server.NewReconciliationHandler(logger, "secret-token", fakeGenerator)
+
+			responseRecorder = httptest.NewRecorder()
+
+			var err error
+			request, err = http.NewRequest("GET", "/reconciliation", nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			request.Header.Set("Authorization", "Bearer secret-token")
+		})
+
+		JustBeforeEach(func() {
+			handler.ServeHTTP(responseRecorder, request)
+		})
+
+		Context("when diffing succeeds", func() {
+			BeforeEach(func() {
+				fakeGenerator.DiffReturns(map[string]generator.Reconciliation{
+					"container-guid": {
+						Guid: "container-guid",
+						Container: &executor.Container{
+							Guid:  "container-guid",
+							State: executor.StateRunning,
+							Env:   []executor.EnvironmentVariable{{Name: "PASSWORD", Value: "hunter2"}},
+						},
+						OperationType: generator.ContainerOperationType,
+					},
+					"task-guid": {
+						Guid: "task-guid",
+						Task: &models.Task{
+							TaskGuid: "task-guid",
+							CellID:   "some-cell",
+							State:    models.TaskStateRunning,
+							Action:   &models.RunAction{Path: "ls", Env: []models.EnvironmentVariable{{Name: "PASSWORD", Value: "hunter2"}}},
+						},
+						OperationType: generator.ResidualTaskOperationType,
+					},
+				}, nil)
+			})
+
+			It("responds with 200 OK", func() {
+				Ω(responseRecorder.Code).Should(Equal(http.StatusOK))
+			})
+
+			It("returns a summary of the diff keyed by guid", func() {
+				var diff map[string]generator.ReconciliationSummary
+				err := json.Unmarshal(responseRecorder.Body.Bytes(), &diff)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(diff).Should(HaveLen(2))
+				Ω(diff["container-guid"].Container.State).Should(Equal(string(executor.StateRunning)))
+				Ω(diff["container-guid"].OperationType).Should(Equal(generator.ContainerOperationType))
+				Ω(diff["task-guid"].Task).Should(Equal(&generator.RecordSummary{State: "RUNNING", CellID: "some-cell"}))
+				Ω(diff["task-guid"].Container).Should(BeNil())
+				Ω(diff["task-guid"].OperationType).Should(Equal(generator.ResidualTaskOperationType))
+			})
+
+			It("does not expose the records themselves", func() {
+				Ω(responseRecorder.Body.String()).ShouldNot(ContainSubstring("hunter2"))
+				Ω(responseRecorder.Body.String()).ShouldNot(ContainSubstring("PASSWORD"))
+			})
+		})
+
+		Context("when the request does not bear the token", func() {
+			BeforeEach(func() {
+				request.Header.Set("Authorization", "Bearer wrong-token")
+			})
+
+			It("responds with 401 Unauthorized without diffing", func() {
+				Ω(responseRecorder.Code).Should(Equal(http.StatusUnauthorized))
+				Ω(fakeGenerator.DiffCallCount()).Should(BeZero())
+			})
+		})
+
+		Context("when diffing fails", func() {
+			BeforeEach(func() {
+				fakeGenerator.DiffReturns(nil, errors.New("nope"))
+			})
+
+			It("responds with 500 Internal Server Error", func() {
+				Ω(responseRecorder.Code).Should(Equal(http.StatusInternalServerError))
+			})
+		})
+	})
+})
@@ -0,0 +1,70 @@
+package http_server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/cloudfoundry-incubator/rep/harmonizer"
+	"github.com/pivotal-golang/lager"
+)
+
+type SyncHandler struct {
+	logger      lager.Logger
+	token       string
+	syncTrigger harmonizer.SyncTrigger
+}
+
+// NewSyncHandler creates a handler that triggers an immediate sync for
+// requests bearing the given token. An empty token rejects every request.
+func NewSyncHandler(logger lager.Logger, token string, syncTrigger harmonizer.SyncTrigger) *SyncHandler {
+	return &SyncHandler{
+		logger:      logger,
+		token:       token,
+		syncTrigger: syncTrigger,
+	}
+}
+
+func (h *SyncHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := h.logger.Session("handling-sync")
+	logger.Info("starting")
+	defer logger.Info("finished")
+
+	if !bearerTokenAuthorized(r, h.token) {
+		logger.Info("unauthorized")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	filter := harmonizer.SyncFilter{
+		Guid:        r.FormValue("guid"),
+		ProcessGuid: r.FormValue("process_guid"),
+		Domain:      r.FormValue("domain"),
+	}
+
+	queued, err := h.syncTrigger.Trigger(logger, filter)
+	if err == harmonizer.ErrBulkerNotRunning {
+		logger.Error("bulker-not-running", err)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	} else if err != nil {
+		logger.Error("failed-to-sync", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	jsonBytes, err := json.Marshal(map[string]interface{}{
+		"filter": filter,
+		"queued": queued,
+	})
+	if err != nil {
+		logger.Error("failed-to-marshal-response-payload", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(jsonBytes)))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonBytes)
+}
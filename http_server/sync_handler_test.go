@@ -0,0 +1,118 @@
+package http_server_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/cloudfoundry-incubator/rep/harmonizer"
+	"github.com/cloudfoundry-incubator/rep/harmonizer/fake_harmonizer"
+	"github.com/cloudfoundry-incubator/rep/http_server"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SyncHandler", func() {
+	var (
+		token       string
+		syncTrigger *fake_harmonizer.FakeSyncTrigger
+		handler     *http_server.SyncHandler
+		resp        *httptest.ResponseRecorder
+		req         *http.Request
+	)
+
+	BeforeEach(func() {
+		token = "secret-token"
+		syncTrigger = new(fake_harmonizer.FakeSyncTrigger)
+		syncTrigger.TriggerReturns(3, nil)
+		resp = httptest.NewRecorder()
+
+		var err error
+		req, err = http.NewRequest("POST", "/sync?guid=some-guid&process_guid=some-process-guid&domain=some-domain", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		req.Header.Set("Authorization", "Bearer secret-token")
+	})
+
+	JustBeforeEach(func() {
+		handler = http_server.NewSyncHandler(lagertest.NewTestLogger("test"), token, syncTrigger)
+		handler.ServeHTTP(resp, req)
+	})
+
+	It("triggers a sync with the requested filter", func() {
+		Ω(syncTrigger.TriggerCallCount()).Should(Equal(1))
+		_, filter := syncTrigger.TriggerArgsForCall(0)
+		Ω(filter).Should(Equal(harmonizer.SyncFilter{
+			Guid:        "some-guid",
+			ProcessGuid: "some-process-guid",
+			Domain:      "some-domain",
+		}))
+	})
+
+	It("responds with the number of operations queued", func() {
+		Ω(resp.Code).Should(Equal(http.StatusOK))
+
+		var body struct {
+			Queued int `json:"queued"`
+		}
+		err := json.Unmarshal(resp.Body.Bytes(), &body)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(body.Queued).Should(Equal(3))
+	})
+
+	Context("when the token is wrong", func() {
+		BeforeEach(func() {
+			req.Header.Set("Authorization", "Bearer wrong-token")
+		})
+
+		It("responds with 401 Unauthorized without syncing", func() {
+			Ω(resp.Code).Should(Equal(http.StatusUnauthorized))
+			Ω(syncTrigger.TriggerCallCount()).Should(BeZero())
+		})
+	})
+
+	Context("when no token is given", func() {
+		BeforeEach(func() {
+			req.Header.Del("Authorization")
+		})
+
+		It("responds with 401 Unauthorized without syncing", func() {
+			Ω(resp.Code).Should(Equal(http.StatusUnauthorized))
+			Ω(syncTrigger.TriggerCallCount()).Should(BeZero())
+		})
+	})
+
+	Context("when no token is configured", func() {
+		BeforeEach(func() {
+			token = ""
+			req.Header.Set("Authorization", "Bearer ")
+		})
+
+		It("responds with 401 Unauthorized without syncing", func() {
+			Ω(resp.Code).Should(Equal(http.StatusUnauthorized))
+			Ω(syncTrigger.TriggerCallCount()).Should(BeZero())
+		})
+	})
+
+	Context("when the bulker is not running", func() {
+		BeforeEach(func() {
+			syncTrigger.TriggerReturns(0, harmonizer.ErrBulkerNotRunning)
+		})
+
+		It("responds with 503 Service Unavailable", func() {
+			Ω(resp.Code).Should(Equal(http.StatusServiceUnavailable))
+		})
+	})
+
+	Context("when the sync fails", func() {
+		BeforeEach(func() {
+			syncTrigger.TriggerReturns(0, errors.New("boom"))
+		})
+
+		It("responds with 500 Internal Server Error", func() {
+			Ω(resp.Code).Should(Equal(http.StatusInternalServerError))
+		})
+	})
+})
@@ -0,0 +1,104 @@
+package lrp_stopper
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/rep"
+	"github.com/cloudfoundry-incubator/runtime-schema/bbs"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+// DrainConfig holds the cell-wide drain period and its per-domain overrides.
+type DrainConfig struct {
+	Period  time.Duration
+	Domains map[string]time.Duration
+}
+
+func (c DrainConfig) PeriodFor(domain string) time.Duration {
+	period, ok := c.Domains[domain]
+	if !ok {
+		return c.Period
+	}
+	return period
+}
+
+//go:generate counterfeiter -o fake_lrp_stopper/fake_drainer.go . Drainer
+type Drainer interface {
+	// DrainAndStop marks the instance as stopping, withdraws its net info from
+	// the BBS, and calls stop once the drain period for its domain has elapsed.
+	DrainAndStop(logger lager.Logger, lrpKey models.ActualLRPKey, instanceKey models.ActualLRPInstanceKey, stop func() error) error
+
+	// Draining reports whether the instance running in the container is being drained.
+	Draining(containerGuid string) bool
+}
+
+type drainer struct {
+	bbs    bbs.RepBBS
+	clock  clock.Clock
+	config DrainConfig
+
+	draining map[string]struct{}
+	mu       sync.Mutex
+}
+
+func NewDrainer(bbs bbs.RepBBS, clock clock.Clock, config DrainConfig) Drainer {
+	return &drainer{
+		bbs:      bbs,
+		clock:    clock,
+		config:   config,
+		draining: map[string]struct{}{},
+	}
+}
+
+func (d *drainer) DrainAndStop(logger lager.Logger, lrpKey models.ActualLRPKey, instanceKey models.ActualLRPInstanceKey, stop func() error) error {
+	period := d.config.PeriodFor(lrpKey.Domain)
+	if period <= 0 {
+		return stop()
+	}
+
+	containerGuid := rep.LRPContainerGuid(lrpKey.ProcessGuid, instanceKey.InstanceGuid)
+
+	logger = logger.Session("drain", lager.Data{
+		"container-guid": containerGuid,
+		"drain-period":   period.String(),
+	})
+
+	d.mu.Lock()
+	_, alreadyDraining := d.draining[containerGuid]
+	d.draining[containerGuid] = struct{}{}
+	d.mu.Unlock()
+
+	if alreadyDraining {
+		logger.Info("already-draining")
+		return nil
+	}
+
+	defer func() {
+		d.mu.Lock()
+		delete(d.draining, containerGuid)
+		d.mu.Unlock()
+	}()
+
+	logger.Info("withdrawing-net-info")
+	err := d.bbs.ClaimActualLRP(logger, lrpKey, instanceKey)
+	if err != nil {
+		logger.Error("failed-withdrawing-net-info", err)
+	}
+
+	logger.Info("draining")
+	d.clock.Sleep(period)
+	logger.Info("drained")
+
+	return stop()
+}
+
+func (d *drainer) Draining(containerGuid string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, draining := d.draining[containerGuid]
+	return draining
+}
@@ -0,0 +1,102 @@
+package lrp_stopper_test
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/rep"
+	"github.com/cloudfoundry-incubator/rep/lrp_stopper"
+	"github.com/cloudfoundry-incubator/runtime-schema/bbs/fake_bbs"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Drainer", func() {
+	var (
+		bbs         *fake_bbs.FakeRepBBS
+		fakeClock   *fakeclock.FakeClock
+		logger      *lagertest.TestLogger
+		config      lrp_stopper.DrainConfig
+		drainer     lrp_stopper.Drainer
+		lrpKey      models.ActualLRPKey
+		instanceKey models.ActualLRPInstanceKey
+
+		stopCount int
+		stop      func() error
+		errCh     chan error
+	)
+
+	BeforeEach(func() {
+		bbs = new(fake_bbs.FakeRepBBS)
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		logger = lagertest.NewTestLogger("test")
+		config = lrp_stopper.DrainConfig{
+			Period:  10 * time.Second,
+			Domains: map[string]time.Duration{"fast-domain": 0},
+		}
+
+		lrpKey = models.NewActualLRPKey("some-process-guid", 1, "some-domain")
+		instanceKey = models.NewActualLRPInstanceKey("some-instance-guid", "some-cell-id")
+
+		stopCount = 0
+		stop = func() error {
+			stopCount++
+			return nil
+		}
+		errCh = make(chan error, 1)
+	})
+
+	JustBeforeEach(func() {
+		drainer = lrp_stopper.NewDrainer(bbs, fakeClock, config)
+	})
+
+	drain := func() {
+		go func() {
+			errCh <- drainer.DrainAndStop(logger, lrpKey, instanceKey, stop)
+		}()
+	}
+
+	Describe("DrainAndStop", func() {
+		It("withdraws the net info before the drain period elapses", func() {
+			drain()
+
+			Eventually(bbs.ClaimActualLRPCallCount).Should(Equal(1))
+			_, key, iKey := bbs.ClaimActualLRPArgsForCall(0)
+			Ω(key).Should(Equal(lrpKey))
+			Ω(iKey).Should(Equal(instanceKey))
+
+			Consistently(errCh).ShouldNot(Receive())
+		})
+
+		It("marks the instance as draining until it has been stopped", func() {
+			containerGuid := rep.LRPContainerGuid(lrpKey.ProcessGuid, instanceKey.InstanceGuid)
+			drain()
+
+			Eventually(func() bool { return drainer.Draining(containerGuid) }).Should(BeTrue())
+
+			Eventually(func() int {
+				fakeClock.Increment(10 * time.Second)
+				return len(errCh)
+			}).Should(Equal(1))
+			Ω(<-errCh).ShouldNot(HaveOccurred())
+
+			Ω(stopCount).Should(Equal(1))
+			Ω(drainer.Draining(containerGuid)).Should(BeFalse())
+		})
+
+		Context("when the domain has no drain period", func() {
+			BeforeEach(func() {
+				lrpKey.Domain = "fast-domain"
+			})
+
+			It("stops immediately without withdrawing the net info", func() {
+				Ω(drainer.DrainAndStop(logger, lrpKey, instanceKey, stop)).Should(Succeed())
+				Ω(stopCount).Should(Equal(1))
+				Ω(bbs.ClaimActualLRPCallCount()).Should(Equal(0))
+			})
+		})
+	})
+})
@@ -0,0 +1,101 @@
+// This file was generated by counterfeiter
+package fake_lrp_stopper
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/rep/lrp_stopper"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeDrainer struct {
+	DrainAndStopStub        func(logger lager.Logger, lrpKey models.ActualLRPKey, instanceKey models.ActualLRPInstanceKey, stop func() error) error
+	drainAndStopMutex       sync.RWMutex
+	drainAndStopArgsForCall []struct {
+		logger      lager.Logger
+		lrpKey      models.ActualLRPKey
+		instanceKey models.ActualLRPInstanceKey
+		stop        func() error
+	}
+	drainAndStopReturns struct {
+		result1 error
+	}
+	DrainingStub        func(containerGuid string) bool
+	drainingMutex       sync.RWMutex
+	drainingArgsForCall []struct {
+		containerGuid string
+	}
+	drainingReturns struct {
+		result1 bool
+	}
+}
+
+func (fake *FakeDrainer) DrainAndStop(logger lager.Logger, lrpKey models.ActualLRPKey, instanceKey models.ActualLRPInstanceKey, stop func() error) error {
+	fake.drainAndStopMutex.Lock()
+	fake.drainAndStopArgsForCall = append(fake.drainAndStopArgsForCall, struct {
+		logger      lager.Logger
+		lrpKey      models.ActualLRPKey
+		instanceKey models.ActualLRPInstanceKey
+		stop        func() error
+	}{logger, lrpKey, instanceKey, stop})
+	fake.drainAndStopMutex.Unlock()
+	if fake.DrainAndStopStub != nil {
+		return fake.DrainAndStopStub(logger, lrpKey, instanceKey, stop)
+	} else {
+		return fake.drainAndStopReturns.result1
+	}
+}
+
+func (fake *FakeDrainer) DrainAndStopCallCount() int {
+	fake.drainAndStopMutex.RLock()
+	defer fake.drainAndStopMutex.RUnlock()
+	return len(fake.drainAndStopArgsForCall)
+}
+
+func (fake *FakeDrainer) DrainAndStopArgsForCall(i int) (lager.Logger, models.ActualLRPKey, models.ActualLRPInstanceKey, func() error) {
+	fake.drainAndStopMutex.RLock()
+	defer fake.drainAndStopMutex.RUnlock()
+	return fake.drainAndStopArgsForCall[i].logger, fake.drainAndStopArgsForCall[i].lrpKey, fake.drainAndStopArgsForCall[i].instanceKey, fake.drainAndStopArgsForCall[i].stop
+}
+
+func (fake *FakeDrainer) DrainAndStopReturns(result1 error) {
+	fake.DrainAndStopStub = nil
+	fake.drainAndStopReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDrainer) Draining(containerGuid string) bool {
+	fake.drainingMutex.Lock()
+	fake.drainingArgsForCall = append(fake.drainingArgsForCall, struct {
+		containerGuid string
+	}{containerGuid})
+	fake.drainingMutex.Unlock()
+	if fake.DrainingStub != nil {
+		return fake.DrainingStub(containerGuid)
+	} else {
+		return fake.drainingReturns.result1
+	}
+}
+
+func (fake *FakeDrainer) DrainingCallCount() int {
+	fake.drainingMutex.RLock()
+	defer fake.drainingMutex.RUnlock()
+	return len(fake.drainingArgsForCall)
+}
+
+func (fake *FakeDrainer) DrainingArgsForCall(i int) string {
+	fake.drainingMutex.RLock()
+	defer fake.drainingMutex.RUnlock()
+	return fake.drainingArgsForCall[i].containerGuid
+}
+
+func (fake *FakeDrainer) DrainingReturns(result1 bool) {
+	fake.DrainingStub = nil
+	fake.drainingReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+var _ lrp_stopper.Drainer = new(FakeDrainer)
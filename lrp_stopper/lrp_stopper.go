@@ -12,16 +12,18 @@ type LRPStopper interface {
 }
 
 type lrpStopper struct {
-	guid   string
-	client executor.Client
-	logger lager.Logger
+	guid    string
+	client  executor.Client
+	drainer Drainer
+	logger  lager.Logger
 }
 
-func New(guid string, client executor.Client, logger lager.Logger) LRPStopper {
+func New(guid string, client executor.Client, drainer Drainer, logger lager.Logger) LRPStopper {
 	return &lrpStopper{
-		guid:   guid,
-		client: client,
-		logger: logger.Session("lrp-stopper"),
+		guid:    guid,
+		client:  client,
+		drainer: drainer,
+		logger:  logger.Session("lrp-stopper"),
 	}
 }
 
@@ -34,5 +36,32 @@ func (stopper *lrpStopper) StopInstance(processGuid, instanceGuid string) error
 	stopLog.Info("stopping")
 	defer stopLog.Info("finished")
 
-	return stopper.client.StopContainer(rep.LRPContainerGuid(processGuid, instanceGuid))
+	containerGuid := rep.LRPContainerGuid(processGuid, instanceGuid)
+	stop := func() error {
+		return stopper.client.StopContainer(containerGuid)
+	}
+
+	container, err := stopper.client.GetContainer(containerGuid)
+	if err != nil {
+		stopLog.Error("failed-fetching-container", err)
+		return err
+	}
+
+	if container.State != executor.StateRunning {
+		return stop()
+	}
+
+	lrpKey, err := rep.ActualLRPKeyFromContainer(container)
+	if err != nil {
+		stopLog.Error("failed-to-generate-lrp-key", err)
+		return stop()
+	}
+
+	instanceKey, err := rep.ActualLRPInstanceKeyFromContainer(container, stopper.guid)
+	if err != nil {
+		stopLog.Error("failed-to-generate-instance-key", err)
+		return stop()
+	}
+
+	return stopper.drainer.DrainAndStop(stopLog, lrpKey, instanceKey, stop)
 }
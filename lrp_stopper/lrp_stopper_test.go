@@ -3,8 +3,11 @@ package lrp_stopper_test
 import (
 	"errors"
 
+	"github.com/cloudfoundry-incubator/executor"
 	fake_client "github.com/cloudfoundry-incubator/executor/fakes"
+	"github.com/cloudfoundry-incubator/rep"
 	"github.com/cloudfoundry-incubator/rep/lrp_stopper"
+	"github.com/cloudfoundry-incubator/rep/lrp_stopper/fake_lrp_stopper"
 	"github.com/pivotal-golang/lager"
 	"github.com/pivotal-golang/lager/lagertest"
 
@@ -21,6 +24,8 @@ var _ = Describe("LRP Stopper", func() {
 		stopper   lrp_stopper.LRPStopper
 		bbs       *fake_bbs.FakeRepBBS
 		client    *fake_client.FakeClient
+		drainer   *fake_lrp_stopper.FakeDrainer
+		container executor.Container
 		logger    lager.Logger
 		actualLRP models.ActualLRP
 	)
@@ -41,9 +46,26 @@ var _ = Describe("LRP Stopper", func() {
 
 		bbs = &fake_bbs.FakeRepBBS{}
 		client = new(fake_client.FakeClient)
+		drainer = new(fake_lrp_stopper.FakeDrainer)
+		drainer.DrainAndStopStub = func(_ lager.Logger, _ models.ActualLRPKey, _ models.ActualLRPInstanceKey, stop func() error) error {
+			return stop()
+		}
 		logger = lagertest.NewTestLogger("test")
 
-		stopper = lrp_stopper.New(cellID, client, logger)
+		container = executor.Container{
+			Guid:  rep.LRPContainerGuid(actualLRP.ProcessGuid, actualLRP.InstanceGuid),
+			State: executor.StateRunning,
+			Tags: executor.Tags{
+				rep.LifecycleTag:    rep.LRPLifecycle,
+				rep.DomainTag:       actualLRP.Domain,
+				rep.ProcessGuidTag:  actualLRP.ProcessGuid,
+				rep.InstanceGuidTag: actualLRP.InstanceGuid,
+				rep.ProcessIndexTag: "1138",
+			},
+		}
+		client.GetContainerReturns(container, nil)
+
+		stopper = lrp_stopper.New(cellID, client, drainer, logger)
 	})
 
 	Describe("StopInstance", func() {
@@ -57,6 +79,42 @@ var _ = Describe("LRP Stopper", func() {
 			Ω(returnedError).ShouldNot(HaveOccurred())
 		})
 
+		It("drains the instance before stopping the container", func() {
+			Ω(drainer.DrainAndStopCallCount()).Should(Equal(1))
+			_, lrpKey, instanceKey, _ := drainer.DrainAndStopArgsForCall(0)
+			Ω(lrpKey).Should(Equal(actualLRP.ActualLRPKey))
+			Ω(instanceKey).Should(Equal(models.NewActualLRPInstanceKey(actualLRP.InstanceGuid, cellID)))
+
+			Ω(client.StopContainerCallCount()).Should(Equal(1))
+			Ω(client.StopContainerArgsForCall(0)).Should(Equal(container.Guid))
+		})
+
+		Context("when the container is not running", func() {
+			BeforeEach(func() {
+				container.State = executor.StateCreated
+				client.GetContainerReturns(container, nil)
+			})
+
+			It("stops the container without draining", func() {
+				Ω(drainer.DrainAndStopCallCount()).Should(BeZero())
+				Ω(client.StopContainerCallCount()).Should(Equal(1))
+			})
+		})
+
+		Context("when fetching the container fails", func() {
+			BeforeEach(func() {
+				client.GetContainerReturns(executor.Container{}, executor.ErrContainerNotFound)
+			})
+
+			It("returns the error", func() {
+				Ω(returnedError).Should(Equal(executor.ErrContainerNotFound))
+			})
+
+			It("does not stop the container", func() {
+				Ω(client.StopContainerCallCount()).Should(BeZero())
+			})
+		})
+
 		Context("when the executor returns an unexpected error", func() {
 			BeforeEach(func() {
 				client.StopContainerReturns(errors.New("use of closed network connection"))
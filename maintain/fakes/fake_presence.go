@@ -0,0 +1,96 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"os"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/rep/maintain"
+)
+
+type FakePresence struct {
+	RunStub        func(signals <-chan os.Signal, ready chan<- struct{}) error
+	runMutex       sync.RWMutex
+	runArgsForCall []struct {
+		signals <-chan os.Signal
+		ready   chan<- struct{}
+	}
+	runReturns struct {
+		result1 error
+	}
+	UpdateStub        func(presence maintain.CellPresence) error
+	updateMutex       sync.RWMutex
+	updateArgsForCall []struct {
+		presence maintain.CellPresence
+	}
+	updateReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakePresence) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	fake.runMutex.Lock()
+	fake.runArgsForCall = append(fake.runArgsForCall, struct {
+		signals <-chan os.Signal
+		ready   chan<- struct{}
+	}{signals, ready})
+	fake.runMutex.Unlock()
+	if fake.RunStub != nil {
+		return fake.RunStub(signals, ready)
+	} else {
+		return fake.runReturns.result1
+	}
+}
+
+func (fake *FakePresence) RunCallCount() int {
+	fake.runMutex.RLock()
+	defer fake.runMutex.RUnlock()
+	return len(fake.runArgsForCall)
+}
+
+func (fake *FakePresence) RunArgsForCall(i int) (<-chan os.Signal, chan<- struct{}) {
+	fake.runMutex.RLock()
+	defer fake.runMutex.RUnlock()
+	return fake.runArgsForCall[i].signals, fake.runArgsForCall[i].ready
+}
+
+func (fake *FakePresence) RunReturns(result1 error) {
+	fake.RunStub = nil
+	fake.runReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakePresence) Update(presence maintain.CellPresence) error {
+	fake.updateMutex.Lock()
+	fake.updateArgsForCall = append(fake.updateArgsForCall, struct {
+		presence maintain.CellPresence
+	}{presence})
+	fake.updateMutex.Unlock()
+	if fake.UpdateStub != nil {
+		return fake.UpdateStub(presence)
+	} else {
+		return fake.updateReturns.result1
+	}
+}
+
+func (fake *FakePresence) UpdateCallCount() int {
+	fake.updateMutex.RLock()
+	defer fake.updateMutex.RUnlock()
+	return len(fake.updateArgsForCall)
+}
+
+func (fake *FakePresence) UpdateArgsForCall(i int) maintain.CellPresence {
+	fake.updateMutex.RLock()
+	defer fake.updateMutex.RUnlock()
+	return fake.updateArgsForCall[i].presence
+}
+
+func (fake *FakePresence) UpdateReturns(result1 error) {
+	fake.UpdateStub = nil
+	fake.updateReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ maintain.Presence = new(FakePresence)
@@ -0,0 +1,136 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/rep/maintain"
+	"github.com/cloudfoundry/storeadapter"
+)
+
+type FakePresenceStore struct {
+	CreateStub        func(node storeadapter.StoreNode) error
+	createMutex       sync.RWMutex
+	createArgsForCall []struct {
+		node storeadapter.StoreNode
+	}
+	createReturns struct {
+		result1 error
+	}
+	CompareAndSwapStub        func(oldNode storeadapter.StoreNode, newNode storeadapter.StoreNode) error
+	compareAndSwapMutex       sync.RWMutex
+	compareAndSwapArgsForCall []struct {
+		oldNode storeadapter.StoreNode
+		newNode storeadapter.StoreNode
+	}
+	compareAndSwapReturns struct {
+		result1 error
+	}
+	CompareAndDeleteStub        func(nodes ...storeadapter.StoreNode) error
+	compareAndDeleteMutex       sync.RWMutex
+	compareAndDeleteArgsForCall []struct {
+		nodes []storeadapter.StoreNode
+	}
+	compareAndDeleteReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakePresenceStore) Create(node storeadapter.StoreNode) error {
+	fake.createMutex.Lock()
+	fake.createArgsForCall = append(fake.createArgsForCall, struct {
+		node storeadapter.StoreNode
+	}{node})
+	fake.createMutex.Unlock()
+	if fake.CreateStub != nil {
+		return fake.CreateStub(node)
+	} else {
+		return fake.createReturns.result1
+	}
+}
+
+func (fake *FakePresenceStore) CreateCallCount() int {
+	fake.createMutex.RLock()
+	defer fake.createMutex.RUnlock()
+	return len(fake.createArgsForCall)
+}
+
+func (fake *FakePresenceStore) CreateArgsForCall(i int) storeadapter.StoreNode {
+	fake.createMutex.RLock()
+	defer fake.createMutex.RUnlock()
+	return fake.createArgsForCall[i].node
+}
+
+func (fake *FakePresenceStore) CreateReturns(result1 error) {
+	fake.CreateStub = nil
+	fake.createReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakePresenceStore) CompareAndSwap(oldNode storeadapter.StoreNode, newNode storeadapter.StoreNode) error {
+	fake.compareAndSwapMutex.Lock()
+	fake.compareAndSwapArgsForCall = append(fake.compareAndSwapArgsForCall, struct {
+		oldNode storeadapter.StoreNode
+		newNode storeadapter.StoreNode
+	}{oldNode, newNode})
+	fake.compareAndSwapMutex.Unlock()
+	if fake.CompareAndSwapStub != nil {
+		return fake.CompareAndSwapStub(oldNode, newNode)
+	} else {
+		return fake.compareAndSwapReturns.result1
+	}
+}
+
+func (fake *FakePresenceStore) CompareAndSwapCallCount() int {
+	fake.compareAndSwapMutex.RLock()
+	defer fake.compareAndSwapMutex.RUnlock()
+	return len(fake.compareAndSwapArgsForCall)
+}
+
+func (fake *FakePresenceStore) CompareAndSwapArgsForCall(i int) (storeadapter.StoreNode, storeadapter.StoreNode) {
+	fake.compareAndSwapMutex.RLock()
+	defer fake.compareAndSwapMutex.RUnlock()
+	return fake.compareAndSwapArgsForCall[i].oldNode, fake.compareAndSwapArgsForCall[i].newNode
+}
+
+func (fake *FakePresenceStore) CompareAndSwapReturns(result1 error) {
+	fake.CompareAndSwapStub = nil
+	fake.compareAndSwapReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakePresenceStore) CompareAndDelete(nodes ...storeadapter.StoreNode) error {
+	fake.compareAndDeleteMutex.Lock()
+	fake.compareAndDeleteArgsForCall = append(fake.compareAndDeleteArgsForCall, struct {
+		nodes []storeadapter.StoreNode
+	}{nodes})
+	fake.compareAndDeleteMutex.Unlock()
+	if fake.CompareAndDeleteStub != nil {
+		return fake.CompareAndDeleteStub(nodes...)
+	} else {
+		return fake.compareAndDeleteReturns.result1
+	}
+}
+
+func (fake *FakePresenceStore) CompareAndDeleteCallCount() int {
+	fake.compareAndDeleteMutex.RLock()
+	defer fake.compareAndDeleteMutex.RUnlock()
+	return len(fake.compareAndDeleteArgsForCall)
+}
+
+func (fake *FakePresenceStore) CompareAndDeleteArgsForCall(i int) []storeadapter.StoreNode {
+	fake.compareAndDeleteMutex.RLock()
+	defer fake.compareAndDeleteMutex.RUnlock()
+	return fake.compareAndDeleteArgsForCall[i].nodes
+}
+
+func (fake *FakePresenceStore) CompareAndDeleteReturns(result1 error) {
+	fake.CompareAndDeleteStub = nil
+	fake.compareAndDeleteReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ maintain.PresenceStore = new(FakePresenceStore)
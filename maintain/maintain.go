@@ -3,10 +3,13 @@ package maintain
 import (
 	"errors"
 	"os"
+	"reflect"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/cloudfoundry-incubator/executor"
-	"github.com/cloudfoundry-incubator/runtime-schema/bbs"
+	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context"
 	"github.com/cloudfoundry-incubator/runtime-schema/models"
 	"github.com/pivotal-golang/clock"
 	"github.com/pivotal-golang/lager"
@@ -15,10 +18,14 @@ import (
 
 type Maintainer struct {
 	Config
-	executorClient executor.Client
-	bbs            bbs.RepBBS
-	logger         lager.Logger
-	clock          clock.Clock
+	executorClient     executor.Client
+	newPresence        PresenceFactory
+	evacuationReporter evacuation_context.EvacuationReporter
+	logger             lager.Logger
+	clock              clock.Clock
+
+	heartbeatingLock sync.Mutex
+	heartbeating     bool
 }
 
 type Config struct {
@@ -26,21 +33,31 @@ type Config struct {
 	RepAddress        string
 	Zone              string
 	HeartbeatInterval time.Duration
+
+	// PresenceRefreshInterval is how often the presence is refreshed, and
+	// updated should anything in it have changed. Zero never refreshes.
+	PresenceRefreshInterval time.Duration
+
+	RepVersion      string
+	Stacks          []string
+	RootFSProviders []string
 }
 
 func New(
 	config Config,
 	executorClient executor.Client,
-	bbs bbs.RepBBS,
+	newPresence PresenceFactory,
+	evacuationReporter evacuation_context.EvacuationReporter,
 	logger lager.Logger,
 	clock clock.Clock,
 ) *Maintainer {
 	return &Maintainer{
-		Config:         config,
-		executorClient: executorClient,
-		bbs:            bbs,
-		logger:         logger.Session("maintainer"),
-		clock:          clock,
+		Config:             config,
+		executorClient:     executorClient,
+		newPresence:        newPresence,
+		evacuationReporter: evacuationReporter,
+		logger:             logger.Session("maintainer"),
+		clock:              clock,
 	}
 }
 
@@ -52,13 +69,13 @@ func (m *Maintainer) Run(sigChan <-chan os.Signal, ready chan<- struct{}) error
 	m.logger.Info("starting-executor-heartbeat")
 	defer m.logger.Info("complete-executor-heartbeat")
 	for {
-		heartbeater, err := m.waitForExecutor(sigChan)
+		data, err := m.waitForExecutor(sigChan)
 		if err != nil {
 			m.logger.Error("error-while-waiting-for-executor", err)
 			return err
 		}
 
-		err = m.heartbeat(sigChan, ready, heartbeater)
+		err = m.heartbeat(sigChan, ready, data)
 		ready = nil
 		if err == nil {
 			return nil
@@ -68,7 +85,7 @@ func (m *Maintainer) Run(sigChan <-chan os.Signal, ready chan<- struct{}) error
 	}
 }
 
-func (m *Maintainer) waitForExecutor(sigChan <-chan os.Signal) (ifrit.Runner, error) {
+func (m *Maintainer) waitForExecutor(sigChan <-chan os.Signal) (CellPresence, error) {
 	m.logger.Info("start-waiting-for-executor")
 	defer m.logger.Info("complete-waiting-for-executor")
 
@@ -77,7 +94,7 @@ func (m *Maintainer) waitForExecutor(sigChan <-chan os.Signal) (ifrit.Runner, er
 		m.logger.Debug("waiting-pinging-executor")
 		err := m.executorClient.Ping()
 		if err == nil {
-			return m.createHeartbeater()
+			return m.cellPresence()
 		}
 
 		m.logger.Error("failed-to-ping-executor-on-start", err)
@@ -86,32 +103,93 @@ func (m *Maintainer) waitForExecutor(sigChan <-chan os.Signal) (ifrit.Runner, er
 		select {
 		case <-sigChan:
 			m.logger.Info("signaled-while-waiting-for-executor")
-			return nil, ErrSignaledWhileWaiting
+			return CellPresence{}, ErrSignaledWhileWaiting
 		case <-sleeper.C():
 		}
 	}
 }
 
-func (m *Maintainer) createHeartbeater() (ifrit.Runner, error) {
+func (m *Maintainer) cellPresence() (CellPresence, error) {
 	resources, err := m.executorClient.TotalResources()
 	if err != nil {
-		return nil, err
+		return CellPresence{}, err
+	}
+
+	stacks := append([]string{}, m.Stacks...)
+	sort.Strings(stacks)
+
+	providers := append([]string{}, m.RootFSProviders...)
+	sort.Strings(providers)
+
+	capacity := models.NewCellCapacity(resources.MemoryMB, resources.DiskMB, resources.Containers)
+
+	presence := CellPresence{
+		CellPresence:    models.NewCellPresence(m.CellID, m.RepAddress, m.Zone, capacity),
+		Evacuating:      m.evacuationReporter.Evacuating(),
+		RepVersion:      m.RepVersion,
+		Stacks:          stacks,
+		RootFSProviders: providers,
 	}
 
-	cellCapacity := models.NewCellCapacity(resources.MemoryMB, resources.DiskMB, resources.Containers)
-	cellPresence := models.NewCellPresence(m.CellID, m.RepAddress, m.Zone, cellCapacity)
-	return m.bbs.NewCellHeartbeat(cellPresence, m.HeartbeatInterval), nil
+	if presence.Evacuating {
+		scope := m.evacuationReporter.EvacuationScope()
+		if !scope.All() {
+			presence.EvacuationScope = &scope
+		}
+	}
+
+	return presence, nil
+}
+
+// Heartbeating reports whether the cell presence is being maintained.
+func (m *Maintainer) Heartbeating() bool {
+	m.heartbeatingLock.Lock()
+	defer m.heartbeatingLock.Unlock()
+
+	return m.heartbeating
+}
+
+func (m *Maintainer) setHeartbeating(heartbeating bool) {
+	m.heartbeatingLock.Lock()
+	m.heartbeating = heartbeating
+	m.heartbeatingLock.Unlock()
 }
 
-func (m *Maintainer) heartbeat(sigChan <-chan os.Signal, ready chan<- struct{}, heartbeater ifrit.Runner) error {
+func (m *Maintainer) heartbeat(sigChan <-chan os.Signal, ready chan<- struct{}, published CellPresence) error {
 	m.logger.Info("start-heartbeating")
 	defer m.logger.Info("complete-heartbeating")
 	ticker := m.clock.NewTicker(m.HeartbeatInterval)
 	defer ticker.Stop()
 
-	heartbeatProcess := ifrit.Invoke(heartbeater)
+	var refresh <-chan time.Time
+	if m.PresenceRefreshInterval > 0 {
+		refreshTicker := m.clock.NewTicker(m.PresenceRefreshInterval)
+		defer refreshTicker.Stop()
+		refresh = refreshTicker.C()
+	}
+
+	m.logger.Info("publishing-presence", lager.Data{"presence": published})
+	presence := m.newPresence(published, m.HeartbeatInterval)
+	heartbeatProcess := ifrit.Background(presence)
 	heartbeatExitChan := heartbeatProcess.Wait()
 
+	// A presence left behind by a previous heartbeat has to expire before it
+	// can be acquired again; the cell is not heartbeating until then.
+	select {
+	case <-heartbeatProcess.Ready():
+	case err := <-heartbeatExitChan:
+		m.logger.Error("failed-to-acquire-presence", err)
+		return err
+	case <-sigChan:
+		m.logger.Info("signaled-while-acquiring-presence")
+		heartbeatProcess.Signal(os.Kill)
+		<-heartbeatExitChan
+		return nil
+	}
+
+	m.setHeartbeating(true)
+	defer m.setHeartbeating(false)
+
 	if ready != nil {
 		close(ready)
 	}
@@ -128,6 +206,28 @@ func (m *Maintainer) heartbeat(sigChan <-chan os.Signal, ready chan<- struct{},
 			<-heartbeatExitChan
 			return nil
 
+		case <-refresh:
+			refreshed, err := m.cellPresence()
+			if err != nil {
+				m.logger.Error("failed-to-refresh-presence", err)
+				continue
+			}
+
+			if reflect.DeepEqual(refreshed, published) {
+				continue
+			}
+
+			m.logger.Info("presence-changed", lager.Data{"presence": refreshed})
+			err = presence.Update(refreshed)
+			if err != nil {
+				// The presence exits having lost its key; the heartbeat stops with it.
+				m.logger.Error("failed-to-update-presence", err)
+				m.setHeartbeating(false)
+				continue
+			}
+
+			published = refreshed
+
 		case <-ticker.C():
 			m.logger.Debug("heartbeat-pinging-executor")
 			err := m.executorClient.Ping()
@@ -136,6 +236,7 @@ func (m *Maintainer) heartbeat(sigChan <-chan os.Signal, ready chan<- struct{},
 			}
 
 			m.logger.Info("start-signaling-heartbeat-to-stop")
+			m.setHeartbeating(false)
 			heartbeatProcess.Signal(os.Kill)
 			select {
 			case <-heartbeatExitChan:
@@ -7,9 +7,11 @@ import (
 
 	"github.com/cloudfoundry-incubator/executor"
 	fake_client "github.com/cloudfoundry-incubator/executor/fakes"
+	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context"
+	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context/fake_evacuation_context"
 	"github.com/cloudfoundry-incubator/rep/maintain"
 	maintain_fakes "github.com/cloudfoundry-incubator/rep/maintain/fakes"
-	"github.com/cloudfoundry-incubator/runtime-schema/bbs/fake_bbs"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
 	"github.com/pivotal-golang/clock/fakeclock"
 	"github.com/pivotal-golang/lager/lagertest"
 	"github.com/tedsuo/ifrit"
@@ -23,12 +25,15 @@ import (
 var _ = Describe("Maintain Presence", func() {
 	var (
 		config          maintain.Config
-		fakeHeartbeater *maintain_fakes.FakeRunner
+		fakeHeartbeater *maintain_fakes.FakePresence
 		fakeClient      *fake_client.FakeClient
-		fakeBBS         *fake_bbs.FakeRepBBS
+		newPresence     maintain.PresenceFactory
+		presences       chan maintain.CellPresence
 		logger          *lagertest.TestLogger
 
-		maintainer        ifrit.Runner
+		evacuationReporter *fake_evacuation_context.FakeEvacuationReporter
+
+		maintainer        *maintain.Maintainer
 		maintainProcess   ifrit.Process
 		heartbeaterErrors chan error
 		observedSignals   chan os.Signal
@@ -51,7 +56,7 @@ var _ = Describe("Maintain Presence", func() {
 
 		heartbeaterErrors = make(chan error)
 		observedSignals = make(chan os.Signal, 2)
-		fakeHeartbeater = &maintain_fakes.FakeRunner{
+		fakeHeartbeater = &maintain_fakes.FakePresence{
 			RunStub: func(sigChan <-chan os.Signal, ready chan<- struct{}) error {
 				defer GinkgoRecover()
 				logger.Info("fake-heartbeat-started")
@@ -70,8 +75,11 @@ var _ = Describe("Maintain Presence", func() {
 			},
 		}
 
-		fakeBBS = &fake_bbs.FakeRepBBS{}
-		fakeBBS.NewCellHeartbeatReturns(fakeHeartbeater)
+		presences = make(chan maintain.CellPresence, 10)
+		newPresence = func(presence maintain.CellPresence, interval time.Duration) maintain.Presence {
+			presences <- presence
+			return fakeHeartbeater
+		}
 
 		config = maintain.Config{
 			CellID:            "cell-id",
@@ -79,7 +87,9 @@ var _ = Describe("Maintain Presence", func() {
 			Zone:              "az1",
 			HeartbeatInterval: 1 * time.Second,
 		}
-		maintainer = maintain.New(config, fakeClient, fakeBBS, logger, clock)
+		evacuationReporter = &fake_evacuation_context.FakeEvacuationReporter{}
+
+		maintainer = maintain.New(config, fakeClient, newPresence, evacuationReporter, logger, clock)
 	})
 
 	AfterEach(func() {
@@ -115,6 +125,44 @@ var _ = Describe("Maintain Presence", func() {
 		})
 	})
 
+	Context("when the presence has not been acquired yet", func() {
+		var acquired chan struct{}
+
+		BeforeEach(func() {
+			acquired = make(chan struct{})
+			fakeHeartbeater.RunStub = func(sigChan <-chan os.Signal, ready chan<- struct{}) error {
+				select {
+				case <-acquired:
+					close(ready)
+				case <-sigChan:
+					return nil
+				}
+
+				<-sigChan
+				return nil
+			}
+
+			pingErrors <- nil
+			maintainProcess = ifrit.Background(maintainer)
+			Eventually(fakeHeartbeater.RunCallCount).Should(Equal(1))
+		})
+
+		It("does not report that it is heartbeating until it has been acquired", func() {
+			Consistently(maintainer.Heartbeating).Should(BeFalse())
+			Ω(maintainProcess.Ready()).ShouldNot(BeClosed())
+
+			close(acquired)
+
+			Eventually(maintainProcess.Ready()).Should(BeClosed())
+			Ω(maintainer.Heartbeating()).Should(BeTrue())
+		})
+
+		It("can still be signaled", func() {
+			maintainProcess.Signal(os.Interrupt)
+			Eventually(maintainProcess.Wait()).Should(Receive(BeNil()))
+		})
+	})
+
 	Context("when pinging the executor succeeds", func() {
 		BeforeEach(func() {
 			pingErrors <- nil
@@ -122,10 +170,14 @@ var _ = Describe("Maintain Presence", func() {
 		})
 
 		It("starts maintaining presence", func() {
-			Ω(fakeBBS.NewCellHeartbeatCallCount()).Should(Equal(1))
+			Ω(presences).Should(HaveLen(1))
 			Eventually(fakeHeartbeater.RunCallCount).Should(Equal(1))
 		})
 
+		It("reports that it is heartbeating", func() {
+			Ω(maintainer.Heartbeating()).Should(BeTrue())
+		})
+
 		It("continues pings the executor on an interval", func() {
 			for i := 1; i < 5; i++ {
 				pingErrors <- nil
@@ -144,6 +196,10 @@ var _ = Describe("Maintain Presence", func() {
 				Eventually(observedSignals).Should(Receive(Equal(os.Kill)))
 			})
 
+			It("reports that it is no longer heartbeating", func() {
+				Eventually(maintainer.Heartbeating).Should(BeFalse())
+			})
+
 			It("continues pinging the executor", func() {
 				for i := 2; i < 6; i++ {
 					pingErrors <- errors.New("failed again")
@@ -197,6 +253,10 @@ var _ = Describe("Maintain Presence", func() {
 				Eventually(logger.TestSink.Buffer).Should(gbytes.Say("lost-lock"))
 			})
 
+			It("reports that it is no longer heartbeating", func() {
+				Eventually(maintainer.Heartbeating).Should(BeFalse())
+			})
+
 			It("tries to restart heartbeating each time the ping succeeds", func() {
 				Ω(fakeHeartbeater.RunCallCount()).Should(Equal(1))
 
@@ -210,4 +270,108 @@ var _ = Describe("Maintain Presence", func() {
 
 		})
 	})
+
+	Context("when refreshing the presence", func() {
+		const refreshInterval = 10 * time.Second
+
+		BeforeEach(func() {
+			config.HeartbeatInterval = time.Minute
+			config.PresenceRefreshInterval = refreshInterval
+			config.RepVersion = "some-version"
+			config.Stacks = []string{"lucid64"}
+			config.RootFSProviders = []string{"docker"}
+			maintainer = maintain.New(config, fakeClient, newPresence, evacuationReporter, logger, clock)
+
+			pingErrors <- nil
+			maintainProcess = ginkgomon.Invoke(maintainer)
+		})
+
+		It("publishes everything it knows about the cell", func() {
+			var presence maintain.CellPresence
+			Ω(presences).Should(Receive(&presence))
+			Ω(presence.CellID).Should(Equal("cell-id"))
+			Ω(presence.Capacity).Should(Equal(models.NewCellCapacity(128, 1024, 6)))
+			Ω(presence.Evacuating).Should(BeFalse())
+			Ω(presence.RepVersion).Should(Equal("some-version"))
+			Ω(presence.Stacks).Should(Equal([]string{"lucid64"}))
+			Ω(presence.RootFSProviders).Should(Equal([]string{"docker"}))
+		})
+
+		Context("when the executor's capacity changes", func() {
+			BeforeEach(func() {
+				fakeClient.TotalResourcesReturns(executor.ExecutorResources{MemoryMB: 256, DiskMB: 2048, Containers: 12}, nil)
+				clock.Increment(refreshInterval)
+			})
+
+			It("updates the presence with the new capacity", func() {
+				Eventually(fakeHeartbeater.UpdateCallCount).Should(Equal(1))
+				presence := fakeHeartbeater.UpdateArgsForCall(0)
+				Ω(presence.Capacity).Should(Equal(models.NewCellCapacity(256, 2048, 12)))
+			})
+
+			It("keeps the presence it holds", func() {
+				Eventually(fakeHeartbeater.UpdateCallCount).Should(Equal(1))
+				Consistently(observedSignals).ShouldNot(Receive())
+				Ω(fakeHeartbeater.RunCallCount()).Should(Equal(1))
+				Ω(presences).Should(HaveLen(1))
+			})
+
+			Context("when updating the presence fails", func() {
+				BeforeEach(func() {
+					fakeHeartbeater.UpdateReturns(errors.New("lost it"))
+				})
+
+				It("logs the failure", func() {
+					Eventually(logger.TestSink.Buffer).Should(gbytes.Say("failed-to-update-presence"))
+				})
+
+				It("reports that it is no longer heartbeating", func() {
+					Eventually(maintainer.Heartbeating).Should(BeFalse())
+				})
+			})
+		})
+
+		Context("when the cell starts evacuating", func() {
+			BeforeEach(func() {
+				evacuationReporter.EvacuatingReturns(true)
+				clock.Increment(refreshInterval)
+			})
+
+			It("updates the presence to say so", func() {
+				Eventually(fakeHeartbeater.UpdateCallCount).Should(Equal(1))
+				presence := fakeHeartbeater.UpdateArgsForCall(0)
+				Ω(presence.Evacuating).Should(BeTrue())
+				Ω(presence.EvacuationScope).Should(BeNil())
+				Ω(presence.RepVersion).Should(Equal("some-version"))
+			})
+		})
+
+		Context("when part of the cell starts evacuating", func() {
+			scope := evacuation_context.Scope{Domains: []string{"some-domain"}}
+
+			BeforeEach(func() {
+				evacuationReporter.EvacuatingReturns(true)
+				evacuationReporter.EvacuationScopeReturns(scope)
+				clock.Increment(refreshInterval)
+			})
+
+			It("advertises the evacuation scope in the presence", func() {
+				Eventually(fakeHeartbeater.UpdateCallCount).Should(Equal(1))
+				presence := fakeHeartbeater.UpdateArgsForCall(0)
+				Ω(presence.Evacuating).Should(BeTrue())
+				Ω(presence.EvacuationScope).Should(Equal(&scope))
+			})
+		})
+
+		Context("when nothing has changed", func() {
+			BeforeEach(func() {
+				clock.Increment(refreshInterval)
+			})
+
+			It("keeps the presence it published", func() {
+				Eventually(fakeClient.TotalResourcesCallCount).Should(Equal(2))
+				Consistently(fakeHeartbeater.UpdateCallCount).Should(BeZero())
+			})
+		})
+	})
 })
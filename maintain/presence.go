@@ -0,0 +1,189 @@
+package maintain
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/cloudfoundry-incubator/rep/evacuation/evacuation_context"
+	"github.com/cloudfoundry-incubator/runtime-schema/bbs/shared"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/cloudfoundry/storeadapter"
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+	"github.com/tedsuo/ifrit"
+)
+
+// CellPresence is the presence published for the cell. It is the BBS cell
+// presence, which is what the auctioneer reads, along with the rest of what
+// the rep knows about the cell.
+type CellPresence struct {
+	models.CellPresence
+	Evacuating bool `json:"evacuating"`
+
+	// EvacuationScope is what is being evacuated when only part of the cell
+	// is. It is absent when the whole cell is evacuating, or none of it.
+	EvacuationScope *evacuation_context.Scope `json:"evacuation_scope,omitempty"`
+
+	RepVersion      string   `json:"rep_version"`
+	Stacks          []string `json:"stacks"`
+	RootFSProviders []string `json:"rootfs_providers"`
+}
+
+var ErrPresenceNotMaintained = errors.New("presence is not being maintained")
+
+//go:generate counterfeiter -o fakes/fake_presence.go . Presence
+
+// Presence maintains the cell presence in the BBS.
+type Presence interface {
+	ifrit.Runner
+
+	// Update changes the published presence without releasing it.
+	Update(presence CellPresence) error
+}
+
+// PresenceFactory creates the Presence that maintains a cell presence,
+// refreshing it every interval.
+type PresenceFactory func(presence CellPresence, interval time.Duration) Presence
+
+//go:generate counterfeiter -o fakes/fake_presence_store.go . PresenceStore
+
+// PresenceStore is the part of the store a Presence is maintained in.
+type PresenceStore interface {
+	Create(node storeadapter.StoreNode) error
+	CompareAndSwap(oldNode storeadapter.StoreNode, newNode storeadapter.StoreNode) error
+	CompareAndDelete(nodes ...storeadapter.StoreNode) error
+}
+
+// NewPresenceFactory creates Presences kept directly in the store. Unlike the
+// BBS cell heartbeat, their value can change while they run: the new value is
+// swapped in against the old one, so the key is never released. The key
+// expires should it go unrefreshed for two intervals, and never in less than
+// a second, the store's finest TTL.
+func NewPresenceFactory(logger lager.Logger, store PresenceStore, clock clock.Clock) PresenceFactory {
+	return func(presence CellPresence, interval time.Duration) Presence {
+		return &storePresence{
+			logger:   logger,
+			store:    store,
+			clock:    clock,
+			presence: presence,
+			interval: interval,
+			updates:  make(chan presenceUpdate),
+			stopped:  make(chan struct{}),
+		}
+	}
+}
+
+type storePresence struct {
+	logger   lager.Logger
+	store    PresenceStore
+	clock    clock.Clock
+	presence CellPresence
+	interval time.Duration
+
+	updates chan presenceUpdate
+	stopped chan struct{}
+}
+
+type presenceUpdate struct {
+	presence CellPresence
+	result   chan<- error
+}
+
+func (p *storePresence) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	logger := p.logger.Session("presence", lager.Data{"cell-id": p.presence.CellID})
+	logger.Info("starting")
+	defer logger.Info("finished")
+	defer close(p.stopped)
+
+	node, err := p.node(p.presence)
+	if err != nil {
+		logger.Error("failed-to-marshal-presence", err)
+		return err
+	}
+
+	ticker := p.clock.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	// A presence left behind by a previous rep must expire before this one
+	// can be created.
+	for {
+		err := p.store.Create(node)
+		if err == nil {
+			break
+		}
+
+		logger.Error("failed-to-acquire-presence", err)
+
+		select {
+		case <-ticker.C():
+		case signal := <-signals:
+			logger.Info("signaled-while-acquiring", lager.Data{"signal": signal.String()})
+			return nil
+		}
+	}
+
+	logger.Info("acquired-presence")
+	close(ready)
+
+	for {
+		select {
+		case <-ticker.C():
+			err := p.store.CompareAndSwap(node, node)
+			if err != nil {
+				logger.Error("lost-presence", err)
+				return err
+			}
+
+		case update := <-p.updates:
+			updated, err := p.node(update.presence)
+			if err == nil {
+				err = p.store.CompareAndSwap(node, updated)
+			}
+
+			update.result <- err
+			if err != nil {
+				logger.Error("failed-to-update-presence", err)
+				return err
+			}
+
+			logger.Info("updated-presence")
+			node = updated
+
+		case signal := <-signals:
+			logger.Info("signaled", lager.Data{"signal": signal.String()})
+
+			err := p.store.CompareAndDelete(node)
+			if err != nil {
+				logger.Error("failed-to-release-presence", err)
+			}
+			return nil
+		}
+	}
+}
+
+func (p *storePresence) Update(presence CellPresence) error {
+	result := make(chan error, 1)
+
+	select {
+	case p.updates <- presenceUpdate{presence: presence, result: result}:
+	case <-p.stopped:
+		return ErrPresenceNotMaintained
+	}
+
+	return <-result
+}
+
+func (p *storePresence) node(presence CellPresence) (storeadapter.StoreNode, error) {
+	value, err := json.Marshal(presence)
+	if err != nil {
+		return storeadapter.StoreNode{}, err
+	}
+
+	return storeadapter.StoreNode{
+		Key:   shared.CellSchemaPath(presence.CellID),
+		Value: value,
+		TTL:   uint64((2*p.interval + time.Second - 1) / time.Second),
+	}, nil
+}
@@ -0,0 +1,183 @@
+package maintain_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/cloudfoundry-incubator/rep/maintain"
+	maintain_fakes "github.com/cloudfoundry-incubator/rep/maintain/fakes"
+	"github.com/cloudfoundry-incubator/runtime-schema/bbs/shared"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/cloudfoundry/storeadapter"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager/lagertest"
+	"github.com/tedsuo/ifrit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Presence", func() {
+	const interval = 10 * time.Second
+
+	var (
+		store     *maintain_fakes.FakePresenceStore
+		fakeClock *fakeclock.FakeClock
+		presence  maintain.Presence
+		process   ifrit.Process
+
+		cellPresence maintain.CellPresence
+	)
+
+	nodeFor := func(cellPresence maintain.CellPresence) storeadapter.StoreNode {
+		value, err := json.Marshal(cellPresence)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		return storeadapter.StoreNode{
+			Key:   shared.CellSchemaPath(cellPresence.CellID),
+			Value: value,
+			TTL:   20,
+		}
+	}
+
+	BeforeEach(func() {
+		store = new(maintain_fakes.FakePresenceStore)
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		cellPresence = maintain.CellPresence{
+			CellPresence: models.NewCellPresence("cell-id", "1.2.3.4", "az1", models.NewCellCapacity(128, 1024, 6)),
+			RepVersion:   "some-version",
+			Stacks:       []string{"lucid64"},
+		}
+
+		newPresence := maintain.NewPresenceFactory(lagertest.NewTestLogger("test"), store, fakeClock)
+		presence = newPresence(cellPresence, interval)
+	})
+
+	AfterEach(func() {
+		process.Signal(os.Interrupt)
+		Eventually(process.Wait()).Should(Receive())
+	})
+
+	Context("when the presence can be created", func() {
+		BeforeEach(func() {
+			process = ifrit.Invoke(presence)
+		})
+
+		It("creates it with a TTL of two intervals", func() {
+			Ω(store.CreateCallCount()).Should(Equal(1))
+			Ω(store.CreateArgsForCall(0)).Should(Equal(nodeFor(cellPresence)))
+		})
+
+		It("refreshes it every interval", func() {
+			fakeClock.Increment(interval)
+			Eventually(store.CompareAndSwapCallCount).Should(Equal(1))
+
+			oldNode, newNode := store.CompareAndSwapArgsForCall(0)
+			Ω(oldNode).Should(Equal(nodeFor(cellPresence)))
+			Ω(newNode).Should(Equal(nodeFor(cellPresence)))
+		})
+
+		It("releases it when signaled", func() {
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive(BeNil()))
+
+			Ω(store.CompareAndDeleteCallCount()).Should(Equal(1))
+			Ω(store.CompareAndDeleteArgsForCall(0)).Should(Equal([]storeadapter.StoreNode{nodeFor(cellPresence)}))
+		})
+
+		Context("when it is updated", func() {
+			var updated maintain.CellPresence
+
+			BeforeEach(func() {
+				updated = cellPresence
+				updated.Evacuating = true
+			})
+
+			It("swaps the new value in against the old one", func() {
+				err := presence.Update(updated)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(store.CompareAndSwapCallCount()).Should(Equal(1))
+				oldNode, newNode := store.CompareAndSwapArgsForCall(0)
+				Ω(oldNode).Should(Equal(nodeFor(cellPresence)))
+				Ω(newNode).Should(Equal(nodeFor(updated)))
+
+				Ω(store.CompareAndDeleteCallCount()).Should(BeZero())
+			})
+
+			It("refreshes the new value", func() {
+				err := presence.Update(updated)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				fakeClock.Increment(interval)
+				Eventually(store.CompareAndSwapCallCount).Should(Equal(2))
+
+				oldNode, _ := store.CompareAndSwapArgsForCall(1)
+				Ω(oldNode).Should(Equal(nodeFor(updated)))
+			})
+
+			Context("when swapping fails", func() {
+				BeforeEach(func() {
+					store.CompareAndSwapReturns(errors.New("not ours"))
+				})
+
+				It("returns the error and exits", func() {
+					err := presence.Update(updated)
+					Ω(err).Should(MatchError("not ours"))
+					Eventually(process.Wait()).Should(Receive(MatchError("not ours")))
+				})
+			})
+		})
+
+		Context("when refreshing fails", func() {
+			BeforeEach(func() {
+				store.CompareAndSwapReturns(errors.New("not ours"))
+			})
+
+			It("exits with the error", func() {
+				fakeClock.Increment(interval)
+				Eventually(process.Wait()).Should(Receive(MatchError("not ours")))
+			})
+
+			It("no longer accepts updates", func() {
+				fakeClock.Increment(interval)
+				Eventually(process.Wait()).Should(Receive())
+
+				Ω(presence.Update(cellPresence)).Should(Equal(maintain.ErrPresenceNotMaintained))
+			})
+		})
+	})
+
+	Context("when the interval is under half a second", func() {
+		BeforeEach(func() {
+			newPresence := maintain.NewPresenceFactory(lagertest.NewTestLogger("test"), store, fakeClock)
+			presence = newPresence(cellPresence, 100*time.Millisecond)
+			process = ifrit.Invoke(presence)
+		})
+
+		It("still creates it with a TTL of a second", func() {
+			Ω(store.CreateCallCount()).Should(Equal(1))
+			Ω(store.CreateArgsForCall(0).TTL).Should(Equal(uint64(1)))
+		})
+	})
+
+	Context("when a presence is left behind", func() {
+		BeforeEach(func() {
+			store.CreateReturns(storeadapter.ErrorKeyExists)
+			process = ifrit.Background(presence)
+		})
+
+		It("retries every interval until it expires", func() {
+			Eventually(store.CreateCallCount).Should(Equal(1))
+			Consistently(process.Ready()).ShouldNot(BeClosed())
+
+			store.CreateReturns(nil)
+			fakeClock.Increment(interval)
+
+			Eventually(process.Ready()).Should(BeClosed())
+			Ω(store.CreateCallCount()).Should(Equal(2))
+		})
+	})
+})
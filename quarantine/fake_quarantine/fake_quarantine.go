@@ -0,0 +1,105 @@
+// This file was generated by counterfeiter
+package fake_quarantine
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/rep/quarantine"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeQuarantine struct {
+	ObserveStub        func(logger lager.Logger, container executor.Container)
+	observeMutex       sync.RWMutex
+	observeArgsForCall []struct {
+		logger    lager.Logger
+		container executor.Container
+	}
+	ReleaseStub        func(logger lager.Logger, guid string)
+	releaseMutex       sync.RWMutex
+	releaseArgsForCall []struct {
+		logger lager.Logger
+		guid   string
+	}
+	EntriesStub        func() []quarantine.Entry
+	entriesMutex       sync.RWMutex
+	entriesArgsForCall []struct{}
+	entriesReturns     struct {
+		result1 []quarantine.Entry
+	}
+}
+
+func (fake *FakeQuarantine) Observe(logger lager.Logger, container executor.Container) {
+	fake.observeMutex.Lock()
+	fake.observeArgsForCall = append(fake.observeArgsForCall, struct {
+		logger    lager.Logger
+		container executor.Container
+	}{logger, container})
+	fake.observeMutex.Unlock()
+	if fake.ObserveStub != nil {
+		fake.ObserveStub(logger, container)
+	}
+}
+
+func (fake *FakeQuarantine) ObserveCallCount() int {
+	fake.observeMutex.RLock()
+	defer fake.observeMutex.RUnlock()
+	return len(fake.observeArgsForCall)
+}
+
+func (fake *FakeQuarantine) ObserveArgsForCall(i int) (lager.Logger, executor.Container) {
+	fake.observeMutex.RLock()
+	defer fake.observeMutex.RUnlock()
+	return fake.observeArgsForCall[i].logger, fake.observeArgsForCall[i].container
+}
+
+func (fake *FakeQuarantine) Release(logger lager.Logger, guid string) {
+	fake.releaseMutex.Lock()
+	fake.releaseArgsForCall = append(fake.releaseArgsForCall, struct {
+		logger lager.Logger
+		guid   string
+	}{logger, guid})
+	fake.releaseMutex.Unlock()
+	if fake.ReleaseStub != nil {
+		fake.ReleaseStub(logger, guid)
+	}
+}
+
+func (fake *FakeQuarantine) ReleaseCallCount() int {
+	fake.releaseMutex.RLock()
+	defer fake.releaseMutex.RUnlock()
+	return len(fake.releaseArgsForCall)
+}
+
+func (fake *FakeQuarantine) ReleaseArgsForCall(i int) (lager.Logger, string) {
+	fake.releaseMutex.RLock()
+	defer fake.releaseMutex.RUnlock()
+	return fake.releaseArgsForCall[i].logger, fake.releaseArgsForCall[i].guid
+}
+
+func (fake *FakeQuarantine) Entries() []quarantine.Entry {
+	fake.entriesMutex.Lock()
+	fake.entriesArgsForCall = append(fake.entriesArgsForCall, struct{}{})
+	fake.entriesMutex.Unlock()
+	if fake.EntriesStub != nil {
+		return fake.EntriesStub()
+	} else {
+		return fake.entriesReturns.result1
+	}
+}
+
+func (fake *FakeQuarantine) EntriesCallCount() int {
+	fake.entriesMutex.RLock()
+	defer fake.entriesMutex.RUnlock()
+	return len(fake.entriesArgsForCall)
+}
+
+func (fake *FakeQuarantine) EntriesReturns(result1 []quarantine.Entry) {
+	fake.EntriesStub = nil
+	fake.entriesReturns = struct {
+		result1 []quarantine.Entry
+	}{result1}
+}
+
+var _ quarantine.Quarantine = new(FakeQuarantine)
@@ -0,0 +1,116 @@
+package quarantine
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/runtime-schema/metric"
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+const quarantinedContainers = metric.Metric("RepQuarantinedContainers")
+
+// Entry records a container that was found in a state the rep does not know
+// how to process.
+type Entry struct {
+	Guid      string         `json:"guid"`
+	State     executor.State `json:"state"`
+	Tags      executor.Tags  `json:"tags,omitempty"`
+	FirstSeen time.Time      `json:"first_seen"`
+	LastSeen  time.Time      `json:"last_seen"`
+}
+
+//go:generate counterfeiter -o fake_quarantine/fake_quarantine.go . Quarantine
+type Quarantine interface {
+	// Observe records the container, keeping the time it was first seen in its
+	// current state.
+	Observe(logger lager.Logger, container executor.Container)
+
+	// Release forgets the container.
+	Release(logger lager.Logger, guid string)
+
+	// Entries returns the quarantined containers, oldest first.
+	Entries() []Entry
+}
+
+type quarantine struct {
+	clock clock.Clock
+
+	entries map[string]Entry
+	mu      sync.Mutex
+}
+
+func New(clock clock.Clock) Quarantine {
+	return &quarantine{
+		clock:   clock,
+		entries: map[string]Entry{},
+	}
+}
+
+func (q *quarantine) Observe(logger lager.Logger, container executor.Container) {
+	now := q.clock.Now()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, found := q.entries[container.Guid]
+	if !found || entry.State != container.State {
+		logger.Info("quarantining-container", lager.Data{
+			"container-guid":  container.Guid,
+			"container-state": container.State,
+		})
+
+		entry = Entry{
+			Guid:      container.Guid,
+			State:     container.State,
+			Tags:      container.Tags,
+			FirstSeen: now,
+		}
+	}
+
+	entry.LastSeen = now
+	q.entries[container.Guid] = entry
+
+	quarantinedContainers.Send(len(q.entries))
+}
+
+func (q *quarantine) Release(logger lager.Logger, guid string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, found := q.entries[guid]; !found {
+		return
+	}
+
+	logger.Info("releasing-container", lager.Data{"container-guid": guid})
+	delete(q.entries, guid)
+
+	quarantinedContainers.Send(len(q.entries))
+}
+
+func (q *quarantine) Entries() []Entry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entries := make([]Entry, 0, len(q.entries))
+	for _, entry := range q.entries {
+		entries = append(entries, entry)
+	}
+
+	sort.Sort(byFirstSeen(entries))
+	return entries
+}
+
+type byFirstSeen []Entry
+
+func (e byFirstSeen) Len() int      { return len(e) }
+func (e byFirstSeen) Swap(i, j int) { e[i], e[j] = e[j], e[i] }
+func (e byFirstSeen) Less(i, j int) bool {
+	if e[i].FirstSeen.Equal(e[j].FirstSeen) {
+		return e[i].Guid < e[j].Guid
+	}
+	return e[i].FirstSeen.Before(e[j].FirstSeen)
+}
@@ -0,0 +1,82 @@
+package quarantine_test
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/rep/quarantine"
+	"github.com/cloudfoundry/dropsonde/metric_sender/fake"
+	"github.com/cloudfoundry/dropsonde/metrics"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Quarantine", func() {
+	var (
+		logger       *lagertest.TestLogger
+		fakeClock    *fakeclock.FakeClock
+		sender       *fake.FakeMetricSender
+		q            quarantine.Quarantine
+		container    executor.Container
+		startingTime time.Time
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		startingTime = time.Unix(1000, 0)
+		fakeClock = fakeclock.NewFakeClock(startingTime)
+		sender = fake.NewFakeMetricSender()
+		metrics.Initialize(sender)
+
+		q = quarantine.New(fakeClock)
+		container = executor.Container{Guid: "some-guid", State: executor.StateInvalid}
+	})
+
+	Describe("Observe", func() {
+		It("records the container with the time it was first seen", func() {
+			q.Observe(logger, container)
+			fakeClock.Increment(time.Minute)
+			q.Observe(logger, container)
+
+			Ω(q.Entries()).Should(Equal([]quarantine.Entry{{
+				Guid:      "some-guid",
+				State:     executor.StateInvalid,
+				FirstSeen: startingTime,
+				LastSeen:  startingTime.Add(time.Minute),
+			}}))
+		})
+
+		It("restarts the clock when the container changes state", func() {
+			q.Observe(logger, container)
+			fakeClock.Increment(time.Minute)
+
+			container.State = executor.StateCompleted
+			q.Observe(logger, container)
+
+			entries := q.Entries()
+			Ω(entries).Should(HaveLen(1))
+			Ω(entries[0].State).Should(Equal(executor.StateCompleted))
+			Ω(entries[0].FirstSeen).Should(Equal(startingTime.Add(time.Minute)))
+		})
+
+		It("emits the number of quarantined containers", func() {
+			q.Observe(logger, container)
+			q.Observe(logger, executor.Container{Guid: "other-guid", State: executor.StateInvalid})
+
+			Ω(sender.GetValue("RepQuarantinedContainers").Value).Should(Equal(float64(2)))
+		})
+	})
+
+	Describe("Release", func() {
+		It("forgets the container", func() {
+			q.Observe(logger, container)
+			q.Release(logger, container.Guid)
+
+			Ω(q.Entries()).Should(BeEmpty())
+			Ω(sender.GetValue("RepQuarantinedContainers").Value).Should(Equal(float64(0)))
+		})
+	})
+})
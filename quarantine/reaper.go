@@ -0,0 +1,149 @@
+package quarantine
+
+import (
+	"os"
+	"time"
+
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/rep"
+	"github.com/cloudfoundry-incubator/runtime-schema/bbs"
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+const TaskFailureReasonQuarantined = "container was quarantined in an invalid state"
+
+// Reaper stops and deletes containers that have stayed quarantined in the same
+// state for longer than the quarantine period, and cleans up the BBS record
+// they were backing.
+type Reaper struct {
+	logger         lager.Logger
+	quarantine     Quarantine
+	bbs            bbs.RepBBS
+	executorClient executor.Client
+	clock          clock.Clock
+	cellID         string
+	period         time.Duration
+	pollInterval   time.Duration
+}
+
+func NewReaper(
+	logger lager.Logger,
+	quarantine Quarantine,
+	bbs bbs.RepBBS,
+	executorClient executor.Client,
+	clock clock.Clock,
+	cellID string,
+	period time.Duration,
+	pollInterval time.Duration,
+) *Reaper {
+	return &Reaper{
+		logger:         logger,
+		quarantine:     quarantine,
+		bbs:            bbs,
+		executorClient: executorClient,
+		clock:          clock,
+		cellID:         cellID,
+		period:         period,
+		pollInterval:   pollInterval,
+	}
+}
+
+func (r *Reaper) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	logger := r.logger.Session("running-quarantine-reaper")
+	logger.Info("starting", lager.Data{
+		"period":   r.period.String(),
+		"interval": r.pollInterval.String(),
+	})
+	defer logger.Info("finished")
+
+	timer := r.clock.NewTimer(r.pollInterval)
+	defer timer.Stop()
+
+	close(ready)
+
+	for {
+		select {
+		case <-timer.C():
+		case signal := <-signals:
+			logger.Info("received-signal", lager.Data{"signal": signal.String()})
+			return nil
+		}
+
+		r.reap(logger)
+
+		timer.Reset(r.pollInterval)
+	}
+}
+
+func (r *Reaper) reap(logger lager.Logger) {
+	now := r.clock.Now()
+
+	for _, entry := range r.quarantine.Entries() {
+		if now.Sub(entry.FirstSeen) < r.period {
+			continue
+		}
+
+		r.reapEntry(logger.Session("reap", lager.Data{"container-guid": entry.Guid}), entry)
+	}
+}
+
+func (r *Reaper) reapEntry(logger lager.Logger, entry Entry) {
+	container, err := r.executorClient.GetContainer(entry.Guid)
+	if err == executor.ErrContainerNotFound {
+		logger.Info("container-already-gone")
+		r.quarantine.Release(logger, entry.Guid)
+		return
+	} else if err != nil {
+		logger.Error("failed-fetching-container", err)
+		return
+	}
+
+	if container.State != entry.State {
+		logger.Info("container-changed-state", lager.Data{"container-state": container.State})
+		r.quarantine.Release(logger, entry.Guid)
+		return
+	}
+
+	logger.Info("stopping-container")
+	err = r.executorClient.StopContainer(entry.Guid)
+	if err != nil && err != executor.ErrContainerNotFound {
+		logger.Error("failed-stopping-container", err)
+	}
+
+	logger.Info("deleting-container")
+	err = r.executorClient.DeleteContainer(entry.Guid)
+	if err != nil && err != executor.ErrContainerNotFound {
+		logger.Error("failed-deleting-container", err)
+		return
+	}
+
+	r.cleanUpBBS(logger, container)
+	r.quarantine.Release(logger, entry.Guid)
+}
+
+func (r *Reaper) cleanUpBBS(logger lager.Logger, container executor.Container) {
+	switch container.Tags[rep.LifecycleTag] {
+	case rep.LRPLifecycle:
+		lrpKey, err := rep.ActualLRPKeyFromContainer(container)
+		if err != nil {
+			logger.Error("failed-to-generate-lrp-key", err)
+			return
+		}
+
+		instanceKey, err := rep.ActualLRPInstanceKeyFromContainer(container, r.cellID)
+		if err != nil {
+			logger.Error("failed-to-generate-instance-key", err)
+			return
+		}
+
+		r.bbs.RemoveActualLRP(logger, lrpKey, instanceKey)
+		r.bbs.RemoveEvacuatingActualLRP(logger, lrpKey, instanceKey)
+
+	case rep.TaskLifecycle:
+		err := r.bbs.FailTask(logger, container.Guid, TaskFailureReasonQuarantined)
+		if err != nil {
+			logger.Error("failed-failing-task", err)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package quarantine_test
+
+import (
+	"os"
+	"time"
+
+	"github.com/cloudfoundry-incubator/executor"
+	efakes "github.com/cloudfoundry-incubator/executor/fakes"
+	"github.com/cloudfoundry-incubator/rep"
+	"github.com/cloudfoundry-incubator/rep/quarantine"
+	"github.com/cloudfoundry-incubator/rep/quarantine/fake_quarantine"
+	"github.com/cloudfoundry-incubator/runtime-schema/bbs/fake_bbs"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager/lagertest"
+	"github.com/tedsuo/ifrit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reaper", func() {
+	const (
+		cellID       = "cell-id"
+		period       = 10 * time.Minute
+		pollInterval = time.Minute
+	)
+
+	var (
+		logger         *lagertest.TestLogger
+		fakeClock      *fakeclock.FakeClock
+		fakeQuarantine *fake_quarantine.FakeQuarantine
+		fakeBBS        *fake_bbs.FakeRepBBS
+		executorClient *efakes.FakeClient
+		container      executor.Container
+		process        ifrit.Process
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		fakeQuarantine = new(fake_quarantine.FakeQuarantine)
+		fakeBBS = new(fake_bbs.FakeRepBBS)
+		executorClient = new(efakes.FakeClient)
+
+		container = executor.Container{
+			Guid:  rep.LRPContainerGuid("process-guid", "instance-guid"),
+			State: executor.StateInvalid,
+			Tags: executor.Tags{
+				rep.LifecycleTag:    rep.LRPLifecycle,
+				rep.DomainTag:       "domain",
+				rep.ProcessGuidTag:  "process-guid",
+				rep.InstanceGuidTag: "instance-guid",
+				rep.ProcessIndexTag: "0",
+			},
+		}
+		executorClient.GetContainerReturns(container, nil)
+	})
+
+	JustBeforeEach(func() {
+		reaper := quarantine.NewReaper(logger, fakeQuarantine, fakeBBS, executorClient, fakeClock, cellID, period, pollInterval)
+		process = ifrit.Invoke(reaper)
+	})
+
+	AfterEach(func() {
+		process.Signal(os.Interrupt)
+		Eventually(process.Wait()).Should(Receive())
+	})
+
+	poll := func() {
+		fakeClock.Increment(pollInterval)
+		Eventually(fakeQuarantine.EntriesCallCount).Should(BeNumerically(">", 0))
+	}
+
+	Context("when a container has been quarantined longer than the period", func() {
+		BeforeEach(func() {
+			fakeQuarantine.EntriesReturns([]quarantine.Entry{{
+				Guid:      container.Guid,
+				State:     executor.StateInvalid,
+				FirstSeen: fakeClock.Now().Add(-period),
+			}})
+		})
+
+		It("stops and deletes the container", func() {
+			poll()
+
+			Eventually(executorClient.DeleteContainerCallCount).Should(Equal(1))
+			Ω(executorClient.StopContainerCallCount()).Should(Equal(1))
+			Ω(executorClient.StopContainerArgsForCall(0)).Should(Equal(container.Guid))
+			Ω(executorClient.DeleteContainerArgsForCall(0)).Should(Equal(container.Guid))
+		})
+
+		It("removes the actual LRP records it was backing", func() {
+			poll()
+
+			Eventually(fakeBBS.RemoveActualLRPCallCount).Should(Equal(1))
+			_, lrpKey, instanceKey := fakeBBS.RemoveActualLRPArgsForCall(0)
+			Ω(lrpKey).Should(Equal(models.NewActualLRPKey("process-guid", 0, "domain")))
+			Ω(instanceKey).Should(Equal(models.NewActualLRPInstanceKey("instance-guid", cellID)))
+			Ω(fakeBBS.RemoveEvacuatingActualLRPCallCount()).Should(Equal(1))
+		})
+
+		It("releases the container", func() {
+			poll()
+
+			Eventually(fakeQuarantine.ReleaseCallCount).Should(Equal(1))
+		})
+
+		Context("when the container is a task", func() {
+			BeforeEach(func() {
+				container.Tags = executor.Tags{rep.LifecycleTag: rep.TaskLifecycle}
+				executorClient.GetContainerReturns(container, nil)
+			})
+
+			It("fails the task", func() {
+				poll()
+
+				Eventually(fakeBBS.FailTaskCallCount).Should(Equal(1))
+				_, taskGuid, reason := fakeBBS.FailTaskArgsForCall(0)
+				Ω(taskGuid).Should(Equal(container.Guid))
+				Ω(reason).Should(Equal(quarantine.TaskFailureReasonQuarantined))
+			})
+		})
+
+		Context("when the container has since changed state", func() {
+			BeforeEach(func() {
+				container.State = executor.StateRunning
+				executorClient.GetContainerReturns(container, nil)
+			})
+
+			It("releases the container without deleting it", func() {
+				poll()
+
+				Eventually(fakeQuarantine.ReleaseCallCount).Should(Equal(1))
+				Ω(executorClient.DeleteContainerCallCount()).Should(Equal(0))
+			})
+		})
+
+		Context("when the container no longer exists", func() {
+			BeforeEach(func() {
+				executorClient.GetContainerReturns(executor.Container{}, executor.ErrContainerNotFound)
+			})
+
+			It("releases the container", func() {
+				poll()
+
+				Eventually(fakeQuarantine.ReleaseCallCount).Should(Equal(1))
+				Ω(executorClient.DeleteContainerCallCount()).Should(Equal(0))
+			})
+		})
+	})
+
+	Context("when a container has been quarantined for less than the period", func() {
+		BeforeEach(func() {
+			fakeQuarantine.EntriesReturns([]quarantine.Entry{{
+				Guid:      container.Guid,
+				State:     executor.StateInvalid,
+				FirstSeen: fakeClock.Now(),
+			}})
+		})
+
+		It("leaves the container alone", func() {
+			poll()
+
+			Consistently(executorClient.DeleteContainerCallCount).Should(Equal(0))
+			Ω(fakeQuarantine.ReleaseCallCount()).Should(Equal(0))
+		})
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"strconv"
+	"strings"
 
 	"github.com/cloudfoundry-incubator/executor"
 	"github.com/cloudfoundry-incubator/runtime-schema/models"
@@ -20,6 +21,12 @@ const (
 	ProcessGuidTag  = "process-guid"
 	InstanceGuidTag = "instance-guid"
 	ProcessIndexTag = "process-index"
+
+	ResultFileSeparator      = ","
+	OptionalResultFilePrefix = "?"
+
+	DiagnosticFilesTag    = "diagnostic-files"
+	DiagnosticFilesEnvVar = "DIAGNOSTIC_FILES"
 )
 
 var (
@@ -99,3 +106,67 @@ func UnmarshalStackPathMap(payload []byte) (StackPathMap, error) {
 	err := json.Unmarshal(payload, &stackPathMap)
 	return stackPathMap, err
 }
+
+type ResultFile struct {
+	Path     string
+	Optional bool
+}
+
+// ParseResultFiles splits a task's result file specification into its files.
+// Multiple files are separated by commas, and a file prefixed with "?" is
+// optional: its absence does not fail the task.
+func ParseResultFiles(spec string) []ResultFile {
+	resultFiles := []ResultFile{}
+	for _, path := range strings.Split(spec, ResultFileSeparator) {
+		path = strings.TrimSpace(path)
+
+		optional := strings.HasPrefix(path, OptionalResultFilePrefix)
+		path = strings.TrimPrefix(path, OptionalResultFilePrefix)
+
+		if path == "" {
+			continue
+		}
+
+		resultFiles = append(resultFiles, ResultFile{Path: path, Optional: optional})
+	}
+
+	return resultFiles
+}
+
+// DiagnosticFilesFromEnv returns the files a task has asked to have captured
+// should it fail, as given by its DIAGNOSTIC_FILES environment variable.
+func DiagnosticFilesFromEnv(env []models.EnvironmentVariable) string {
+	for _, variable := range env {
+		if variable.Name == DiagnosticFilesEnvVar {
+			return variable.Value
+		}
+	}
+	return ""
+}
+
+// WithoutDiagnosticFiles returns the environment without the DIAGNOSTIC_FILES
+// variable, which is meant for the rep rather than the task's processes.
+func WithoutDiagnosticFiles(env []models.EnvironmentVariable) []models.EnvironmentVariable {
+	stripped := make([]models.EnvironmentVariable, 0, len(env))
+	for _, variable := range env {
+		if variable.Name != DiagnosticFilesEnvVar {
+			stripped = append(stripped, variable)
+		}
+	}
+	return stripped
+}
+
+// ParseDiagnosticFiles splits a comma-separated list of diagnostic files.
+func ParseDiagnosticFiles(spec string) []string {
+	paths := []string{}
+	for _, path := range strings.Split(spec, ResultFileSeparator) {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		paths = append(paths, path)
+	}
+
+	return paths
+}
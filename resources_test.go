@@ -262,4 +262,53 @@ var _ = Describe("Resources", func() {
 			Ω(err).Should(MatchError(ContainSubstring("unmarshal")))
 		})
 	})
+
+	Describe("ParseResultFiles", func() {
+		It("parses a single result file", func() {
+			Ω(rep.ParseResultFiles("/tmp/result")).Should(Equal([]rep.ResultFile{
+				{Path: "/tmp/result"},
+			}))
+		})
+
+		It("parses multiple result files, some of them optional", func() {
+			Ω(rep.ParseResultFiles("/tmp/result, ?/tmp/extra")).Should(Equal([]rep.ResultFile{
+				{Path: "/tmp/result"},
+				{Path: "/tmp/extra", Optional: true},
+			}))
+		})
+
+		It("returns no result files for an empty specification", func() {
+			Ω(rep.ParseResultFiles("")).Should(BeEmpty())
+		})
+	})
+
+	Describe("DiagnosticFilesFromEnv", func() {
+		It("returns the value of the diagnostic files variable", func() {
+			env := []models.EnvironmentVariable{
+				{Name: "FOO", Value: "BAR"},
+				{Name: rep.DiagnosticFilesEnvVar, Value: "/tmp/a.log,/tmp/core"},
+			}
+			Ω(rep.DiagnosticFilesFromEnv(env)).Should(Equal("/tmp/a.log,/tmp/core"))
+		})
+
+		It("returns nothing when the variable is not set", func() {
+			Ω(rep.DiagnosticFilesFromEnv(nil)).Should(BeEmpty())
+		})
+	})
+
+	Describe("WithoutDiagnosticFiles", func() {
+		It("drops only the diagnostic files variable", func() {
+			env := []models.EnvironmentVariable{
+				{Name: "FOO", Value: "BAR"},
+				{Name: rep.DiagnosticFilesEnvVar, Value: "/tmp/a.log"},
+			}
+			Ω(rep.WithoutDiagnosticFiles(env)).Should(Equal([]models.EnvironmentVariable{{Name: "FOO", Value: "BAR"}}))
+		})
+	})
+
+	Describe("ParseDiagnosticFiles", func() {
+		It("splits the files", func() {
+			Ω(rep.ParseDiagnosticFiles("/tmp/a.log, /tmp/core,")).Should(Equal([]string{"/tmp/a.log", "/tmp/core"}))
+		})
+	})
 })
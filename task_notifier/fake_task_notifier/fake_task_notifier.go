@@ -0,0 +1,44 @@
+// This file was generated by counterfeiter
+package fake_task_notifier
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/rep/task_notifier"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeTaskNotifier struct {
+	NotifyStub        func(logger lager.Logger, notification task_notifier.Notification)
+	notifyMutex       sync.RWMutex
+	notifyArgsForCall []struct {
+		logger       lager.Logger
+		notification task_notifier.Notification
+	}
+}
+
+func (fake *FakeTaskNotifier) Notify(logger lager.Logger, notification task_notifier.Notification) {
+	fake.notifyMutex.Lock()
+	fake.notifyArgsForCall = append(fake.notifyArgsForCall, struct {
+		logger       lager.Logger
+		notification task_notifier.Notification
+	}{logger, notification})
+	fake.notifyMutex.Unlock()
+	if fake.NotifyStub != nil {
+		fake.NotifyStub(logger, notification)
+	}
+}
+
+func (fake *FakeTaskNotifier) NotifyCallCount() int {
+	fake.notifyMutex.RLock()
+	defer fake.notifyMutex.RUnlock()
+	return len(fake.notifyArgsForCall)
+}
+
+func (fake *FakeTaskNotifier) NotifyArgsForCall(i int) (lager.Logger, task_notifier.Notification) {
+	fake.notifyMutex.RLock()
+	defer fake.notifyMutex.RUnlock()
+	return fake.notifyArgsForCall[i].logger, fake.notifyArgsForCall[i].notification
+}
+
+var _ task_notifier.TaskNotifier = new(FakeTaskNotifier)
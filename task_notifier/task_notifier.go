@@ -0,0 +1,182 @@
+package task_notifier
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+const SignatureHeader = "X-Rep-Signature"
+
+// Notification describes a task that has been completed or failed on the cell.
+type Notification struct {
+	TaskGuid      string `json:"task_guid"`
+	Domain        string `json:"domain"`
+	Failed        bool   `json:"failed"`
+	FailureReason string `json:"failure_reason"`
+	ResultSize    int    `json:"result_size"`
+}
+
+type Config struct {
+	URLs          []string
+	Secret        string
+	QueueSize     int
+	MaxAttempts   int
+	RetryInterval time.Duration
+}
+
+//go:generate counterfeiter -o fake_task_notifier/fake_task_notifier.go . TaskNotifier
+type TaskNotifier interface {
+	// Notify queues the notification for delivery to each URL without
+	// blocking. It is dropped for any URL whose queue is full.
+	Notify(logger lager.Logger, notification Notification)
+}
+
+// Notifier delivers queued notifications to every configured URL while it is
+// running. Each URL has its own queue, so that a slow or failing URL does not
+// hold up delivery to the others.
+type Notifier struct {
+	logger lager.Logger
+	client *http.Client
+	clock  clock.Clock
+	config Config
+	queues map[string]chan Notification
+}
+
+func New(logger lager.Logger, client *http.Client, clock clock.Clock, config Config) *Notifier {
+	queues := make(map[string]chan Notification, len(config.URLs))
+	for _, url := range config.URLs {
+		queues[url] = make(chan Notification, config.QueueSize)
+	}
+
+	return &Notifier{
+		logger: logger.Session("task-notifier"),
+		client: client,
+		clock:  clock,
+		config: config,
+		queues: queues,
+	}
+}
+
+func (n *Notifier) Notify(logger lager.Logger, notification Notification) {
+	for url, queue := range n.queues {
+		select {
+		case queue <- notification:
+		default:
+			logger.Info("dropped-task-notification", lager.Data{"task-guid": notification.TaskGuid, "url": url})
+		}
+	}
+}
+
+func (n *Notifier) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	logger := n.logger.Session("running")
+	logger.Info("started")
+	defer logger.Info("finished")
+
+	stop := make(chan struct{})
+	wg := new(sync.WaitGroup)
+
+	for url, queue := range n.queues {
+		wg.Add(1)
+		go func(url string, queue <-chan Notification) {
+			defer wg.Done()
+			n.deliverTo(logger.Session("deliver", lager.Data{"url": url}), url, queue, stop)
+		}(url, queue)
+	}
+
+	close(ready)
+
+	signal := <-signals
+	logger.Info("signaled", lager.Data{"signal": signal.String()})
+
+	close(stop)
+	wg.Wait()
+
+	return nil
+}
+
+func (n *Notifier) deliverTo(logger lager.Logger, url string, queue <-chan Notification, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case notification := <-queue:
+			n.deliver(logger.WithData(lager.Data{"task-guid": notification.TaskGuid}), url, notification, stop)
+		}
+	}
+}
+
+func (n *Notifier) deliver(logger lager.Logger, url string, notification Notification, stop <-chan struct{}) {
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		logger.Error("failed-marshaling-notification", err)
+		return
+	}
+	signature := Sign(n.config.Secret, payload)
+
+	maxAttempts := n.config.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := n.postOnce(url, payload, signature)
+		if err == nil {
+			logger.Debug("succeeded-posting-notification")
+			return
+		}
+
+		if attempt >= maxAttempts {
+			logger.Error("failed-posting-notification", err, lager.Data{"attempts": attempt})
+			return
+		}
+
+		logger.Info("retrying-posting-notification", lager.Data{"attempt": attempt, "error": err.Error()})
+
+		timer := n.clock.NewTimer(n.config.RetryInterval)
+		select {
+		case <-timer.C():
+		case <-stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+func (n *Notifier) postOnce(url string, payload []byte, signature string) error {
+	request, err := http.NewRequest("POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(SignatureHeader, signature)
+
+	response, err := n.client.Do(request)
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code: %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+// Sign returns the hex-encoded HMAC-SHA256 of the payload, keyed by secret.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,13 @@
+package task_notifier_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestTaskNotifier(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TaskNotifier Suite")
+}
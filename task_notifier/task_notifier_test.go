@@ -0,0 +1,171 @@
+package task_notifier_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/cloudfoundry-incubator/rep/task_notifier"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager/lagertest"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TaskNotifier", func() {
+	type request struct {
+		body      []byte
+		signature string
+	}
+
+	var (
+		logger       *lagertest.TestLogger
+		fakeClock    *fakeclock.FakeClock
+		config       task_notifier.Config
+		server       *httptest.Server
+		requests     chan request
+		statusCodes  chan int
+		notifier     *task_notifier.Notifier
+		process      ifrit.Process
+		notification task_notifier.Notification
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+
+		requests = make(chan request, 10)
+		statusCodes = make(chan int, 10)
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			requests <- request{body: body, signature: r.Header.Get(task_notifier.SignatureHeader)}
+
+			select {
+			case code := <-statusCodes:
+				w.WriteHeader(code)
+			default:
+			}
+		}))
+
+		config = task_notifier.Config{
+			URLs:          []string{server.URL},
+			Secret:        "some-secret",
+			QueueSize:     10,
+			MaxAttempts:   2,
+			RetryInterval: time.Second,
+		}
+
+		notification = task_notifier.Notification{
+			TaskGuid:      "some-task-guid",
+			Domain:        "some-domain",
+			Failed:        true,
+			FailureReason: "some-reason",
+		}
+	})
+
+	JustBeforeEach(func() {
+		notifier = task_notifier.New(logger, http.DefaultClient, fakeClock, config)
+		process = ginkgomon.Invoke(notifier)
+	})
+
+	AfterEach(func() {
+		ginkgomon.Interrupt(process)
+		server.Close()
+	})
+
+	It("posts the signed notification", func() {
+		notifier.Notify(logger, notification)
+
+		var received request
+		Eventually(requests).Should(Receive(&received))
+
+		var decoded task_notifier.Notification
+		Ω(json.Unmarshal(received.body, &decoded)).Should(Succeed())
+		Ω(decoded).Should(Equal(notification))
+		Ω(received.signature).Should(Equal(task_notifier.Sign("some-secret", received.body)))
+	})
+
+	Context("when delivery fails", func() {
+		BeforeEach(func() {
+			statusCodes <- http.StatusInternalServerError
+			statusCodes <- http.StatusInternalServerError
+			statusCodes <- http.StatusInternalServerError
+		})
+
+		It("retries up to the maximum number of attempts", func() {
+			notifier.Notify(logger, notification)
+
+			Eventually(requests).Should(Receive())
+			Eventually(func() int {
+				fakeClock.Increment(time.Second)
+				return len(requests)
+			}).Should(Equal(1))
+			Consistently(func() int {
+				fakeClock.Increment(time.Second)
+				return len(requests)
+			}).Should(Equal(1))
+		})
+	})
+
+	Context("when one of several URLs does not respond", func() {
+		var (
+			slowServer *httptest.Server
+			release    chan struct{}
+		)
+
+		BeforeEach(func() {
+			release = make(chan struct{})
+			slowServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				<-release
+			}))
+
+			config.URLs = []string{slowServer.URL, server.URL}
+		})
+
+		AfterEach(func() {
+			close(release)
+			slowServer.Close()
+		})
+
+		It("still delivers to the others", func() {
+			notifier.Notify(logger, notification)
+			notifier.Notify(logger, notification)
+
+			Eventually(requests).Should(Receive())
+			Eventually(requests).Should(Receive())
+		})
+	})
+
+	Context("when the queue is full", func() {
+		BeforeEach(func() {
+			config.QueueSize = 0
+		})
+
+		It("drops the notification without blocking", func() {
+			done := make(chan struct{})
+			go func() {
+				notifier.Notify(logger, notification)
+				notifier.Notify(logger, notification)
+				close(done)
+			}()
+
+			Eventually(done).Should(BeClosed())
+		})
+	})
+
+	Context("when no URLs are configured", func() {
+		BeforeEach(func() {
+			config.URLs = nil
+		})
+
+		It("does not post anything", func() {
+			notifier.Notify(logger, notification)
+			Consistently(requests).ShouldNot(Receive())
+		})
+	})
+})